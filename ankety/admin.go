@@ -0,0 +1,149 @@
+package ankety
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"talant/auth"
+	"talant/moderation"
+)
+
+// listVisible - анкеты для публичных страниц, без скрытых модератором
+func listVisible() ([]Ankety, error) {
+	anketyList, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	visible := make([]Ankety, 0, len(anketyList))
+	for _, a := range anketyList {
+		if a.Moderation == nil {
+			visible = append(visible, a)
+		}
+	}
+	return visible, nil
+}
+
+// removePhoto удаляет файл фотографии анкеты, если он есть
+func removePhoto(a Ankety) {
+	if a.Photo == "" {
+		return
+	}
+	photoPath := filepath.Join(uploadsDir, a.Photo)
+	if _, err := os.Stat(photoPath); err == nil {
+		os.Remove(photoPath)
+	}
+}
+
+// AdminListHandler - все анкеты, включая скрытые (GET /admin/ankety?status=hidden|visible)
+func AdminListHandler(w http.ResponseWriter, r *http.Request) {
+	filter, ok := moderation.FilterFromRequest(r)
+	if !ok {
+		http.Error(w, "Invalid status", http.StatusBadRequest)
+		return
+	}
+	anketyList, err := store.List()
+	if err != nil {
+		http.Error(w, "Error loading ankety", http.StatusInternalServerError)
+		return
+	}
+	list := []Ankety{}
+	for _, a := range anketyList {
+		if filter.Match(a.Moderation) {
+			list = append(list, a)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// moderate загружает анкету из пути, меняет ее и сохраняет
+func moderate(w http.ResponseWriter, r *http.Request, change func(*Ankety)) {
+	anketa, err := store.Get(r.PathValue("id"))
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, "Anketa not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error loading ankety", http.StatusInternalServerError)
+		return
+	}
+	change(&anketa)
+	if err := store.Update(anketa); err != nil {
+		http.Error(w, "Error saving data", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(anketa)
+}
+
+// HideHandler скрывает анкету с указанием причины (POST /admin/ankety/{id}/hide, поле reason)
+func HideHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	reason := r.FormValue("reason")
+	if reason == "" {
+		http.Error(w, "Reason is required", http.StatusBadRequest)
+		return
+	}
+	moderate(w, r, func(a *Ankety) {
+		a.Moderation = moderation.New(reason, user.ID, false)
+		fmt.Printf("Анкета скрыта модератором: ID=%s, причина: %s\n", a.Id, reason)
+	})
+}
+
+// RestoreHandler возвращает скрытую анкету (POST /admin/ankety/{id}/restore)
+func RestoreHandler(w http.ResponseWriter, r *http.Request) {
+	moderate(w, r, func(a *Ankety) {
+		a.Moderation = nil
+	})
+}
+
+// PurgeHandler удаляет любую анкету вместе с фото (DELETE /admin/ankety/{id})
+func PurgeHandler(w http.ResponseWriter, r *http.Request) {
+	anketa, err := store.Get(r.PathValue("id"))
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, "Anketa not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error loading ankety", http.StatusInternalServerError)
+		return
+	}
+	err = deleteAnketa(anketa)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, "Anketa not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error saving data", http.StatusInternalServerError)
+		return
+	}
+	fmt.Printf("Анкета удалена модератором: ID=%s\n", anketa.Id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetUserBan скрывает анкету забаненного пользователя (ban != nil) или
+// возвращает ее, когда бан сняли (ban == nil). Подключается через auth.OnBan.
+func SetUserBan(userID string, ban *moderation.State) error {
+	anketa, err := store.GetByUser(userID)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	switch {
+	case ban != nil && anketa.Moderation == nil:
+		anketa.Moderation = ban
+	case ban == nil && anketa.Moderation != nil && anketa.Moderation.Ban:
+		anketa.Moderation = nil
+	default:
+		return nil
+	}
+	return store.Update(anketa)
+}
@@ -2,6 +2,7 @@ package ankety
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -9,87 +10,97 @@ import (
 	"path/filepath"
 	"strings"
 	"talant/auth"
+	"talant/experience"
+	"talant/moderation"
+	"talant/salary"
+	"talant/skills"
 
 	"github.com/google/uuid"
 )
 
 type Ankety struct {
-	Id          string `json:"id"`
-	UserId      string `json:"user_id"`
-	Name        string `json:"name"`
-	Gender      string `json:"gender"`
-	Age         string `json:"age"`
-	Job         string `json:"job"`
-	School      string `json:"school"`
-	Skills      string `json:"skills"`
-	Photo       string `json:"photo"`
-	Position    string `json:"position"`
-	Salary      string `json:"salary"`
-	Experience  string `json:"experience"`
-	City        string `json:"city"`
-	Jobtype     string `json:"jobtype"`
-	Description string `json:"description,omitempty"`
-	Telegram    string `json:"telegram,omitempty"`
+	Id       string      `json:"id"`
+	UserId   string      `json:"user_id"`
+	Name     string      `json:"name"`
+	Gender   string      `json:"gender"`
+	Age      string      `json:"age"`
+	Job      string      `json:"job"`
+	School   string      `json:"school"`
+	Skills   skills.List `json:"skills"` // ID навыков из словаря skills
+	Photo    string      `json:"photo"`
+	Position string      `json:"position"`
+	// Salary - ожидаемая зарплата текстом; SalaryRange - она же для сравнения
+	// и фильтров (нет, если в тексте не нашлось сумм)
+	Salary      string         `json:"salary"`
+	SalaryRange *salary.Salary `json:"salary_range,omitempty"`
+	// Experience - опыт текстом; ExperienceRange - он же в годах для фильтров,
+	// Seniority - уровень из формы или из должности
+	Experience      string            `json:"experience"`
+	ExperienceRange *experience.Range `json:"experience_range,omitempty"`
+	Seniority       experience.Level  `json:"seniority,omitempty"`
+	City            string            `json:"city"`
+	Jobtype         string            `json:"jobtype"`
+	Description     string            `json:"description,omitempty"`
+	Telegram        string            `json:"telegram,omitempty"`
+
+	// Moderation - анкета скрыта модератором; причину видит владелец в /api/ankety/my
+	Moderation *moderation.State `json:"moderation,omitempty"`
 }
 
-var anketybase string = "ankety.json"
-
-func LoadUser() ([]Ankety, error) {
-	// Проверяем существование файла
-	if _, err := os.Stat(anketybase); os.IsNotExist(err) {
-		// Создаем файл с пустым массивом
-		fmt.Println("Файл ankety.json не найден, создаем новый...")
-		emptyData := []Ankety{}
-		data, err := json.MarshalIndent(emptyData, "", "  ")
-		if err != nil {
-			return nil, err
-		}
-		err = os.WriteFile(anketybase, data, 0644)
-		if err != nil {
-			return nil, err
-		}
-		fmt.Println("Файл ankety.json успешно создан")
-		return emptyData, nil
-	}
+// anketaJSON - анкета в ответах: ее поля и skill_names - названия навыков для
+// показа (skills.Name), чтобы страницам не переводить ID самим
+type anketaJSON struct {
+	plainAnketa
+	SkillNames []string `json:"skill_names"`
+}
 
-	data, err := os.ReadFile(anketybase)
-	if err != nil {
-		fmt.Printf("Ошибка чтения файла %s: %v\n", anketybase, err)
-		return nil, err
-	}
+// plainAnketa - Ankety без MarshalJSON
+type plainAnketa Ankety
 
-	// Проверяем, не пустой ли файл
-	if len(data) == 0 {
-		return []Ankety{}, nil
-	}
+func (a Ankety) view() anketaJSON {
+	return anketaJSON{plainAnketa(a), a.Skills.Names()}
+}
 
-	var ankety []Ankety
-	err = json.Unmarshal(data, &ankety)
-	if err != nil {
-		fmt.Printf("Ошибка разбора JSON из файла %s: %v\n", anketybase, err)
-		return nil, err
-	}
-	fmt.Printf("Загружено %d анкет из файла\n", len(ankety))
-	return ankety, nil
+func (a Ankety) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.view())
 }
 
-// Обновите CreateHandler
-func SaveAnkety(anketyList []Ankety) error {
-	fmt.Printf("Сохранение %d анкет в файл...\n", len(anketyList))
-	updatedData, err := json.MarshalIndent(anketyList, "", "  ")
-	if err != nil {
-		fmt.Printf("Ошибка кодирования данных: %v\n", err)
-		return err
-	}
+var (
+	uploadsDir          = "uploads" // Каталог загрузок; фото лежат в uploadsDir/photos
+	maxUploadSize int64 = 10 << 20  // Максимальный размер фото
+)
 
-	err = os.WriteFile(anketybase, updatedData, 0644)
-	if err != nil {
-		fmt.Printf("Ошибка записи в файл %s: %v\n", anketybase, err)
-		return err
+// Event - что случилось с анкетой; передается хукам OnChange
+type Event string
+
+const (
+	EventCreated Event = "created"
+	EventUpdated Event = "updated"
+	EventDeleted Event = "deleted"
+)
+
+// changeHooks вызываются после создания, правки и удаления анкеты (вебхуки).
+// ankety не знает, кто на них подписан, они подключаются через OnChange.
+var changeHooks []func(e Event, a Ankety) error
+
+// OnChange подключает обработчик изменений анкет
+func OnChange(hook func(e Event, a Ankety) error) {
+	changeHooks = append(changeHooks, hook)
+}
+
+// runChangeHooks вызывает хуки; их ошибки только пишутся в лог - анкета уже сохранена
+func runChangeHooks(e Event, a Ankety) {
+	for _, hook := range changeHooks {
+		if err := hook(e, a); err != nil {
+			fmt.Printf("Ошибка обработчика анкеты %s (%s): %v\n", a.Id, e, err)
+		}
 	}
+}
 
-	fmt.Println("Анкеты успешно сохранены в файл")
-	return nil
+// Configure задает каталог загрузок и лимит размера фото
+func Configure(uploads string, maxUpload int64) {
+	uploadsDir = uploads
+	maxUploadSize = maxUpload
 }
 
 func ShowAnketyHandler(w http.ResponseWriter, r *http.Request) {
@@ -98,7 +109,7 @@ func ShowAnketyHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	anketyList, err := LoadUser()
+	anketyList, err := listVisible()
 	if err != nil {
 		http.Error(w, "Error loading ankety", http.StatusInternalServerError)
 		return
@@ -143,15 +154,15 @@ func CreateHandler(w http.ResponseWriter, r *http.Request) {
 	age := r.FormValue("age")
 	job := r.FormValue("job")
 	school := r.FormValue("school")
-	skills := r.FormValue("skills")
+	skillsText := r.FormValue("skills")
 	description := r.FormValue("description")
 	telegram := r.FormValue("telegram")
 
 	fmt.Printf("Поля анкеты: name='%s', gender='%s', age='%s', job='%s', school='%s', skills='%s', telegram='%s'\n",
-		name, gender, age, job, school, skills, telegram)
+		name, gender, age, job, school, skillsText, telegram)
 
 	// Проверяем только обязательные поля
-	if name == "" || age == "" || job == "" || school == "" || gender == "" || skills == "" {
+	if name == "" || age == "" || job == "" || school == "" || gender == "" || skillsText == "" {
 		errorMsg := "Missing required fields: "
 		if name == "" {
 			errorMsg += "name "
@@ -168,7 +179,7 @@ func CreateHandler(w http.ResponseWriter, r *http.Request) {
 		if gender == "" {
 			errorMsg += "gender "
 		}
-		if skills == "" {
+		if skillsText == "" {
 			errorMsg += "skills "
 		}
 		fmt.Println("Ошибка: " + errorMsg)
@@ -176,72 +187,79 @@ func CreateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Получаем userID из токена
-	cookie, err := r.Cookie("auth_token")
+	salaryText, salaryRange, err := salary.Read(r.Form)
 	if err != nil {
-		fmt.Println("Ошибка: отсутствует токен авторизации")
-		http.Error(w, "Unauthorized: missing token", http.StatusUnauthorized)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-
-	userID, username, err := auth.ValidateJWT(cookie.Value)
+	anketaSkills, err := skills.Read(r.Form)
 	if err != nil {
-		fmt.Printf("Ошибка валидации токена: %v\n", err)
-		http.Error(w, "Unauthorized: invalid token", http.StatusUnauthorized)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-
-	fmt.Printf("Пользователь: ID=%s, username=%s\n", userID, username)
-
-	anketyList, err := LoadUser()
+	if len(anketaSkills) == 0 {
+		http.Error(w, "Missing required fields: skills", http.StatusBadRequest)
+		return
+	}
+	experienceText, experienceRange, err := experience.Read(r.Form)
 	if err != nil {
-		fmt.Printf("Ошибка загрузки анкет: %v\n", err)
-		http.Error(w, "Error loading ankety", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	seniority, err := experience.ReadLevel(r.Form, r.FormValue("position"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Проверяем, существует ли уже анкета для этого пользователя
-	for _, a := range anketyList {
-		if a.UserId == userID {
-			fmt.Printf("Анкета для пользователя %s уже существует\n", userID)
-			// Возвращаем ошибку или можно обновить существующую
-			http.Error(w, "Ankety already exists for this user", http.StatusBadRequest)
-			return
-		}
+	// Пользователь уже проверен auth.RequireAuth
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
 	}
+	userID, username := user.ID, user.Username
+
+	fmt.Printf("Пользователь: ID=%s, username=%s\n", userID, username)
 
 	// Создаем новую анкету
 	newID := uuid.New().String()
 	anketa := Ankety{
-		Id:          newID,
-		UserId:      userID,
-		Name:        name,
-		Gender:      gender,
-		Age:         age,
-		Job:         job,
-		School:      school,
-		Skills:      skills,
-		Description: description,
-		Photo:       "",
-		City:        r.FormValue("city"),
-		Position:    r.FormValue("position"),
-		Salary:      r.FormValue("salary"),
-		Experience:  r.FormValue("experience"),
-		Jobtype:     r.FormValue("jobtype"),
-		Telegram:    telegram,
+		Id:              newID,
+		UserId:          userID,
+		Name:            name,
+		Gender:          gender,
+		Age:             age,
+		Job:             job,
+		School:          school,
+		Skills:          anketaSkills,
+		Description:     description,
+		Photo:           "",
+		City:            r.FormValue("city"),
+		Position:        r.FormValue("position"),
+		Salary:          salaryText,
+		SalaryRange:     salaryRange,
+		Experience:      experienceText,
+		ExperienceRange: experienceRange,
+		Seniority:       seniority,
+		Jobtype:         r.FormValue("jobtype"),
+		Telegram:        telegram,
 	}
 
 	fmt.Printf("Создана новая анкета: ID=%s, UserID=%s, Name=%s\n", newID, userID, name)
 
-	anketyList = append(anketyList, anketa)
-
-	// Сохраняем анкеты
-	err = SaveAnkety(anketyList)
+	// Сохраняем анкету; хранилище само проверяет, что анкеты у пользователя еще нет
+	err = store.Create(anketa)
+	if errors.Is(err, ErrAlreadyExists) {
+		fmt.Printf("Анкета для пользователя %s уже существует\n", userID)
+		http.Error(w, "Ankety already exists for this user", http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		fmt.Printf("Ошибка сохранения анкет: %v\n", err)
 		http.Error(w, "Error writing data file", http.StatusInternalServerError)
 		return
 	}
+	runChangeHooks(EventCreated, anketa)
 
 	// Возвращаем успешный ответ
 	w.Header().Set("Content-Type", "application/json")
@@ -277,81 +295,89 @@ func UpdateAnketyHandler(w http.ResponseWriter, r *http.Request) {
 	age := r.FormValue("age")
 	job := r.FormValue("job")
 	school := r.FormValue("school")
-	skills := r.FormValue("skills")
+	skillsText := r.FormValue("skills")
 	description := r.FormValue("description")
 	telegram := r.FormValue("telegram")
 
 	fmt.Printf("Обновление анкеты ID=%s: name='%s', gender='%s', age='%s', job='%s', school='%s', skills='%s', telegram='%s'\n",
-		id, name, gender, age, job, school, skills, telegram)
+		id, name, gender, age, job, school, skillsText, telegram)
 
 	// Проверяем только обязательные поля (telegram теперь необязателен)
-	if id == "" || name == "" || gender == "" || age == "" || job == "" || school == "" || skills == "" {
+	if id == "" || name == "" || gender == "" || age == "" || job == "" || school == "" || skillsText == "" {
 		http.Error(w, "Missing required fields", http.StatusBadRequest)
 		return
 	}
 
-	// Проверяем авторизацию
-	cookie, err := r.Cookie("auth_token")
+	salaryText, salaryRange, err := salary.Read(r.Form)
 	if err != nil {
-		http.Error(w, "Unauthorized: missing token", http.StatusUnauthorized)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	userID, _, err := auth.ValidateJWT(cookie.Value)
+	anketaSkills, err := skills.Read(r.Form)
 	if err != nil {
-		http.Error(w, "Unauthorized: invalid token", http.StatusUnauthorized)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-
-	// Загружаем все анкеты
-	anketyList, err := LoadUser()
+	if len(anketaSkills) == 0 {
+		http.Error(w, "Missing required fields: skills", http.StatusBadRequest)
+		return
+	}
+	experienceText, experienceRange, err := experience.Read(r.Form)
 	if err != nil {
-		http.Error(w, "Error loading ankety", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	seniority, err := experience.ReadLevel(r.Form, r.FormValue("position"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Ищем анкету для обновления
-	found := false
-	for i, a := range anketyList {
-		if a.Id == id && a.UserId == userID {
-			// Сохраняем существующее фото, если оно есть
-			photo := anketyList[i].Photo
-
-			// Обновляем данные анкеты
-			anketyList[i].Name = name
-			anketyList[i].Gender = gender
-			anketyList[i].Age = age
-			anketyList[i].Job = job
-			anketyList[i].School = school
-			anketyList[i].Skills = skills
-			anketyList[i].Photo = photo // Сохраняем старое фото
-			anketyList[i].Description = description
-			anketyList[i].City = r.FormValue("city")
-			anketyList[i].Position = r.FormValue("position")
-			anketyList[i].Salary = r.FormValue("salary")
-			anketyList[i].Experience = r.FormValue("experience")
-			anketyList[i].Jobtype = r.FormValue("jobtype")
-			anketyList[i].Telegram = telegram
-			found = true
-
-			fmt.Printf("Анкета обновлена: ID=%s\n", id)
-			break
-		}
+	// Пользователь уже проверен auth.RequireAuth
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
 	}
+	userID := user.ID
 
-	if !found {
+	// Ищем анкету для обновления
+	anketa, err := store.Get(id)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		http.Error(w, "Error loading ankety", http.StatusInternalServerError)
+		return
+	}
+	// Чужие анкеты может править только модератор
+	if err != nil || (anketa.UserId != userID && !user.Can(auth.PermAnketyModerate)) {
 		fmt.Printf("Анкета не найдена: ID=%s, UserID=%s\n", id, userID)
 		http.Error(w, "Ankety not found or access denied", http.StatusNotFound)
 		return
 	}
 
+	// Обновляем данные анкеты, существующее фото не трогаем
+	anketa.Name = name
+	anketa.Gender = gender
+	anketa.Age = age
+	anketa.Job = job
+	anketa.School = school
+	anketa.Skills = anketaSkills
+	anketa.Description = description
+	anketa.City = r.FormValue("city")
+	anketa.Position = r.FormValue("position")
+	anketa.Salary, anketa.SalaryRange = salaryText, salaryRange
+	anketa.Experience, anketa.ExperienceRange = experienceText, experienceRange
+	anketa.Seniority = seniority
+	anketa.Jobtype = r.FormValue("jobtype")
+	anketa.Telegram = telegram
+
 	// Сохраняем обновленные данные
-	err = SaveAnkety(anketyList)
+	err = store.Update(anketa)
 	if err != nil {
 		http.Error(w, "Error writing data file", http.StatusInternalServerError)
 		return
 	}
+	runChangeHooks(EventUpdated, anketa)
 
-	fmt.Println("Анкета успешно обновлена")
+	fmt.Printf("Анкета обновлена: ID=%s\n", id)
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Profile updated successfully"))
 }
@@ -363,20 +389,17 @@ func UploadPhotoHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Проверяем авторизацию
-	cookie, err := r.Cookie("auth_token")
-	if err != nil {
-		http.Error(w, "Unauthorized: missing token", http.StatusUnauthorized)
-		return
-	}
-	userID, _, err := auth.ValidateJWT(cookie.Value)
-	if err != nil {
-		http.Error(w, "Unauthorized: invalid token", http.StatusUnauthorized)
+	// Пользователь уже проверен auth.RequireAuth
+	user, ok := auth.MustUser(w, r)
+	if !ok {
 		return
 	}
+	userID := user.ID
 
 	// Парсим multipart форму
-	err = r.ParseMultipartForm(10 << 20) // Максимальный размер 10MB
+	// MaxBytesReader обрывает слишком большое тело, а не только ограничивает память
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	err := r.ParseMultipartForm(maxUploadSize)
 	if err != nil {
 		http.Error(w, "Error parsing form", http.StatusBadRequest)
 		return
@@ -406,29 +429,19 @@ func UploadPhotoHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Загружаем все анкеты
-	anketyList, err := LoadUser()
-	if err != nil {
-		http.Error(w, "Error loading ankety", http.StatusInternalServerError)
-		return
-	}
-
 	// Ищем анкету пользователя
-	userAnketaIndex := -1
-	for i, a := range anketyList {
-		if a.UserId == userID {
-			userAnketaIndex = i
-			break
-		}
-	}
-
-	if userAnketaIndex == -1 {
+	anketa, err := store.GetByUser(userID)
+	if errors.Is(err, ErrNotFound) {
 		http.Error(w, "User anketa not found", http.StatusNotFound)
 		return
 	}
+	if err != nil {
+		http.Error(w, "Error loading ankety", http.StatusInternalServerError)
+		return
+	}
 
 	// Создаем директорию для фотографий, если её нет
-	uploadDir := "uploads/photos"
+	uploadDir := filepath.Join(uploadsDir, "photos")
 	err = os.MkdirAll(uploadDir, 0755)
 	if err != nil {
 		http.Error(w, "Error creating upload directory", http.StatusInternalServerError)
@@ -441,9 +454,9 @@ func UploadPhotoHandler(w http.ResponseWriter, r *http.Request) {
 	filePath := filepath.Join(uploadDir, newFileName)
 
 	// Удаляем старую фотографию, если она есть
-	oldPhoto := anketyList[userAnketaIndex].Photo
+	oldPhoto := anketa.Photo
 	if oldPhoto != "" {
-		oldFilePath := filepath.Join("uploads", oldPhoto)
+		oldFilePath := filepath.Join(uploadsDir, oldPhoto)
 		if _, err := os.Stat(oldFilePath); err == nil {
 			os.Remove(oldFilePath)
 		}
@@ -465,14 +478,15 @@ func UploadPhotoHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Обновляем путь к фото в анкете
-	anketyList[userAnketaIndex].Photo = "photos/" + newFileName
+	anketa.Photo = "photos/" + newFileName
 
 	// Сохраняем обновленные данные
-	err = SaveAnkety(anketyList)
+	err = store.Update(anketa)
 	if err != nil {
 		http.Error(w, "Error writing data file", http.StatusInternalServerError)
 		return
 	}
+	runChangeHooks(EventUpdated, anketa)
 
 	// Возвращаем успешный ответ с путем к фото
 	response := map[string]string{
@@ -510,12 +524,12 @@ func GetPhotoHandler(w http.ResponseWriter, r *http.Request) {
 	filename = strings.TrimPrefix(filename, "uploads/")
 
 	// Формируем путь к файлу
-	filePath := filepath.Join("uploads", "photos", filename)
+	filePath := filepath.Join(uploadsDir, "photos", filename)
 
 	// Проверяем существование файла
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		// Если файл не найден, возвращаем дефолтную аватарку
-		defaultAvatarPath := "uploads/photos/default_avatar.png"
+		defaultAvatarPath := filepath.Join(uploadsDir, "photos", "default_avatar.png")
 
 		// Проверяем наличие дефолтной аватарки
 		if _, err := os.Stat(defaultAvatarPath); os.IsNotExist(err) {
@@ -555,57 +569,43 @@ func DeletePhotoHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Проверяем авторизацию
-	cookie, err := r.Cookie("auth_token")
-	if err != nil {
-		http.Error(w, "Unauthorized: missing token", http.StatusUnauthorized)
+	// Пользователь уже проверен auth.RequireAuth
+	user, ok := auth.MustUser(w, r)
+	if !ok {
 		return
 	}
-	userID, _, err := auth.ValidateJWT(cookie.Value)
-	if err != nil {
-		http.Error(w, "Unauthorized: invalid token", http.StatusUnauthorized)
+	userID := user.ID
+
+	// Ищем анкету пользователя
+	anketa, err := store.GetByUser(userID)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, "User anketa not found", http.StatusNotFound)
 		return
 	}
-
-	// Загружаем все анкеты
-	anketyList, err := LoadUser()
 	if err != nil {
 		http.Error(w, "Error loading ankety", http.StatusInternalServerError)
 		return
 	}
 
-	// Ищем анкету пользователя
-	userAnketaIndex := -1
-	for i, a := range anketyList {
-		if a.UserId == userID {
-			userAnketaIndex = i
-			break
-		}
-	}
-
-	if userAnketaIndex == -1 {
-		http.Error(w, "User anketa not found", http.StatusNotFound)
-		return
-	}
-
 	// Удаляем старую фотографию, если она есть
-	oldPhoto := anketyList[userAnketaIndex].Photo
+	oldPhoto := anketa.Photo
 	if oldPhoto != "" {
-		oldFilePath := filepath.Join("uploads", oldPhoto)
+		oldFilePath := filepath.Join(uploadsDir, oldPhoto)
 		if _, err := os.Stat(oldFilePath); err == nil {
 			os.Remove(oldFilePath)
 		}
 	}
 
 	// Очищаем поле фото в анкете
-	anketyList[userAnketaIndex].Photo = ""
+	anketa.Photo = ""
 
 	// Сохраняем обновленные данные
-	err = SaveAnkety(anketyList)
+	err = store.Update(anketa)
 	if err != nil {
 		http.Error(w, "Error writing data file", http.StatusInternalServerError)
 		return
 	}
+	runChangeHooks(EventUpdated, anketa)
 
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Photo deleted successfully"))
@@ -617,46 +617,31 @@ func GetMyAnketaHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Проверяем авторизацию
-	cookie, err := r.Cookie("auth_token")
-	if err != nil {
-		http.Error(w, "Unauthorized: missing token", http.StatusUnauthorized)
+	// Пользователь уже проверен auth.RequireAuth
+	user, ok := auth.MustUser(w, r)
+	if !ok {
 		return
 	}
-	userID, username, err := auth.ValidateJWT(cookie.Value)
-	if err != nil {
-		http.Error(w, "Unauthorized: invalid token", http.StatusUnauthorized)
+	userID, username := user.ID, user.Username
+
+	// Ищем анкету пользователя
+	myAnketa, err := store.GetByUser(userID)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, "Anketa not found", http.StatusNotFound)
 		return
 	}
-
-	// Загружаем все анкеты
-	anketyList, err := LoadUser()
 	if err != nil {
 		http.Error(w, "Error loading ankety", http.StatusInternalServerError)
 		return
 	}
 
-	// Ищем анкету пользователя
-	var myAnketa *Ankety
-	for i := range anketyList {
-		if anketyList[i].UserId == userID {
-			myAnketa = &anketyList[i]
-			break
-		}
-	}
-
-	if myAnketa == nil {
-		http.Error(w, "Anketa not found", http.StatusNotFound)
-		return
-	}
-
 	// Добавляем username в ответ
 	response := struct {
-		Ankety
+		anketaJSON
 		Username string `json:"username"`
 	}{
-		Ankety:   *myAnketa,
-		Username: username,
+		anketaJSON: myAnketa.view(),
+		Username:   username,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -669,50 +654,26 @@ func DeleteAnketyHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Проверяем авторизацию
-	cookie, err := r.Cookie("auth_token")
-	if err != nil {
-		http.Error(w, "Unauthorized: missing token", http.StatusUnauthorized)
+	// Пользователь уже проверен auth.RequireAuth
+	user, ok := auth.MustUser(w, r)
+	if !ok {
 		return
 	}
-	userID, _, err := auth.ValidateJWT(cookie.Value)
-	if err != nil {
-		http.Error(w, "Unauthorized: invalid token", http.StatusUnauthorized)
+	userID := user.ID
+
+	// Ищем анкету пользователя
+	anketa, err := store.GetByUser(userID)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, "Anketa not found", http.StatusNotFound)
 		return
 	}
-
-	// Загружаем все анкеты
-	anketyList, err := LoadUser()
 	if err != nil {
 		http.Error(w, "Error loading ankety", http.StatusInternalServerError)
 		return
 	}
 
-	// Ищем и удаляем анкету пользователя
-	found := false
-	newAnketyList := []Ankety{}
-	for _, a := range anketyList {
-		if a.UserId == userID {
-			found = true
-			// Удаляем фотографию, если она есть
-			if a.Photo != "" {
-				photoPath := filepath.Join("uploads", a.Photo)
-				if _, err := os.Stat(photoPath); err == nil {
-					os.Remove(photoPath)
-				}
-			}
-		} else {
-			newAnketyList = append(newAnketyList, a)
-		}
-	}
-
-	if !found {
-		http.Error(w, "Anketa not found", http.StatusNotFound)
-		return
-	}
-
-	// Сохраняем обновленные данные
-	err = SaveAnkety(newAnketyList)
+	// Удаляем анкету вместе с фотографией
+	err = deleteAnketa(anketa)
 	if err != nil {
 		http.Error(w, "Error saving data", http.StatusInternalServerError)
 		return
@@ -722,99 +683,32 @@ func DeleteAnketyHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("Anketa deleted successfully"))
 }
 
+// deleteAnketa удаляет анкету и ее фото и сообщает об этом хукам; общий путь
+// для владельца и модератора
+func deleteAnketa(a Ankety) error {
+	if err := store.Delete(a.Id); err != nil {
+		return err
+	}
+	removePhoto(a)
+	runChangeHooks(EventDeleted, a)
+	return nil
+}
+
 func SearchAnketyHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Получаем параметры поиска
-	query := r.URL.Query()
-	searchTerm := query.Get("q")
-	gender := query.Get("gender")
-	minAge := query.Get("min_age")
-	maxAge := query.Get("max_age")
-	job := query.Get("job")
-	city := query.Get("city")
-	skills := query.Get("skills")
-
-	// Загружаем все анкеты
-	anketyList, err := LoadUser()
+	filter, err := FilterFromQuery(r.URL.Query())
 	if err != nil {
-		http.Error(w, "Error loading ankety", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-
-	// Фильтруем анкеты
-	var filteredAnkety []Ankety
-	for _, a := range anketyList {
-		match := true
-
-		// Поиск по общему тексту
-		if searchTerm != "" {
-			searchTerm = strings.ToLower(searchTerm)
-			textToSearch := strings.ToLower(a.Name + " " + a.Job + " " + a.School + " " + a.Skills + " " + a.Description)
-			if !strings.Contains(textToSearch, searchTerm) {
-				match = false
-			}
-		}
-
-		// Фильтр по полу
-		if gender != "" && a.Gender != gender {
-			match = false
-		}
-
-		// Фильтр по возрасту
-		if minAge != "" {
-			minAgeInt := 0
-			fmt.Sscanf(minAge, "%d", &minAgeInt)
-			ageInt := 0
-			fmt.Sscanf(a.Age, "%d", &ageInt)
-			if ageInt < minAgeInt {
-				match = false
-			}
-		}
-
-		if maxAge != "" {
-			maxAgeInt := 0
-			fmt.Sscanf(maxAge, "%d", &maxAgeInt)
-			ageInt := 0
-			fmt.Sscanf(a.Age, "%d", &ageInt)
-			if ageInt > maxAgeInt {
-				match = false
-			}
-		}
-
-		// Фильтр по работе
-		if job != "" && !strings.Contains(strings.ToLower(a.Job), strings.ToLower(job)) {
-			match = false
-		}
-
-		// Фильтр по городу
-		if city != "" && !strings.Contains(strings.ToLower(a.City), strings.ToLower(city)) {
-			match = false
-		}
-
-		// Фильтр по навыкам
-		if skills != "" {
-			requiredSkills := strings.Split(strings.ToLower(skills), ",")
-			userSkills := strings.ToLower(a.Skills)
-			allSkillsFound := true
-			for _, reqSkill := range requiredSkills {
-				reqSkill = strings.TrimSpace(reqSkill)
-				if reqSkill != "" && !strings.Contains(userSkills, reqSkill) {
-					allSkillsFound = false
-					break
-				}
-			}
-			if !allSkillsFound {
-				match = false
-			}
-		}
-
-		if match {
-			filteredAnkety = append(filteredAnkety, a)
-		}
+	filteredAnkety, err := Search(filter)
+	if err != nil {
+		http.Error(w, "Error loading ankety", http.StatusInternalServerError)
+		return
 	}
 
 	// Подготавливаем ответ
@@ -837,7 +731,7 @@ func GetStatsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Загружаем все анкеты
-	anketyList, err := LoadUser()
+	anketyList, err := listVisible()
 	if err != nil {
 		http.Error(w, "Error loading ankety", http.StatusInternalServerError)
 		return
@@ -845,19 +739,24 @@ func GetStatsHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Считаем статистику
 	stats := struct {
-		TotalAnkety     int            `json:"total_ankety"`
-		GenderStats     map[string]int `json:"gender_stats"`
-		AgeGroups       map[string]int `json:"age_groups"`
-		TopJobs         map[string]int `json:"top_jobs"`
-		TopSkills       map[string]int `json:"top_skills"`
-		AnketyWithPhoto int            `json:"ankety_with_photo"`
+		TotalAnkety int            `json:"total_ankety"`
+		GenderStats map[string]int `json:"gender_stats"`
+		AgeGroups   map[string]int `json:"age_groups"`
+		TopJobs     map[string]int `json:"top_jobs"`
+		TopSkills   map[string]int `json:"top_skills"`
+		// ExperienceGroups - по нижней границе опыта: no_experience, under_1, 1_3, 3_6, 6_plus
+		ExperienceGroups map[string]int `json:"experience_groups"`
+		SeniorityStats   map[string]int `json:"seniority_stats"`
+		AnketyWithPhoto  int            `json:"ankety_with_photo"`
 	}{
-		TotalAnkety:     len(anketyList),
-		GenderStats:     make(map[string]int),
-		AgeGroups:       make(map[string]int),
-		TopJobs:         make(map[string]int),
-		TopSkills:       make(map[string]int),
-		AnketyWithPhoto: 0,
+		TotalAnkety:      len(anketyList),
+		GenderStats:      make(map[string]int),
+		AgeGroups:        make(map[string]int),
+		TopJobs:          make(map[string]int),
+		TopSkills:        make(map[string]int),
+		ExperienceGroups: make(map[string]int),
+		SeniorityStats:   make(map[string]int),
+		AnketyWithPhoto:  0,
 	}
 
 	for _, a := range anketyList {
@@ -887,15 +786,19 @@ func GetStatsHandler(w http.ResponseWriter, r *http.Request) {
 		// Статистика по профессиям
 		stats.TopJobs[a.Job]++
 
-		// Статистика по навыкам
-		skills := strings.Split(a.Skills, ",")
-		for _, skill := range skills {
-			skill = strings.TrimSpace(skill)
-			if skill != "" {
-				stats.TopSkills[skill]++
-			}
+		// Статистика по навыкам - по названиям, как их показывают страницы
+		for _, skill := range a.Skills {
+			stats.TopSkills[skills.Name(skill)]++
 		}
 
+		// Статистика по опыту и уровню
+		stats.ExperienceGroups[experience.Group(a.ExperienceRange)]++
+		seniority := string(a.Seniority)
+		if seniority == "" {
+			seniority = "unknown"
+		}
+		stats.SeniorityStats[seniority]++
+
 		// Статистика по фото
 		if a.Photo != "" {
 			stats.AnketyWithPhoto++
@@ -914,7 +817,7 @@ func ExportCSVHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Загружаем все анкеты
-	anketyList, err := LoadUser()
+	anketyList, err := listVisible()
 	if err != nil {
 		http.Error(w, "Error loading ankety", http.StatusInternalServerError)
 		return
@@ -933,7 +836,7 @@ func ExportCSVHandler(w http.ResponseWriter, r *http.Request) {
 			a.Age,
 			strings.ReplaceAll(a.Job, `"`, `""`),
 			strings.ReplaceAll(a.School, `"`, `""`),
-			strings.ReplaceAll(a.Skills, `"`, `""`),
+			strings.ReplaceAll(a.Skills.String(), `"`, `""`),
 			a.Photo,
 			a.City,
 			strings.ReplaceAll(a.Description, `"`, `""`),
@@ -961,26 +864,19 @@ func GetAnketaByIDHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Загружаем все анкеты
-	anketyList, err := LoadUser()
-	if err != nil {
-		http.Error(w, "Error loading ankety", http.StatusInternalServerError)
-		return
-	}
-
-	// Ищем анкету по ID
-	var foundAnketa *Ankety
-	for i := range anketyList {
-		if anketyList[i].Id == id {
-			foundAnketa = &anketyList[i]
-			break
-		}
+	// Ищем анкету по ID; скрытые модератором для всех как удаленные
+	foundAnketa, err := store.Get(id)
+	if err == nil && foundAnketa.Moderation != nil {
+		err = ErrNotFound
 	}
-
-	if foundAnketa == nil {
+	if errors.Is(err, ErrNotFound) {
 		http.Error(w, "Anketa not found", http.StatusNotFound)
 		return
 	}
+	if err != nil {
+		http.Error(w, "Error loading ankety", http.StatusInternalServerError)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(foundAnketa)
@@ -988,30 +884,36 @@ func GetAnketaByIDHandler(w http.ResponseWriter, r *http.Request) {
 
 // Вспомогательная функция для проверки существования анкеты пользователя
 func HasUserAnketa(userID string) (bool, error) {
-	anketyList, err := LoadUser()
+	_, err := store.GetByUser(userID)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
 	if err != nil {
 		return false, err
 	}
-
-	for _, a := range anketyList {
-		if a.UserId == userID {
-			return true, nil
-		}
-	}
-	return false, nil
+	return true, nil
 }
 
 // Вспомогательная функция для получения анкеты по UserID
 func GetAnketaByUserID(userID string) (*Ankety, error) {
-	anketyList, err := LoadUser()
+	anketa, err := store.GetByUser(userID)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
+	return &anketa, nil
+}
 
-	for i := range anketyList {
-		if anketyList[i].UserId == userID {
-			return &anketyList[i], nil
-		}
+// Вспомогательная функция для получения анкеты по ID
+func GetAnketaByID(id string) (*Ankety, error) {
+	anketa, err := store.Get(id)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
 	}
-	return nil, nil
+	return &anketa, nil
 }
@@ -0,0 +1,199 @@
+package ankety
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"talant/auth/authtest"
+	"talant/skills"
+	"testing"
+)
+
+var (
+	owner    = &authtest.Owner
+	stranger = &authtest.Stranger
+	admin    = &authtest.Admin
+	serve    = authtest.Serve
+)
+
+// useStore подменяет хранилище на время теста
+func useStore(t *testing.T, ankety ...Ankety) *MemStore {
+	t.Helper()
+	prevStore, prevIndex := store, index
+	s := NewMemStore(ankety...)
+	SetStore(s)
+	t.Cleanup(func() { store, index = prevStore, prevIndex })
+	return s
+}
+
+func anketaForm() url.Values {
+	return url.Values{"name": {"Иван"}, "gender": {"м"}, "age": {"30"}, "job": {"Талант"},
+		"school": {"МГУ"}, "skills": {"Go"}}
+}
+
+func TestCreateHandler(t *testing.T) {
+	s := useStore(t)
+
+	w := serve(CreateHandler, http.MethodPost, "/api/ankety/create", anketaForm(), owner)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body)
+	}
+	a, err := s.GetByUser(owner.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Name != "Иван" || !strings.Contains(w.Body.String(), a.Id) {
+		t.Errorf("stored = %+v, response %s", a, w.Body)
+	}
+
+	// Вторая анкета того же пользователя не создается
+	if w := serve(CreateHandler, http.MethodPost, "/api/ankety/create", anketaForm(), owner); w.Code != http.StatusBadRequest {
+		t.Errorf("second create: %d, want 400", w.Code)
+	}
+	form := anketaForm()
+	form.Del("school")
+	if w := serve(CreateHandler, http.MethodPost, "/api/ankety/create", form, stranger); w.Code != http.StatusBadRequest {
+		t.Errorf("create without school: %d, want 400", w.Code)
+	}
+	if list, _ := s.List(); len(list) != 1 {
+		t.Errorf("stored %d ankety, want 1", len(list))
+	}
+}
+
+func TestGetMyAnketaHandler(t *testing.T) {
+	useStore(t, Ankety{Id: "1", UserId: owner.ID, Name: "Иван"})
+
+	w := serve(GetMyAnketaHandler, http.MethodGet, "/api/ankety/my", nil, owner)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"username":"owner"`) {
+		t.Fatalf("my: %d %s", w.Code, w.Body)
+	}
+	if w := serve(GetMyAnketaHandler, http.MethodGet, "/api/ankety/my", nil, stranger); w.Code != http.StatusNotFound {
+		t.Errorf("my without anketa: %d, want 404", w.Code)
+	}
+}
+
+func TestSkillNames(t *testing.T) {
+	s := useStore(t)
+	form := anketaForm()
+	form.Set("skills", "golang, Vue Router")
+	if w := serve(CreateHandler, http.MethodPost, "/api/ankety/create", form, owner); w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body)
+	}
+	// Навык не из словаря остается в написании пользователя
+	if a, _ := s.GetByUser(owner.ID); !slices.Equal(a.Skills, skills.List{"go", "Vue Router"}) {
+		t.Errorf("stored skills = %v", a.Skills)
+	}
+
+	w := serve(GetMyAnketaHandler, http.MethodGet, "/api/ankety/my", nil, owner)
+	if !strings.Contains(w.Body.String(), `"skill_names":["Go","Vue Router"]`) {
+		t.Errorf("my: %s, want skill_names", w.Body)
+	}
+	// Статистика считает навыки по названиям, а не по ID
+	w = serve(GetStatsHandler, http.MethodGet, "/api/ankety/stats", nil, nil)
+	if !strings.Contains(w.Body.String(), `"top_skills":{"Go":1,"Vue Router":1}`) {
+		t.Errorf("stats: %s, want skills by name", w.Body)
+	}
+}
+
+func TestUpdateHandlerOwnership(t *testing.T) {
+	s := useStore(t, Ankety{Id: "1", UserId: owner.ID, Name: "Старое", Photo: "photo.jpg"})
+	form := anketaForm()
+	form.Set("id", "1")
+
+	// Чужую анкету обычный пользователь как будто не видит
+	if w := serve(UpdateAnketyHandler, http.MethodPut, "/api/ankety/update", form, stranger); w.Code != http.StatusNotFound {
+		t.Errorf("update by stranger: %d, want 404", w.Code)
+	}
+	if a, _ := s.Get("1"); a.Name != "Старое" {
+		t.Errorf("stranger changed the anketa: %+v", a)
+	}
+
+	if w := serve(UpdateAnketyHandler, http.MethodPut, "/api/ankety/update", form, owner); w.Code != http.StatusOK {
+		t.Fatalf("update by owner: %d %s", w.Code, w.Body)
+	}
+	if a, _ := s.Get("1"); a.Name != "Иван" || a.UserId != owner.ID || a.Photo != "photo.jpg" {
+		t.Errorf("after update: %+v", a)
+	}
+}
+
+func TestDeleteAnketyHandler(t *testing.T) {
+	s := useStore(t,
+		Ankety{Id: "1", UserId: owner.ID},
+		Ankety{Id: "2", UserId: stranger.ID},
+	)
+
+	// Удаляется только своя анкета
+	if w := serve(DeleteAnketyHandler, http.MethodDelete, "/api/ankety/delete", nil, owner); w.Code != http.StatusOK {
+		t.Fatalf("delete: %d %s", w.Code, w.Body)
+	}
+	if _, err := s.Get("1"); err != ErrNotFound {
+		t.Errorf("own anketa after delete: %v, want ErrNotFound", err)
+	}
+	if _, err := s.Get("2"); err != nil {
+		t.Errorf("other anketa deleted: %v", err)
+	}
+	if w := serve(DeleteAnketyHandler, http.MethodDelete, "/api/ankety/delete", nil, owner); w.Code != http.StatusNotFound {
+		t.Errorf("delete again: %d, want 404", w.Code)
+	}
+}
+
+func TestModeratorEditsOthersAnketa(t *testing.T) {
+	s := useStore(t, Ankety{Id: "1", UserId: owner.ID, Name: "Старое"})
+	form := anketaForm()
+	form.Set("id", "1")
+	form.Set("name", "Поправил модератор")
+
+	if w := serve(UpdateAnketyHandler, http.MethodPut, "/api/ankety/update", form, admin); w.Code != http.StatusOK {
+		t.Fatalf("update by moderator: %d %s", w.Code, w.Body)
+	}
+	if a, _ := s.Get("1"); a.Name != "Поправил модератор" || a.UserId != owner.ID {
+		t.Errorf("after moderator update: %+v", a)
+	}
+}
+
+// recordEvents подменяет хуки изменений на время теста и собирает их события
+func recordEvents(t *testing.T) *[]Event {
+	t.Helper()
+	prev := changeHooks
+	var events []Event
+	changeHooks = nil
+	OnChange(func(e Event, a Ankety) error {
+		events = append(events, e)
+		return nil
+	})
+	t.Cleanup(func() { changeHooks = prev })
+	return &events
+}
+
+func TestChangeHooks(t *testing.T) {
+	useStore(t, Ankety{Id: "2", UserId: stranger.ID})
+	events := recordEvents(t)
+
+	serve(CreateHandler, http.MethodPost, "/api/ankety/create", anketaForm(), owner)
+	// Вторая анкета не создается - и хуки не вызываются
+	serve(CreateHandler, http.MethodPost, "/api/ankety/create", anketaForm(), owner)
+	a, err := store.GetByUser(owner.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	form := anketaForm()
+	form.Set("id", a.Id)
+	serve(UpdateAnketyHandler, http.MethodPut, "/api/ankety/update", form, owner)
+	serve(DeleteAnketyHandler, http.MethodDelete, "/api/ankety/delete", nil, owner)
+
+	// Удаление модератором идет тем же путем, что и владельцем
+	r := authtest.Request(http.MethodDelete, "/admin/ankety/2", nil, admin)
+	r.SetPathValue("id", "2")
+	w := httptest.NewRecorder()
+	PurgeHandler(w, r)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("purge: %d %s", w.Code, w.Body)
+	}
+
+	want := []Event{EventCreated, EventUpdated, EventDeleted, EventDeleted}
+	if !slices.Equal(*events, want) {
+		t.Errorf("events = %v, want %v", *events, want)
+	}
+}
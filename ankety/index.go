@@ -0,0 +1,42 @@
+package ankety
+
+import "talant/fulltext"
+
+// fieldBoosts - веса полей в поиске по q: совпадение в работе и должности
+// важнее, чем в описании
+var fieldBoosts = map[string]float64{"job": 3, "position": 3, "skills": 2, "name": 1.5, "school": 1, "description": 1}
+
+func searchFields(a Ankety) fulltext.Fields {
+	return fulltext.Fields{
+		"name":        a.Name,
+		"job":         a.Job,
+		"position":    a.Position,
+		"school":      a.School,
+		"skills":      a.Skills.String(),
+		"description": a.Description,
+	}
+}
+
+// indexedStore - хранилище, изменения в котором сразу попадают в поисковый индекс
+type indexedStore struct {
+	Store
+	index *fulltext.Indexed[Ankety]
+}
+
+// withIndex оборачивает хранилище и возвращает его вместе с индексом для q
+func withIndex(s Store) (Store, *fulltext.Indexed[Ankety]) {
+	index := fulltext.NewIndexed(s.List, func(a Ankety) string { return a.Id }, searchFields, fieldBoosts)
+	return indexedStore{s, index}, index
+}
+
+func (s indexedStore) Create(a Ankety) error {
+	return s.index.Put(a, s.Store.Create)
+}
+
+func (s indexedStore) Update(a Ankety) error {
+	return s.index.Put(a, s.Store.Update)
+}
+
+func (s indexedStore) Delete(id string) error {
+	return s.index.Delete(id, s.Store.Delete)
+}
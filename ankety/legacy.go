@@ -0,0 +1,50 @@
+package ankety
+
+import (
+	"talant/experience"
+	"talant/salary"
+)
+
+// parseLegacy дополняет анкету разобранными зарплатой, опытом и уровнем,
+// если их еще нет; возвращает, изменилась ли она
+func parseLegacy(a *Ankety) bool {
+	changed := false
+	if a.SalaryRange == nil && a.Salary != "" {
+		if s, ok := salary.Parse(a.Salary); ok {
+			a.SalaryRange, changed = s, true
+		}
+	}
+	if a.ExperienceRange == nil && a.Experience != "" {
+		if r, ok := experience.Parse(a.Experience); ok {
+			a.ExperienceRange, changed = r, true
+		}
+	}
+	if a.Seniority == "" {
+		if level, ok := experience.DetectLevel(a.Position); ok {
+			a.Seniority, changed = level, true
+		}
+	}
+	return changed
+}
+
+// ParseLegacy разбирает текстовые зарплату и опыт у анкет, созданных до
+// появления SalaryRange, ExperienceRange и Seniority, и возвращает, сколько
+// анкет дополнено. Хуки изменений не вызываются: для владельца анкета не
+// меняется.
+func ParseLegacy() (int, error) {
+	anketyList, err := store.List()
+	if err != nil {
+		return 0, err
+	}
+	parsed := 0
+	for _, a := range anketyList {
+		if !parseLegacy(&a) {
+			continue
+		}
+		if err := store.Update(a); err != nil {
+			return parsed, err
+		}
+		parsed++
+	}
+	return parsed, nil
+}
@@ -0,0 +1,161 @@
+package ankety
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"talant/experience"
+	"talant/salary"
+	"talant/skills"
+)
+
+// FilterParams - параметры запроса /api/ankety/search
+var FilterParams = []string{"q", "gender", "min_age", "max_age", "job", "city", "skills",
+	"experience", "seniority", "salary_min", "salary_max", "salary_currency"}
+
+// Filter - условия поиска анкет; пустое условие ничего не отсеивает
+type Filter struct {
+	// Q - слова в имени, работе, должности, учебе, навыках или описании; ищутся
+	// по поисковому индексу с учетом словоформ, нужны все
+	Q      string
+	Gender string
+	MinAge string
+	MaxAge string
+	Job    string
+	City   string
+	Skills skills.List // ID навыков, нужны все; сравниваются целиком
+	// Experience - диапазон лет, с которым должен пересекаться опыт; Seniority -
+	// подходящие уровни. Анкеты без них при этом не подходят.
+	Experience *experience.Range
+	Seniority  []experience.Level
+	// SalaryMin и SalaryMax - месячный диапазон в валюте SalaryCurrency (по
+	// умолчанию базовой), с которым должна пересекаться ожидаемая зарплата;
+	// анкеты без SalaryRange при этом не подходят
+	SalaryMin      int
+	SalaryMax      int
+	SalaryCurrency string
+}
+
+// FilterFromQuery собирает фильтр из параметров запроса
+func FilterFromQuery(query url.Values) (Filter, error) {
+	f := Filter{
+		Q:      strings.TrimSpace(query.Get("q")),
+		Gender: query.Get("gender"),
+		MinAge: query.Get("min_age"),
+		MaxAge: query.Get("max_age"),
+		Job:    query.Get("job"),
+		City:   query.Get("city"),
+		Skills: skills.Parse(query.Get("skills")),
+	}
+	var err error
+	if f.Experience, err = experience.ParseQuery(query.Get("experience")); err != nil {
+		return f, err
+	}
+	if f.Seniority, err = experience.ParseLevels(query.Get("seniority")); err != nil {
+		return f, err
+	}
+	for param, dst := range map[string]*int{"salary_min": &f.SalaryMin, "salary_max": &f.SalaryMax} {
+		if v := query.Get(param); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return f, fmt.Errorf("Invalid %s", param)
+			}
+			*dst = n
+		}
+	}
+	if f.SalaryMax > 0 && f.SalaryMin > f.SalaryMax {
+		return f, errors.New("salary_min is greater than salary_max")
+	}
+	f.SalaryCurrency = strings.ToUpper(query.Get("salary_currency"))
+	if f.SalaryCurrency == "" {
+		f.SalaryCurrency = salary.Base()
+	}
+	if !salary.Known(f.SalaryCurrency) {
+		return f, fmt.Errorf("Unknown currency: %s", f.SalaryCurrency)
+	}
+	return f, nil
+}
+
+// Match - подходит ли анкета под фильтр, кроме Q: текст проверяет Search по индексу
+func (f Filter) Match(a Ankety) bool {
+	// Фильтр по полу
+	if f.Gender != "" && a.Gender != f.Gender {
+		return false
+	}
+
+	// Фильтр по возрасту
+	ageInt := 0
+	fmt.Sscanf(a.Age, "%d", &ageInt)
+	if f.MinAge != "" {
+		minAgeInt := 0
+		fmt.Sscanf(f.MinAge, "%d", &minAgeInt)
+		if ageInt < minAgeInt {
+			return false
+		}
+	}
+	if f.MaxAge != "" {
+		maxAgeInt := 0
+		fmt.Sscanf(f.MaxAge, "%d", &maxAgeInt)
+		if ageInt > maxAgeInt {
+			return false
+		}
+	}
+
+	// Фильтр по работе
+	if f.Job != "" && !strings.Contains(strings.ToLower(a.Job), strings.ToLower(f.Job)) {
+		return false
+	}
+
+	// Фильтр по городу
+	if f.City != "" && !strings.Contains(strings.ToLower(a.City), strings.ToLower(f.City)) {
+		return false
+	}
+
+	// Фильтр по опыту и уровню
+	if f.Experience != nil && (a.ExperienceRange == nil || !a.ExperienceRange.Overlaps(*f.Experience)) {
+		return false
+	}
+	if len(f.Seniority) > 0 && !slices.Contains(f.Seniority, a.Seniority) {
+		return false
+	}
+
+	// Фильтр по зарплате
+	if f.SalaryMin > 0 || f.SalaryMax > 0 {
+		if a.SalaryRange == nil ||
+			!a.SalaryRange.Overlaps(float64(f.SalaryMin), float64(f.SalaryMax), f.SalaryCurrency) {
+			return false
+		}
+	}
+
+	// Фильтр по навыкам
+	for _, skill := range f.Skills {
+		if !a.Skills.Has(skill) {
+			return false
+		}
+	}
+	return true
+}
+
+// Search - видимые анкеты, подходящие под фильтр: с Q - самые релевантные
+// первыми, без Q - в порядке хранилища
+func Search(f Filter) ([]Ankety, error) {
+	anketyList, err := listVisible()
+	if err != nil {
+		return nil, err
+	}
+	ranking, err := index.Search(f.Q)
+	if err != nil {
+		return nil, err
+	}
+	found := []Ankety{}
+	for _, a := range anketyList {
+		if ranking.Has(a.Id) && f.Match(a) {
+			found = append(found, a)
+		}
+	}
+	index.Sort(found, ranking)
+	return found, nil
+}
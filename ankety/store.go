@@ -0,0 +1,280 @@
+package ankety
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"talant/jsonfile"
+	"talant/memstore"
+)
+
+var (
+	// ErrNotFound возвращается хранилищем, если анкета не найдена
+	ErrNotFound = errors.New("anketa not found")
+	// ErrAlreadyExists возвращается при попытке создать вторую анкету пользователю
+	ErrAlreadyExists = errors.New("anketa already exists for this user")
+)
+
+// Store - хранилище анкет. Обработчики работают только через этот интерфейс,
+// поэтому JSON-файл можно заменить другой реализацией (БД, in-memory для тестов).
+type Store interface {
+	List() ([]Ankety, error)
+	Get(id string) (Ankety, error)
+	GetByUser(userID string) (Ankety, error)
+	Create(a Ankety) error
+	Update(a Ankety) error
+	Delete(id string) error
+}
+
+// index - полнотекстовый индекс записей store для поиска по q
+var store, index = withIndex(NewJSONStore("ankety.json"))
+
+// SetStore подменяет хранилище, с которым работают обработчики
+func SetStore(s Store) {
+	store, index = withIndex(s)
+}
+
+// JSONStore хранит все анкеты одним массивом в JSON-файле
+type JSONStore struct {
+	file *jsonfile.File[Ankety]
+}
+
+func NewJSONStore(path string) *JSONStore {
+	return &JSONStore{file: jsonfile.New[Ankety](path)}
+}
+
+func (s *JSONStore) load() ([]Ankety, error) {
+	anketyList, err := s.file.Load()
+	if err != nil {
+		fmt.Printf("Ошибка загрузки анкет: %v\n", err)
+		return nil, err
+	}
+	fmt.Printf("Загружено %d анкет из файла\n", len(anketyList))
+	return anketyList, nil
+}
+
+// update - общий цикл чтение-изменение-запись с логированием, как раньше в SaveAnkety
+func (s *JSONStore) update(fn func([]Ankety) ([]Ankety, error)) error {
+	err := s.file.Update(func(anketyList []Ankety) ([]Ankety, error) {
+		anketyList, err := fn(anketyList)
+		if err == nil {
+			fmt.Printf("Сохранение %d анкет в файл...\n", len(anketyList))
+		}
+		return anketyList, err
+	})
+	if err != nil {
+		fmt.Printf("Анкеты не сохранены в %s: %v\n", s.file.Path(), err)
+		return err
+	}
+	fmt.Println("Анкеты успешно сохранены в файл")
+	return nil
+}
+
+func (s *JSONStore) find(match func(Ankety) bool) (Ankety, error) {
+	anketyList, err := s.load()
+	if err != nil {
+		return Ankety{}, err
+	}
+	for _, a := range anketyList {
+		if match(a) {
+			return a, nil
+		}
+	}
+	return Ankety{}, ErrNotFound
+}
+
+func (s *JSONStore) List() ([]Ankety, error) {
+	return s.load()
+}
+
+func (s *JSONStore) Get(id string) (Ankety, error) {
+	return s.find(func(a Ankety) bool { return a.Id == id })
+}
+
+func (s *JSONStore) GetByUser(userID string) (Ankety, error) {
+	return s.find(func(a Ankety) bool { return a.UserId == userID })
+}
+
+func (s *JSONStore) Create(a Ankety) error {
+	return s.update(func(anketyList []Ankety) ([]Ankety, error) {
+		// У пользователя может быть только одна анкета
+		for _, existing := range anketyList {
+			if existing.UserId == a.UserId {
+				return nil, ErrAlreadyExists
+			}
+		}
+		return append(anketyList, a), nil
+	})
+}
+
+func (s *JSONStore) Update(a Ankety) error {
+	return s.update(func(anketyList []Ankety) ([]Ankety, error) {
+		for i := range anketyList {
+			if anketyList[i].Id == a.Id {
+				anketyList[i] = a
+				return anketyList, nil
+			}
+		}
+		return nil, ErrNotFound
+	})
+}
+
+func (s *JSONStore) Delete(id string) error {
+	return s.update(func(anketyList []Ankety) ([]Ankety, error) {
+		for i := range anketyList {
+			if anketyList[i].Id == id {
+				return append(anketyList[:i], anketyList[i+1:]...), nil
+			}
+		}
+		return nil, ErrNotFound
+	})
+}
+
+// CachedStore держит все анкеты в памяти с индексом по пользователю.
+// Изменения пишутся в журнал и периодически сворачиваются в JSON-файл.
+type CachedStore struct {
+	m *memstore.Store[Ankety]
+}
+
+// NewCachedStore загружает анкеты из path (и журнала path + ".journal")
+func NewCachedStore(path string) (*CachedStore, error) {
+	m, err := memstore.Open(memstore.Options[Ankety]{
+		Snapshot: path,
+		ID:       func(a Ankety) string { return a.Id },
+		Indexes: map[string]func(Ankety) string{
+			"user": func(a Ankety) string { return a.UserId },
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &CachedStore{m: m}, nil
+}
+
+func (s *CachedStore) Close() error {
+	return s.m.Close()
+}
+
+func (s *CachedStore) List() ([]Ankety, error) {
+	return s.m.List(), nil
+}
+
+func (s *CachedStore) Get(id string) (Ankety, error) {
+	a, ok := s.m.Get(id)
+	if !ok {
+		return Ankety{}, ErrNotFound
+	}
+	return a, nil
+}
+
+func (s *CachedStore) GetByUser(userID string) (Ankety, error) {
+	found := s.m.Find("user", userID)
+	if len(found) == 0 {
+		return Ankety{}, ErrNotFound
+	}
+	return found[0], nil
+}
+
+func (s *CachedStore) Create(a Ankety) error {
+	return s.m.Put(a, func(v memstore.View[Ankety]) error {
+		// У пользователя может быть только одна анкета
+		if len(v.Find("user", a.UserId)) > 0 {
+			return ErrAlreadyExists
+		}
+		return nil
+	})
+}
+
+func (s *CachedStore) Update(a Ankety) error {
+	return s.m.Put(a, func(v memstore.View[Ankety]) error {
+		if _, ok := v.Get(a.Id); !ok {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (s *CachedStore) Delete(id string) error {
+	ok, err := s.m.Delete(id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// MemStore хранит анкеты только в памяти процесса: для тестов обработчиков
+type MemStore struct {
+	mu     sync.Mutex
+	ankety []Ankety
+}
+
+// NewMemStore - хранилище с начальным набором анкет
+func NewMemStore(ankety ...Ankety) *MemStore {
+	return &MemStore{ankety: slices.Clone(ankety)}
+}
+
+func (s *MemStore) find(match func(Ankety) bool) (Ankety, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range s.ankety {
+		if match(a) {
+			return a, nil
+		}
+	}
+	return Ankety{}, ErrNotFound
+}
+
+func (s *MemStore) List() ([]Ankety, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.ankety), nil
+}
+
+func (s *MemStore) Get(id string) (Ankety, error) {
+	return s.find(func(a Ankety) bool { return a.Id == id })
+}
+
+func (s *MemStore) GetByUser(userID string) (Ankety, error) {
+	return s.find(func(a Ankety) bool { return a.UserId == userID })
+}
+
+func (s *MemStore) Create(a Ankety) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// У пользователя может быть только одна анкета
+	for _, existing := range s.ankety {
+		if existing.UserId == a.UserId {
+			return ErrAlreadyExists
+		}
+	}
+	s.ankety = append(s.ankety, a)
+	return nil
+}
+
+func (s *MemStore) Update(a Ankety) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.ankety {
+		if s.ankety[i].Id == a.Id {
+			s.ankety[i] = a
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (s *MemStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.ankety {
+		if s.ankety[i].Id == id {
+			s.ankety = slices.Delete(s.ankety, i, i+1)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
@@ -0,0 +1,383 @@
+// Package applications - отклики кандидатов на вакансии: кандидат откликается
+// своей анкетой и сопроводительным письмом, владелец вакансии ведет отклик по статусам.
+package applications
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"talant/ankety"
+	"talant/auth"
+	"talant/job"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Status - этап отклика
+type Status string
+
+const (
+	StatusNew       Status = "new"       // кандидат откликнулся
+	StatusViewed    Status = "viewed"    // владелец вакансии открыл отклик
+	StatusInterview Status = "interview" // приглашен на собеседование
+	StatusOffer     Status = "offer"     // сделано предложение
+	StatusRejected  Status = "rejected"  // отказ
+)
+
+// ownerStatuses - статусы, которые выставляет владелец вакансии; new ставится только при отклике
+var ownerStatuses = []Status{StatusViewed, StatusInterview, StatusOffer, StatusRejected}
+
+// maxCoverLetter - предельная длина сопроводительного письма в символах
+const maxCoverLetter = 5000
+
+// Change - смена статуса, из них складывается история отклика
+type Change struct {
+	Status Status    `json:"status"`
+	At     time.Time `json:"at"`
+}
+
+// Application - отклик на вакансию
+type Application struct {
+	Id    string `json:"id"`
+	JobID string `json:"job_id"`
+	// UserID и AnketaID - кандидат и анкета, с которой он откликнулся
+	UserID      string    `json:"user_id"`
+	AnketaID    string    `json:"anketa_id"`
+	CoverLetter string    `json:"cover_letter,omitempty"`
+	Status      Status    `json:"status"`
+	History     []Change  `json:"history"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// setStatus меняет статус и дописывает историю
+func (a *Application) setStatus(status Status, now time.Time) {
+	a.Status = status
+	a.History = append(a.History, Change{Status: status, At: now})
+	a.UpdatedAt = now
+}
+
+// Хуки откликов: applications не знает про уведомления, они подключаются
+// через OnApply и OnStatusChange
+var applyHooks, statusHooks []func(a Application, j job.Job) error
+
+// OnApply подключает обработчик нового отклика
+func OnApply(hook func(a Application, j job.Job) error) {
+	applyHooks = append(applyHooks, hook)
+}
+
+// OnStatusChange подключает обработчик смены статуса отклика владельцем вакансии
+func OnStatusChange(hook func(a Application, j job.Job) error) {
+	statusHooks = append(statusHooks, hook)
+}
+
+// runHooks вызывает хуки; их ошибки только пишутся в лог - отклик уже сохранен
+func runHooks(hooks []func(Application, job.Job) error, a Application, j job.Job) {
+	for _, hook := range hooks {
+		if err := hook(a, j); err != nil {
+			fmt.Printf("Ошибка обработчика отклика %s: %v\n", a.Id, err)
+		}
+	}
+}
+
+// newestFirst - свежие отклики первыми
+func newestFirst(list []Application) {
+	slices.SortStableFunc(list, func(a, b Application) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+}
+
+// loadJob загружает вакансию из пути запроса
+func loadJob(w http.ResponseWriter, r *http.Request) (*job.Job, bool) {
+	j, err := job.GetJobByID(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Error loading jobs", http.StatusInternalServerError)
+		return nil, false
+	}
+	if j == nil {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return nil, false
+	}
+	return j, true
+}
+
+func canManage(user auth.CurrentUser, j *job.Job) bool {
+	return j.UserID == user.ID || user.Can(auth.PermJobsModerate)
+}
+
+// ApplyHandler - отклик текущего пользователя на вакансию его анкетой
+// (POST /job/{id}/apply, поле cover_letter)
+func ApplyHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	j, ok := loadJob(w, r)
+	if !ok {
+		return
+	}
+	if j.UserID == user.ID {
+		http.Error(w, "Cannot apply to your own job", http.StatusBadRequest)
+		return
+	}
+
+	anketa, err := ankety.GetAnketaByUserID(user.ID)
+	if err != nil {
+		http.Error(w, "Error loading ankety", http.StatusInternalServerError)
+		return
+	}
+	if anketa == nil {
+		http.Error(w, "Create an anketa before applying", http.StatusBadRequest)
+		return
+	}
+	if anketa.Moderation != nil {
+		http.Error(w, "Anketa is hidden by moderator", http.StatusForbidden)
+		return
+	}
+
+	coverLetter := strings.TrimSpace(r.FormValue("cover_letter"))
+	if utf8.RuneCountInString(coverLetter) > maxCoverLetter {
+		http.Error(w, fmt.Sprintf("Cover letter is too long (max %d characters)", maxCoverLetter),
+			http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	a := Application{
+		Id:          uuid.New().String(),
+		JobID:       j.Id,
+		UserID:      user.ID,
+		AnketaID:    anketa.Id,
+		CoverLetter: coverLetter,
+		CreatedAt:   now,
+	}
+	a.setStatus(StatusNew, now)
+
+	err = store.Create(a)
+	if errors.Is(err, ErrAlreadyApplied) {
+		http.Error(w, "Already applied to this job", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error saving applications", http.StatusInternalServerError)
+		return
+	}
+	runHooks(applyHooks, a, *j)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(a)
+}
+
+// applicant - отклик с анкетой кандидата для владельца вакансии.
+// Анкета пустая, если кандидат ее удалил или ее скрыл модератор.
+type applicant struct {
+	Application
+	Anketa *ankety.Ankety `json:"anketa"`
+}
+
+func withAnketa(a Application) (applicant, error) {
+	anketa, err := ankety.GetAnketaByID(a.AnketaID)
+	if err != nil {
+		return applicant{}, err
+	}
+	if anketa != nil && anketa.Moderation != nil {
+		anketa = nil
+	}
+	return applicant{Application: a, Anketa: anketa}, nil
+}
+
+// JobApplicationsHandler - отклики на вакансию для ее владельца
+// (GET /job/{id}/applications, фильтр status)
+func JobApplicationsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	j, ok := loadJob(w, r)
+	if !ok {
+		return
+	}
+	if !canManage(user, j) {
+		http.Error(w, "Forbidden: not your job", http.StatusForbidden)
+		return
+	}
+
+	list, err := store.ListByJob(j.Id)
+	if err != nil {
+		http.Error(w, "Error loading applications", http.StatusInternalServerError)
+		return
+	}
+	newestFirst(list)
+	status := Status(r.URL.Query().Get("status"))
+
+	result := []applicant{}
+	for _, a := range list {
+		if status != "" && a.Status != status {
+			continue
+		}
+		view, err := withAnketa(a)
+		if err != nil {
+			http.Error(w, "Error loading ankety", http.StatusInternalServerError)
+			return
+		}
+		result = append(result, view)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// withJob - отклик с вакансией для кандидата; вакансия пустая, если ее удалили или скрыли
+type withJob struct {
+	Application
+	Job *job.Job `json:"job"`
+}
+
+// MyApplicationsHandler - отклики текущего пользователя со статусами (GET /api/applications/my)
+func MyApplicationsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	list, err := store.ListByUser(user.ID)
+	if err != nil {
+		http.Error(w, "Error loading applications", http.StatusInternalServerError)
+		return
+	}
+	newestFirst(list)
+
+	result := make([]withJob, 0, len(list))
+	for _, a := range list {
+		j, err := job.GetJobByID(a.JobID)
+		if err != nil {
+			http.Error(w, "Error loading jobs", http.StatusInternalServerError)
+			return
+		}
+		result = append(result, withJob{Application: a, Job: j})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// loadApplication загружает отклик из пути запроса вместе с вакансией
+// (nil, если вакансии уже нет) и сообщает, владелец ли вакансии текущий пользователь
+func loadApplication(w http.ResponseWriter, r *http.Request) (Application, *job.Job, bool) {
+	a, err := store.Get(r.PathValue("id"))
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, "Application not found", http.StatusNotFound)
+		return Application{}, nil, false
+	}
+	if err != nil {
+		http.Error(w, "Error loading applications", http.StatusInternalServerError)
+		return Application{}, nil, false
+	}
+	j, err := job.GetJobByID(a.JobID)
+	if err != nil {
+		http.Error(w, "Error loading jobs", http.StatusInternalServerError)
+		return Application{}, nil, false
+	}
+	return a, j, true
+}
+
+// GetHandler - один отклик (GET /api/applications/{id}) для кандидата или
+// владельца вакансии. Когда владелец впервые открывает отклик, он становится viewed.
+func GetHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	a, j, ok := loadApplication(w, r)
+	if !ok {
+		return
+	}
+
+	owner := j != nil && canManage(user, j)
+	if !owner && a.UserID != user.ID {
+		http.Error(w, "Forbidden: not your application", http.StatusForbidden)
+		return
+	}
+	if owner && a.Status == StatusNew && j.UserID == user.ID {
+		a.setStatus(StatusViewed, time.Now())
+		if err := store.Update(a); err != nil {
+			http.Error(w, "Error saving applications", http.StatusInternalServerError)
+			return
+		}
+		runHooks(statusHooks, a, *j)
+	}
+
+	view, err := withAnketa(a)
+	if err != nil {
+		http.Error(w, "Error loading ankety", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		applicant
+		Job *job.Job `json:"job"`
+	}{view, j})
+}
+
+// SetStatusHandler переводит отклик в другой статус (PUT /api/applications/{id}/status,
+// поле status: viewed, interview, offer или rejected). Только для владельца вакансии.
+func SetStatusHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	a, j, ok := loadApplication(w, r)
+	if !ok {
+		return
+	}
+	if j == nil || !canManage(user, j) {
+		http.Error(w, "Forbidden: not your job", http.StatusForbidden)
+		return
+	}
+
+	status := Status(r.FormValue("status"))
+	if !slices.Contains(ownerStatuses, status) {
+		http.Error(w, "Invalid status", http.StatusBadRequest)
+		return
+	}
+	if status != a.Status {
+		a.setStatus(status, time.Now())
+		if err := store.Update(a); err != nil {
+			http.Error(w, "Error saving applications", http.StatusInternalServerError)
+			return
+		}
+		runHooks(statusHooks, a, *j)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a)
+}
+
+// WithdrawHandler - кандидат отзывает свой отклик (DELETE /api/applications/{id})
+func WithdrawHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	a, err := store.Get(r.PathValue("id"))
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, "Application not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error loading applications", http.StatusInternalServerError)
+		return
+	}
+	if a.UserID != user.ID {
+		http.Error(w, "Forbidden: not your application", http.StatusForbidden)
+		return
+	}
+	if err := store.Delete(a.Id); err != nil {
+		http.Error(w, "Error saving applications", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
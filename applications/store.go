@@ -0,0 +1,180 @@
+package applications
+
+import (
+	"errors"
+	"talant/jsonfile"
+	"talant/memstore"
+)
+
+var (
+	// ErrNotFound возвращается хранилищем, если отклик не найден
+	ErrNotFound = errors.New("application not found")
+	// ErrAlreadyApplied возвращается при повторном отклике на ту же вакансию
+	ErrAlreadyApplied = errors.New("already applied to this job")
+)
+
+// Store - хранилище откликов
+type Store interface {
+	Get(id string) (Application, error)
+	ListByJob(jobID string) ([]Application, error)
+	ListByUser(userID string) ([]Application, error)
+	// Create возвращает ErrAlreadyApplied, если пользователь уже откликался на вакансию
+	Create(a Application) error
+	Update(a Application) error
+	Delete(id string) error
+}
+
+var store Store = NewJSONStore("applications.json")
+
+// SetStore подменяет хранилище, с которым работают обработчики
+func SetStore(s Store) {
+	store = s
+}
+
+// JSONStore хранит все отклики одним массивом в JSON-файле
+type JSONStore struct {
+	file *jsonfile.File[Application]
+}
+
+func NewJSONStore(path string) *JSONStore {
+	return &JSONStore{file: jsonfile.New[Application](path)}
+}
+
+func (s *JSONStore) filter(match func(Application) bool) ([]Application, error) {
+	list, err := s.file.Load()
+	if err != nil {
+		return nil, err
+	}
+	found := []Application{}
+	for _, a := range list {
+		if match(a) {
+			found = append(found, a)
+		}
+	}
+	return found, nil
+}
+
+func (s *JSONStore) Get(id string) (Application, error) {
+	found, err := s.filter(func(a Application) bool { return a.Id == id })
+	if err != nil {
+		return Application{}, err
+	}
+	if len(found) == 0 {
+		return Application{}, ErrNotFound
+	}
+	return found[0], nil
+}
+
+func (s *JSONStore) ListByJob(jobID string) ([]Application, error) {
+	return s.filter(func(a Application) bool { return a.JobID == jobID })
+}
+
+func (s *JSONStore) ListByUser(userID string) ([]Application, error) {
+	return s.filter(func(a Application) bool { return a.UserID == userID })
+}
+
+func (s *JSONStore) Create(a Application) error {
+	return s.file.Update(func(list []Application) ([]Application, error) {
+		for _, other := range list {
+			if other.JobID == a.JobID && other.UserID == a.UserID {
+				return nil, ErrAlreadyApplied
+			}
+		}
+		return append(list, a), nil
+	})
+}
+
+func (s *JSONStore) Update(a Application) error {
+	return s.file.Update(func(list []Application) ([]Application, error) {
+		for i := range list {
+			if list[i].Id == a.Id {
+				list[i] = a
+				return list, nil
+			}
+		}
+		return nil, ErrNotFound
+	})
+}
+
+func (s *JSONStore) Delete(id string) error {
+	return s.file.Update(func(list []Application) ([]Application, error) {
+		for i := range list {
+			if list[i].Id == id {
+				return append(list[:i], list[i+1:]...), nil
+			}
+		}
+		return nil, ErrNotFound
+	})
+}
+
+// CachedStore держит отклики в памяти с индексами по вакансии и кандидату
+type CachedStore struct {
+	m *memstore.Store[Application]
+}
+
+// NewCachedStore загружает отклики из path (и журнала path + ".journal")
+func NewCachedStore(path string) (*CachedStore, error) {
+	m, err := memstore.Open(memstore.Options[Application]{
+		Snapshot: path,
+		ID:       func(a Application) string { return a.Id },
+		Indexes: map[string]func(Application) string{
+			"job":  func(a Application) string { return a.JobID },
+			"user": func(a Application) string { return a.UserID },
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &CachedStore{m: m}, nil
+}
+
+func (s *CachedStore) Close() error {
+	return s.m.Close()
+}
+
+func (s *CachedStore) Get(id string) (Application, error) {
+	a, ok := s.m.Get(id)
+	if !ok {
+		return Application{}, ErrNotFound
+	}
+	return a, nil
+}
+
+func (s *CachedStore) ListByJob(jobID string) ([]Application, error) {
+	return s.m.Find("job", jobID), nil
+}
+
+func (s *CachedStore) ListByUser(userID string) ([]Application, error) {
+	return s.m.Find("user", userID), nil
+}
+
+func (s *CachedStore) Create(a Application) error {
+	return s.m.Put(a, func(v memstore.View[Application]) error {
+		for _, other := range v.Find("user", a.UserID) {
+			if other.JobID == a.JobID {
+				return ErrAlreadyApplied
+			}
+		}
+		return nil
+	})
+}
+
+func (s *CachedStore) Update(a Application) error {
+	return s.m.Put(a, func(v memstore.View[Application]) error {
+		if _, ok := v.Get(a.Id); !ok {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (s *CachedStore) Delete(id string) error {
+	ok, err := s.m.Delete(id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFound
+	}
+	return nil
+}
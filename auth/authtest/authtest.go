@@ -0,0 +1,37 @@
+// Package authtest - пользователи и запросы для тестов обработчиков, которые
+// берут проверенного пользователя из контекста (см. auth.RequireAuth)
+package authtest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"talant/auth"
+)
+
+var (
+	// Owner - автор записи в тестах
+	Owner = auth.CurrentUser{ID: "owner", Username: "owner"}
+	// Stranger - другой пользователь без особых прав
+	Stranger = auth.CurrentUser{ID: "stranger", Username: "stranger"}
+	// Admin - администратор: правит и удаляет чужие записи
+	Admin = auth.CurrentUser{ID: "admin", Username: "admin", Roles: []auth.Role{auth.RoleAdmin}}
+)
+
+// Request - запрос с формой form от имени user (nil - без входа)
+func Request(method, path string, form url.Values, user *auth.CurrentUser) *http.Request {
+	r := httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if user != nil {
+		r = r.WithContext(auth.WithUser(r.Context(), *user))
+	}
+	return r
+}
+
+// Serve вызывает обработчик с формой form от имени user (nil - без входа)
+func Serve(h http.HandlerFunc, method, path string, form url.Values, user *auth.CurrentUser) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h(w, Request(method, path, form, user))
+	return w
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"talant/moderation"
+	"time"
+)
+
+// banHooks скрывают и возвращают контент пользователя при бане и разбане.
+// auth не знает про объявления и анкеты, поэтому пакеты подключаются через OnBan.
+var banHooks []func(userID string, ban *moderation.State) error
+
+// OnBan подключает обработчик бана: ban != nil - пользователя забанили, nil - разбанили
+func OnBan(hook func(userID string, ban *moderation.State) error) {
+	banHooks = append(banHooks, hook)
+}
+
+func runBanHooks(userID string, ban *moderation.State) error {
+	for _, hook := range banHooks {
+		if err := hook(userID, ban); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setBan загружает пользователя из пути, меняет бан и применяет его к контенту
+func setBan(w http.ResponseWriter, r *http.Request, ban *moderation.State) {
+	user, err := users.GetByID(r.PathValue("id"))
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error loading users", http.StatusInternalServerError)
+		return
+	}
+
+	user.Ban = ban
+	user.UpdatedAt = time.Now()
+	if err := users.Update(user); err != nil {
+		http.Error(w, "Error writing data file", http.StatusInternalServerError)
+		return
+	}
+	if ban != nil {
+		// Завершаем все сессии: выданные токены доступа сразу перестают действовать
+		if _, err := revokeUserSessions(user.Id, nil); err != nil {
+			http.Error(w, "Error revoking sessions", http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := runBanHooks(user.Id, ban); err != nil {
+		fmt.Printf("Ошибка модерации контента пользователя %s: %v\n", user.Id, err)
+		http.Error(w, "Error moderating user content", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newUserView(user))
+}
+
+// BanUserHandler банит пользователя (POST /admin/users/{id}/ban, поле reason):
+// вход блокируется, сессии завершаются, объявления и анкета скрываются
+func BanUserHandler(w http.ResponseWriter, r *http.Request) {
+	current, ok := MustUser(w, r)
+	if !ok {
+		return
+	}
+	reason := r.FormValue("reason")
+	if reason == "" {
+		http.Error(w, "Reason is required", http.StatusBadRequest)
+		return
+	}
+	if r.PathValue("id") == current.ID {
+		http.Error(w, "Cannot ban yourself", http.StatusConflict)
+		return
+	}
+	setBan(w, r, moderation.New(reason, current.ID, true))
+}
+
+// UnbanUserHandler снимает бан и возвращает контент, скрытый вместе с ним
+// (POST /admin/users/{id}/unban). Скрытое модератором по отдельности остается скрытым.
+func UnbanUserHandler(w http.ResponseWriter, r *http.Request) {
+	setBan(w, r, nil)
+}
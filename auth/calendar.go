@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// Ссылка на личный календарь открывается приложением календаря без cookie,
+// поэтому пользователь узнается по подписанному токену в адресе. Токен
+// бессрочный; чтобы отозвать утекшую ссылку, пользователь меняет CalendarKey.
+
+// CalendarToken возвращает токен для ссылки на личный календарь пользователя
+func CalendarToken(userID string) (string, error) {
+	user, err := users.GetByID(userID)
+	if err != nil {
+		return "", err
+	}
+	return generateActionToken(user, purposeCalendar, 0)
+}
+
+// ResetCalendarToken меняет ключ календаря (старые ссылки перестают работать)
+// и возвращает новый токен
+func ResetCalendarToken(userID string) (string, error) {
+	user, err := users.GetByID(userID)
+	if err != nil {
+		return "", err
+	}
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	user.CalendarKey = hex.EncodeToString(key)
+	user.UpdatedAt = time.Now()
+	if err := users.Update(user); err != nil {
+		return "", err
+	}
+	return generateActionToken(user, purposeCalendar, 0)
+}
+
+// CalendarUser проверяет токен календаря и возвращает ID пользователя.
+// Забаненным календарь не отдается.
+func CalendarUser(token string) (string, error) {
+	user, err := parseActionToken(token, purposeCalendar)
+	if err != nil {
+		return "", err
+	}
+	if user.Ban != nil {
+		return "", ErrInvalidActionToken
+	}
+	return user.Id, nil
+}
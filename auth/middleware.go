@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// CurrentUser - пользователь, чей JWT проверил RequireAuth
+type CurrentUser struct {
+	ID        string
+	Username  string
+	SessionID string
+	Roles     []Role
+}
+
+type contextKey struct{}
+
+// WithUser кладет пользователя в контекст запроса
+func WithUser(ctx context.Context, user CurrentUser) context.Context {
+	return context.WithValue(ctx, contextKey{}, user)
+}
+
+// UserFromContext достает пользователя, которого положил RequireAuth
+func UserFromContext(ctx context.Context) (CurrentUser, bool) {
+	user, ok := ctx.Value(contextKey{}).(CurrentUser)
+	return user, ok
+}
+
+// tokenFromRequest берет JWT из cookie auth_token или из заголовка Authorization: Bearer
+func tokenFromRequest(r *http.Request) string {
+	if cookie, err := r.Cookie("auth_token"); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+	if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimPrefix(header, "Bearer ")
+	}
+	return ""
+}
+
+// RequireAuth пропускает запрос дальше только с валидным токеном и кладет
+// проверенного пользователя в контекст. Обработчики берут его через UserFromContext
+// и не доверяют никаким другим cookie.
+func RequireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var user CurrentUser
+		token := tokenFromRequest(r)
+		claims, err := ParseJWT(token)
+		if err == nil {
+			user = CurrentUser{ID: claims.UserID, Username: claims.Username, SessionID: claims.SessionID,
+				Roles: claims.Roles}
+		} else {
+			// Токен доступа истек или отсутствует - молча обновляем его по refresh-токену,
+			// чтобы фронтенду не нужно было самому вызывать /refresh
+			user, err = refreshFromRequest(w, r)
+			if err != nil {
+				if token == "" {
+					http.Error(w, "Unauthorized: missing token", http.StatusUnauthorized)
+				} else {
+					http.Error(w, "Unauthorized: invalid token", http.StatusUnauthorized)
+				}
+				return
+			}
+		}
+		ctx := WithUser(r.Context(), user)
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// MustUser достает пользователя из контекста. Если его нет (маршрут объявлен
+// без RequireAuth), сразу отвечает 401 и возвращает false.
+func MustUser(w http.ResponseWriter, r *http.Request) (CurrentUser, bool) {
+	user, ok := UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	}
+	return user, ok
+}
@@ -2,12 +2,14 @@ package auth
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"os"
+	"strings"
+	"talant/mailer"
+	"talant/moderation"
 	"time"
 
-	//"strings"
 	"github.com/golang-jwt/jwt/v5" // token generation
 	"github.com/google/uuid"       // UUID generation
 	"golang.org/x/crypto/bcrypt"   // password hashing
@@ -18,11 +20,25 @@ type User struct {
 	Username string `json:"username"`
 	Usermail string `json:"usermail"`
 	Password string `json:"password"`
+	// Roles - роли пользователя; пустой список означает DefaultRoles
+	Roles []Role `json:"roles,omitempty"`
+	// Ban - пользователь забанен: войти нельзя, его объявления и анкета скрыты
+	Ban *moderation.State `json:"ban,omitempty"`
+	// CalendarKey входит в подпись ссылки на личный календарь; смена ключа отзывает ссылку
+	CalendarKey string `json:"calendar_key,omitempty"`
+
+	// EmailVerified - пользователь перешел по ссылке из письма подтверждения
+	EmailVerified   bool       `json:"email_verified"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 }
 
 type CustomClaims struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
+	UserID    string `json:"user_id"`
+	Username  string `json:"username"`
+	SessionID string `json:"sid"` // Сессия, по которой выдан токен; по ней работает отзыв
+	Roles     []Role `json:"roles,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -32,24 +48,83 @@ type AuthResponse struct {
 	Message         string `json:"message,omitempty"`
 }
 
-var dataFile string = "data.json"
-var jwtSecretKey = []byte("YOUR_EXTREMELY_STRONG_SECRET_KEY") // Секретный ключ для подписи JWT
+var (
+	jwtSecretKey   []byte             // Секретный ключ для подписи JWT, задается через Configure
+	tokenTTL       = 15 * time.Minute // Срок действия токена доступа
+	cookieSecure   = false            // Secure у cookie; включать при работе по HTTPS
+	allowedOrigins map[string]bool    // Разрешенные Origin для CORS; пусто - только свой сайт, "*" - любой
+)
+
+// Settings - настройки пакета auth, приходят из config
+type Settings struct {
+	JWTSecret    string
+	TokenTTL     time.Duration
+	RefreshTTL   time.Duration
+	CookieSecure bool
+	// PublicURL - адрес сайта для ссылок в письмах
+	PublicURL string
+	// Mailer - отправитель писем подтверждения и сброса пароля
+	Mailer      mailer.Mailer
+	CORSOrigins []string
+}
+
+// Configure применяет настройки; вызывается один раз при старте сервера
+func Configure(s Settings) {
+	jwtSecretKey = []byte(s.JWTSecret)
+	if s.TokenTTL > 0 {
+		tokenTTL = s.TokenTTL
+	}
+	if s.RefreshTTL > 0 {
+		refreshTTL = s.RefreshTTL
+	}
+	cookieSecure = s.CookieSecure
+	if s.PublicURL != "" {
+		publicURL = strings.TrimRight(s.PublicURL, "/")
+	}
+	if s.Mailer != nil {
+		mail = s.Mailer
+	}
+	allowedOrigins = make(map[string]bool, len(s.CORSOrigins))
+	for _, origin := range s.CORSOrigins {
+		allowedOrigins[origin] = true
+	}
+}
+
+// setCookie выставляет HttpOnly-cookie с общими для сервера флагами
+func setCookie(w http.ResponseWriter, name, value string, expires time.Time) {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true, // Защита от XSS
+		Secure:   cookieSecure,
+	}
+	if expires.Before(time.Now()) {
+		cookie.MaxAge = -1
+	}
+	http.SetCookie(w, cookie)
+}
 
 // Middleware для обработки CORS
 func CORSMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		origin := r.Header.Get("Origin")
-		if origin != "" {
+		// Помечаем, что ответ зависит от Origin, чтобы кэширующие прокси не мешали
+		w.Header().Set("Vary", "Origin")
+		switch {
+		case origin == "":
+			// Запрос не из браузера или со своего сайта - CORS не нужен
+		case allowedOrigins[origin]:
+			// Явно разрешенному сайту можно отправлять cookie
 			w.Header().Set("Access-Control-Allow-Origin", origin)
-		} else {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		case allowedOrigins["*"]:
+			// Любой сайт - только без cookie, иначе любой сайт действовал бы от имени пользователя
 			w.Header().Set("Access-Control-Allow-Origin", "*")
 		}
-		// Помечаем, что ответ зависит от Origin, чтобы кэширующие прокси не мешали
-		w.Header().Set("Vary", "Origin")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		// Разрешаем отправлять cookie/credentials
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
 
 		// Обязательная обработка Preflight-запросов
 		if r.Method == http.MethodOptions {
@@ -72,7 +147,17 @@ func CheckAuthHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	cookie, err := r.Cookie("auth_token")
+	var username string
+	if err == nil {
+		_, username, err = ValidateJWT(cookie.Value)
+	}
 	if err != nil {
+		// Токен доступа истек или отсутствует - пробуем обновить его по refresh-токену
+		if user, refreshErr := refreshFromRequest(w, r); refreshErr == nil {
+			username, err = user.Username, nil
+		}
+	}
+	if err != nil && cookie == nil {
 		// Нет токена - пользователь не авторизован
 		response := AuthResponse{
 			IsAuthenticated: false,
@@ -83,8 +168,6 @@ func CheckAuthHandler(w http.ResponseWriter, r *http.Request) {
 		w.Write(jsonResponse)
 		return
 	}
-
-	_, username, err := ValidateJWT(cookie.Value)
 	if err != nil {
 		// Токен невалидный
 		response := AuthResponse{
@@ -113,65 +196,59 @@ func LogOutHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	expiredCookie := http.Cookie{
-		Name:     "auth_token",               // Имя куки, которое вы устанавливали при входе
-		Value:    "",                         // Обнуляем значение токена
-		Path:     "/",                        // Путь должен совпадать
-		Expires:  time.Now().Add(-time.Hour), // Устанавливаем дату в прошлом
-		MaxAge:   -1,                         // Также устанавливаем MaxAge в отрицательное значение
-		HttpOnly: true,                       // Важно: HttpOnly должен быть true
-		Secure:   false,                      // Используйте 'true', если работаете по HTTPS
-	}
-	http.SetCookie(w, &http.Cookie{
-		Name:     "id_cookie", // Используем более явное имя
-		Value:    "",
-		HttpOnly: true,  // Защита от XSS
-		Secure:   false, // !!! Использовать TRUE для продакшена (HTTPS)
-		Expires:  time.Now().Add(24 * time.Hour),
-		Path:     "/",
-	})
-	http.SetCookie(w, &expiredCookie)
-
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("Logged out successfully"))
-}
-
-func LoadUser() ([]User, error) {
-	data, err := os.ReadFile(dataFile)
-	if err != nil {
-		fmt.Printf("Ошибка чтения файла %s: %v\n", dataFile, err)
-		if os.IsNotExist(err) {
-			return []User{}, nil // Файл не найден, возвращаем пустой список
+	// Отзываем текущую сессию: ее id есть и в refresh-токене, и в токене доступа.
+	// Одного id мало - отозвать сессию может только тот, кто предъявил ее
+	// refresh-токен или подписанный по ней токен доступа.
+	var sid string
+	if cookie, err := r.Cookie("refresh_token"); err == nil {
+		if sess, ok := sessionByRefresh(cookie.Value); ok {
+			sid = sess.ID
 		}
-		return nil, fmt.Errorf("ошибка чтения файла: %w", err)
 	}
-
-	var users []User
-	err = json.Unmarshal(data, &users)
-	if err != nil {
-		fmt.Printf("Ошибка парсинга JSON: %v. Данные: %s\n", err, string(data))
-		return nil, fmt.Errorf("ошибка парсинга JSON: %w", err)
+	if sid == "" {
+		if claims, err := ParseJWT(tokenFromRequest(r)); err == nil {
+			sid = claims.SessionID
+		}
 	}
+	if sid != "" {
+		if sess, err := sessions.Get(sid); err == nil {
+			if err := revokeSession(sess); err != nil {
+				http.Error(w, "Error revoking session", http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+
+	// Обнуляем cookie датой в прошлом
+	clearAuthCookies(w)
 
-	return users, nil
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Logged out successfully"))
 }
 
-// GenerateJWT создает подписанный токен
-func GenerateJWT(userID, username string) (string, error) {
-	// Устанавливаем срок действия (например, 24 часа)
-	expirationTime := time.Now().Add(24 * time.Hour)
+// GenerateJWT создает подписанный короткоживущий токен доступа для сессии
+func GenerateJWT(userID, username, sessionID string, roles []Role) (string, error) {
+	// Устанавливаем срок действия из настроек (по умолчанию 15 минут)
+	expirationTime := time.Now().Add(tokenTTL)
 
 	// 1. Создание полезной нагрузки (Claims)
 	claims := &CustomClaims{
-		UserID:   userID,
-		Username: username,
+		UserID:    userID,
+		Username:  username,
+		SessionID: sessionID,
+		Roles:     roles,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime), // 'exp' - время истечения
 			IssuedAt:  jwt.NewNumericDate(time.Now()),     // 'iat' - время создания
 			Subject:   userID,                             // 'sub' - тема (часто UserID)
+			ID:        uuid.New().String(),                // 'jti' - уникальный ID токена
 		},
 	}
 
+	if len(jwtSecretKey) == 0 {
+		return "", errors.New("jwt secret is not configured")
+	}
+
 	// 2. Создание токена с алгоритмом подписи HS256
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
@@ -186,21 +263,37 @@ func GenerateJWT(userID, username string) (string, error) {
 
 // ValidateJWT распарсивает и валидирует токен, возвращая userID и username
 func ValidateJWT(tokenString string) (string, string, error) {
+	claims, err := ParseJWT(tokenString)
+	if err != nil {
+		return "", "", err
+	}
+	return claims.UserID, claims.Username, nil
+}
+
+// ParseJWT проверяет подпись и срок токена, а также что его сессия не отозвана
+func ParseJWT(tokenString string) (*CustomClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &CustomClaims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
+		if len(jwtSecretKey) == 0 {
+			return nil, errors.New("jwt secret is not configured")
+		}
 		return jwtSecretKey, nil
 	})
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*CustomClaims); ok && token.Valid {
-		return claims.UserID, claims.Username, nil
+	claims, ok := token.Claims.(*CustomClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
 	}
-
-	return "", "", fmt.Errorf("invalid token")
+	// Токены без сессии выдавались до появления отзыва - их не принимаем
+	if claims.SessionID == "" || isRevoked(claims.SessionID) {
+		return nil, fmt.Errorf("token revoked")
+	}
+	return claims, nil
 }
 
 // Хеширует пароль и возвращает строку хэша
@@ -213,6 +306,16 @@ func HashPassword(password string) (string, error) {
 	return string(bytes), nil
 }
 
+// findByLogin ищет пользователя по имени, а если такого нет - по почте:
+// входить можно и так, и так
+func findByLogin(login string) (User, error) {
+	user, err := users.GetByUsername(login)
+	if errors.Is(err, ErrNotFound) {
+		user, err = users.GetByEmail(login)
+	}
+	return user, err
+}
+
 func LoaginHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -225,52 +328,40 @@ func LoaginHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Missing fields", http.StatusBadRequest)
 		return
 	}
-	users, err := LoadUser()
-	if err != nil {
+	user, err := findByLogin(usernameOrMail)
+	if err != nil && !errors.Is(err, ErrNotFound) {
 		http.Error(w, "Error loading users", http.StatusInternalServerError)
 		return
 	}
 	var authenticatedUser *User
-	for i, user := range users {
-		if user.Username == usernameOrMail || user.Usermail == usernameOrMail {
-			if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err == nil {
-				authenticatedUser = &users[i]
-				break
-			}
+	if err == nil {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err == nil {
+			authenticatedUser = &user
 		}
 	}
 
+	// Причину бана показываем только тому, кто знает пароль
+	if authenticatedUser != nil && authenticatedUser.Ban != nil {
+		http.Error(w, "Account banned: "+authenticatedUser.Ban.Reason, http.StatusForbidden)
+		return
+	}
 	if authenticatedUser == nil {
 		// Если пользователь не найден ИЛИ пароль был неверен
 		http.Error(w, "Invalid username or password", http.StatusUnauthorized)
 		return
 	}
-	userID := authenticatedUser.Id
-
-	// 2. ГЕНЕРАЦИЯ НОВОГО ТОКЕНА (Правильно!)
-	tokenString, err := GenerateJWT(authenticatedUser.Id, authenticatedUser.Username)
+	// 2. Новая сессия: короткий токен доступа + refresh-токен, который хранится на сервере
+	sess, refresh, err := startSession(authenticatedUser.Id, r)
 	if err != nil {
-		http.Error(w, "Error generating token", http.StatusInternalServerError)
+		http.Error(w, "Error creating session", http.StatusInternalServerError)
 		return
 	}
 
-	// 3. Установка Cookie с новым токеном
-	http.SetCookie(w, &http.Cookie{
-		Name:     "auth_token", // Используем более явное имя
-		Value:    tokenString,
-		HttpOnly: true,  // Защита от XSS
-		Secure:   false, // !!! Использовать TRUE для продакшена (HTTPS)
-		Expires:  time.Now().Add(24 * time.Hour),
-		Path:     "/",
-	})
-	http.SetCookie(w, &http.Cookie{
-		Name:     "id_cookie", // Используем более явное имя
-		Value:    userID,
-		HttpOnly: true,  // Защита от XSS
-		Secure:   false, // !!! Использовать TRUE для продакшена (HTTPS)
-		Expires:  time.Now().Add(24 * time.Hour),
-		Path:     "/",
-	})
+	// 3. Установка Cookie с новыми токенами
+	if err := issueTokens(w, *authenticatedUser, sess, refresh); err != nil {
+		http.Error(w, "Error generating token", http.StatusInternalServerError)
+		return
+	}
 
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Login successful. New token set."))
@@ -289,19 +380,16 @@ func SingInHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Missing fields", http.StatusBadRequest)
 		return
 	}
-
-	users, err := LoadUser()
-	if err != nil {
-		http.Error(w, "Error loading users", http.StatusInternalServerError)
+	// Можно сразу выбрать, кто вы: соискатель или работодатель. Без выбора - обе роли
+	var roles []Role
+	switch role := Role(r.FormValue("role")); role {
+	case "":
+	case RoleCandidate, RoleRecruiter:
+		roles = []Role{role}
+	default:
+		http.Error(w, "Invalid role", http.StatusBadRequest)
 		return
 	}
-	for _, user := range users {
-		if user.Username == username || user.Usermail == usermail {
-			http.Error(w, "Username or email already exists", http.StatusConflict)
-			return
-		}
-	}
-	//если не найден пользователь:
 
 	id := uuid.New().String()
 	hashedPassword, err := HashPassword(password)
@@ -310,26 +398,34 @@ func SingInHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	now := time.Now()
 	newUser := User{
-		Id:       id,
-		Username: username,
-		Usermail: usermail,
-		Password: hashedPassword,
+		Id:        id,
+		Username:  username,
+		Usermail:  usermail,
+		Password:  hashedPassword,
+		Roles:     roles,
+		CreatedAt: now,
+		UpdatedAt: now,
 	}
-	users = append(users, newUser)
-	updatedData, err := json.MarshalIndent(users, "", "  ")
-	if err != nil {
-		http.Error(w, "Error encoding data", http.StatusInternalServerError)
+	// Хранилище само проверяет, что имя и почта еще не заняты
+	err = users.Create(newUser)
+	if errors.Is(err, ErrUserExists) {
+		http.Error(w, "Username or email already exists", http.StatusConflict)
 		return
 	}
-
-	err = os.WriteFile(dataFile, updatedData, 0644)
 	if err != nil {
 		// Если запись не удалась, возвращаем ошибку, и прекращаем выполнение
 		http.Error(w, "Error writing data file", http.StatusInternalServerError)
 		return
 	}
 
+	// Письмо с подтверждением почты. Если оно не ушло, регистрация все равно
+	// состоялась: письмо можно запросить повторно через /verify/resend
+	if err := sendVerification(newUser); err != nil {
+		fmt.Printf("Ошибка отправки письма подтверждения для %s: %v\n", newUser.Id, err)
+	}
+
 	// Если все успешно, отправляем ответ 201
 	w.WriteHeader(http.StatusCreated)
 	w.Write([]byte("Sign up successful"))
@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// useStore подменяет хранилища пользователей и сессий на время теста
+func useStore(t *testing.T, list ...User) *MemStore {
+	t.Helper()
+	prevUsers, prevSessions, prevSecret := users, sessions, jwtSecretKey
+	s := NewMemStore(list...)
+	SetStore(s)
+	if err := SetSessionStore(NewJSONSessionStore(filepath.Join(t.TempDir(), "sessions.json"))); err != nil {
+		t.Fatal(err)
+	}
+	jwtSecretKey = []byte("test-secret")
+	t.Cleanup(func() {
+		users, jwtSecretKey = prevUsers, prevSecret
+		SetSessionStore(prevSessions)
+	})
+	return s
+}
+
+func post(h http.HandlerFunc, form url.Values) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h(w, r)
+	return w
+}
+
+func TestSingInHandler(t *testing.T) {
+	s := useStore(t)
+	form := url.Values{"username": {"ivan"}, "usermail": {"ivan@example.com"}, "password": {"secret"}}
+
+	if w := post(SingInHandler, form); w.Code != http.StatusCreated {
+		t.Fatalf("sign up: %d %s", w.Code, w.Body)
+	}
+	u, err := s.GetByUsername("ivan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Usermail != "ivan@example.com" || u.Password == "secret" || u.Id == "" {
+		t.Errorf("stored = %+v, want hashed password and id", u)
+	}
+
+	// Занятые имя или почта
+	if w := post(SingInHandler, form); w.Code != http.StatusConflict {
+		t.Errorf("same user again: %d, want 409", w.Code)
+	}
+	form.Set("username", "other")
+	if w := post(SingInHandler, form); w.Code != http.StatusConflict {
+		t.Errorf("same email: %d, want 409", w.Code)
+	}
+	if list, _ := s.List(); len(list) != 1 {
+		t.Errorf("stored %d users, want 1", len(list))
+	}
+}
+
+func TestLoaginHandler(t *testing.T) {
+	hash, err := HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	useStore(t, User{Id: "1", Username: "ivan", Usermail: "ivan@example.com", Password: hash})
+
+	for _, login := range []string{"ivan", "ivan@example.com"} {
+		w := post(LoaginHandler, url.Values{"username": {login}, "password": {"secret"}})
+		if w.Code != http.StatusOK {
+			t.Fatalf("login as %s: %d %s", login, w.Code, w.Body)
+		}
+		var token string
+		for _, c := range w.Result().Cookies() {
+			if c.Name == "auth_token" {
+				token = c.Value
+			}
+		}
+		claims, err := ParseJWT(token)
+		if err != nil || claims.UserID != "1" {
+			t.Errorf("login as %s: token claims %+v, %v", login, claims, err)
+		}
+	}
+
+	if w := post(LoaginHandler, url.Values{"username": {"ivan"}, "password": {"wrong"}}); w.Code != http.StatusUnauthorized {
+		t.Errorf("wrong password: %d, want 401", w.Code)
+	}
+	if w := post(LoaginHandler, url.Values{"username": {"nobody"}, "password": {"secret"}}); w.Code != http.StatusUnauthorized {
+		t.Errorf("unknown user: %d, want 401", w.Code)
+	}
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	prev := allowedOrigins
+	t.Cleanup(func() { allowedOrigins = prev })
+	handler := CORSMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		allowed     []string
+		origin      string
+		allowOrigin string
+		credentials bool
+	}{
+		// Пустой список - только свой сайт
+		{nil, "https://evil.example", "", false},
+		{[]string{"https://talant.example"}, "https://talant.example", "https://talant.example", true},
+		{[]string{"https://talant.example"}, "https://evil.example", "", false},
+		// "*" - любой сайт, но без cookie
+		{[]string{"*"}, "https://evil.example", "*", false},
+		{[]string{"*", "https://talant.example"}, "https://talant.example", "https://talant.example", true},
+	}
+	for _, tt := range tests {
+		allowedOrigins = map[string]bool{}
+		for _, o := range tt.allowed {
+			allowedOrigins[o] = true
+		}
+		r := httptest.NewRequest(http.MethodOptions, "/api/jobs", nil)
+		r.Header.Set("Origin", tt.origin)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		got := w.Header().Get("Access-Control-Allow-Origin")
+		credentials := w.Header().Get("Access-Control-Allow-Credentials") == "true"
+		if got != tt.allowOrigin || credentials != tt.credentials {
+			t.Errorf("allowed %v, origin %s: Allow-Origin %q, credentials %v; want %q, %v",
+				tt.allowed, tt.origin, got, credentials, tt.allowOrigin, tt.credentials)
+		}
+	}
+}
+
+func TestLogOutHandlerChecksRefreshSecret(t *testing.T) {
+	hash, err := HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	useStore(t, User{Id: "1", Username: "ivan", Usermail: "ivan@example.com", Password: hash})
+	w := post(LoaginHandler, url.Values{"username": {"ivan"}, "password": {"secret"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("login: %d %s", w.Code, w.Body)
+	}
+	var refresh *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "refresh_token" {
+			refresh = c
+		}
+	}
+	sid, _, ok := splitRefresh(refresh.Value)
+	if !ok {
+		t.Fatalf("refresh token %q", refresh.Value)
+	}
+
+	logout := func(c *http.Cookie) {
+		r := httptest.NewRequest(http.MethodPost, "/logout", nil)
+		r.AddCookie(c)
+		w := httptest.NewRecorder()
+		LogOutHandler(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("logout: %d %s", w.Code, w.Body)
+		}
+	}
+
+	// Чужой id сессии с подобранным секретом сессию не отзывает
+	logout(&http.Cookie{Name: "refresh_token", Value: sid + ".x"})
+	if sess, err := sessions.Get(sid); err != nil || sess.RevokedAt != nil || !SessionActive(sid) {
+		t.Fatalf("forged logout revoked the session: %+v, %v", sess, err)
+	}
+
+	logout(refresh)
+	if sess, err := sessions.Get(sid); err != nil || sess.RevokedAt == nil || SessionActive(sid) {
+		t.Fatalf("logout did not revoke the session: %+v, %v", sess, err)
+	}
+}
+
+func TestSingInHandlerRoles(t *testing.T) {
+	s := useStore(t)
+	form := url.Values{"username": {"ivan"}, "usermail": {"ivan@example.com"}, "password": {"secret"}}
+
+	// Администратором себя не назначить
+	form.Set("role", string(RoleAdmin))
+	if w := post(SingInHandler, form); w.Code != http.StatusBadRequest {
+		t.Errorf("sign up as admin: %d, want 400", w.Code)
+	}
+	form.Set("role", string(RoleRecruiter))
+	if w := post(SingInHandler, form); w.Code != http.StatusCreated {
+		t.Fatalf("sign up as recruiter: %d %s", w.Code, w.Body)
+	}
+	if u, err := s.GetByUsername("ivan"); err != nil || !slices.Equal(u.Roles, []Role{RoleRecruiter}) {
+		t.Errorf("stored = %+v, %v; want recruiter", u, err)
+	}
+}
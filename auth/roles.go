@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"talant/moderation"
+	"time"
+)
+
+// Role - роль пользователя; права определяются набором ролей
+type Role string
+
+const (
+	RoleCandidate Role = "candidate" // ищет работу: ведет анкету
+	RoleRecruiter Role = "recruiter" // нанимает: публикует объявления
+	RoleAdmin     Role = "admin"     // администратор сайта
+)
+
+// Permission - право на действие, его проверяет RequirePermission
+type Permission string
+
+const (
+	PermJobsWrite      Permission = "jobs:write"
+	PermAnketyWrite    Permission = "ankety:write"
+	PermJobsModerate   Permission = "jobs:moderate"
+	PermAnketyModerate Permission = "ankety:moderate"
+	PermEventsModerate Permission = "events:moderate"
+	PermUsersManage    Permission = "users:manage"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleCandidate: {PermAnketyWrite},
+	RoleRecruiter: {PermJobsWrite},
+	RoleAdmin: {PermJobsWrite, PermAnketyWrite, PermJobsModerate, PermAnketyModerate,
+		PermEventsModerate, PermUsersManage},
+}
+
+// DefaultRoles - роли пользователя, у которого они не заданы (в том числе всех,
+// кто зарегистрировался до появления ролей): раньше любой мог и нанимать, и искать работу
+var DefaultRoles = []Role{RoleCandidate, RoleRecruiter}
+
+// effectiveRoles подставляет роли по умолчанию вместо пустого списка
+func effectiveRoles(roles []Role) []Role {
+	if len(roles) == 0 {
+		return DefaultRoles
+	}
+	return roles
+}
+
+// hasPermission - есть ли право хотя бы у одной из ролей
+func hasPermission(roles []Role, p Permission) bool {
+	for _, role := range effectiveRoles(roles) {
+		if slices.Contains(rolePermissions[role], p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Can - есть ли у пользователя право p
+func (u CurrentUser) Can(p Permission) bool {
+	return hasPermission(u.Roles, p)
+}
+
+// parseRoles разбирает список ролей через запятую; неизвестная роль - ошибка
+func parseRoles(s string) ([]Role, error) {
+	var roles []Role
+	for _, item := range strings.Split(s, ",") {
+		role := Role(strings.TrimSpace(item))
+		if role == "" {
+			continue
+		}
+		if _, ok := rolePermissions[role]; !ok {
+			return nil, fmt.Errorf("unknown role: %s", role)
+		}
+		if !slices.Contains(roles, role) {
+			roles = append(roles, role)
+		}
+	}
+	return roles, nil
+}
+
+// RequirePermission - RequireAuth, который дополнительно требует право p
+func RequirePermission(p Permission, next http.HandlerFunc) http.HandlerFunc {
+	return RequireAuth(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := MustUser(w, r)
+		if !ok {
+			return
+		}
+		if !user.Can(p) {
+			http.Error(w, "Forbidden: missing permission "+string(p), http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	})
+}
+
+// BootstrapAdmins выдает роль администратора пользователям из настроек
+// (по имени или почте). Вызывается при старте сервера; ненайденные пропускаются.
+func BootstrapAdmins(logins []string) error {
+	for _, login := range logins {
+		user, err := findByLogin(login)
+		if errors.Is(err, ErrNotFound) {
+			fmt.Printf("Администратор %s не найден: сначала зарегистрируйте его\n", login)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if slices.Contains(user.Roles, RoleAdmin) {
+			continue
+		}
+		user.Roles = append(slices.Clone(effectiveRoles(user.Roles)), RoleAdmin)
+		user.UpdatedAt = time.Now()
+		if err := users.Update(user); err != nil {
+			return fmt.Errorf("администратор %s: %w", login, err)
+		}
+		fmt.Printf("Пользователь %s назначен администратором\n", user.Username)
+	}
+	return nil
+}
+
+// userView - пользователь без хэша пароля, для админки
+type userView struct {
+	ID            string            `json:"id"`
+	Username      string            `json:"username"`
+	Usermail      string            `json:"usermail"`
+	Roles         []Role            `json:"roles"`
+	EmailVerified bool              `json:"email_verified"`
+	CreatedAt     time.Time         `json:"created_at"`
+	Ban           *moderation.State `json:"ban,omitempty"`
+}
+
+func newUserView(u User) userView {
+	return userView{
+		ID:            u.Id,
+		Username:      u.Username,
+		Usermail:      u.Usermail,
+		Roles:         effectiveRoles(u.Roles),
+		EmailVerified: u.EmailVerified,
+		CreatedAt:     u.CreatedAt,
+		Ban:           u.Ban,
+	}
+}
+
+// ListUsersHandler - список пользователей для администратора (GET /admin/users)
+func ListUsersHandler(w http.ResponseWriter, r *http.Request) {
+	list, err := users.List()
+	if err != nil {
+		http.Error(w, "Error loading users", http.StatusInternalServerError)
+		return
+	}
+	views := make([]userView, 0, len(list))
+	for _, u := range list {
+		views = append(views, newUserView(u))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(views)
+}
+
+// SetRolesHandler задает роли пользователя (PUT /admin/users/{id}/roles, поле roles
+// через запятую). Роли записаны в токенах, поэтому при их смене сессии
+// пользователя завершаются: со старыми ролями он больше ничего не сделает, а
+// новые получит при входе. Меняя свои роли, администратор сохраняет текущую сессию.
+func SetRolesHandler(w http.ResponseWriter, r *http.Request) {
+	current, ok := MustUser(w, r)
+	if !ok {
+		return
+	}
+	r.ParseForm()
+	roles, err := parseRoles(r.FormValue("roles"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(roles) == 0 {
+		http.Error(w, "Missing fields", http.StatusBadRequest)
+		return
+	}
+
+	id := r.PathValue("id")
+	// Снять роль администратора с самого себя нельзя - иначе можно остаться без админов
+	if id == current.ID && !slices.Contains(roles, RoleAdmin) {
+		http.Error(w, "Cannot remove your own admin role", http.StatusConflict)
+		return
+	}
+	user, err := users.GetByID(id)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error loading users", http.StatusInternalServerError)
+		return
+	}
+
+	changed := !slices.Equal(user.Roles, roles)
+	user.Roles = roles
+	user.UpdatedAt = time.Now()
+	if err := users.Update(user); err != nil {
+		http.Error(w, "Error writing data file", http.StatusInternalServerError)
+		return
+	}
+	if changed {
+		_, err := revokeUserSessions(user.Id, func(sess Session) bool {
+			return sess.ID == current.SessionID
+		})
+		if err != nil {
+			http.Error(w, "Error revoking sessions", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newUserView(user))
+}
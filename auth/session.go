@@ -0,0 +1,405 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidRefresh - refresh-токен не подходит ни к одной активной сессии
+	ErrInvalidRefresh = errors.New("invalid refresh token")
+	// ErrRefreshReuse - предъявлен уже замененный refresh-токен; сессия отозвана
+	ErrRefreshReuse = errors.New("refresh token reuse detected")
+)
+
+var (
+	refreshTTL = 30 * 24 * time.Hour // Срок жизни сессии (refresh-токена)
+	// refreshGrace - сколько предыдущий refresh-токен еще принимается после замены.
+	// Несколько вкладок могут одновременно обновлять токен; это не кража.
+	refreshGrace = 30 * time.Second
+
+	revokedMu sync.RWMutex
+	revoked   = map[string]time.Time{} // ID сессии -> время отзыва
+)
+
+// hashToken - в хранилище попадает только хэш секрета refresh-токена
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func newSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// splitRefresh разбирает refresh-токен вида "<id сессии>.<секрет>"
+func splitRefresh(token string) (string, string, bool) {
+	sid, secret, ok := strings.Cut(token, ".")
+	if !ok || sid == "" || secret == "" {
+		return "", "", false
+	}
+	return sid, secret, true
+}
+
+func clientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		ip, _, _ := strings.Cut(forwarded, ",")
+		return strings.TrimSpace(ip)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// startSession создает сессию после входа и возвращает ее refresh-токен
+func startSession(userID string, r *http.Request) (Session, string, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return Session{}, "", err
+	}
+	now := time.Now().UTC()
+	sess := Session{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		RefreshHash: hashToken(secret),
+		RotatedAt:   now,
+		UserAgent:   r.UserAgent(),
+		IP:          clientIP(r),
+		CreatedAt:   now,
+		LastUsedAt:  now,
+		ExpiresAt:   now.Add(refreshTTL),
+	}
+	if err := sessions.Create(sess); err != nil {
+		return Session{}, "", err
+	}
+	return sess, sess.ID + "." + secret, nil
+}
+
+// rotateSession проверяет refresh-токен и заменяет его новым. Пустой новый
+// токен означает, что предъявлен только что замененный токен в пределах
+// refreshGrace: сессия действительна, но менять токен еще раз не нужно.
+func rotateSession(token string) (Session, string, error) {
+	sid, secret, ok := splitRefresh(token)
+	if !ok {
+		return Session{}, "", ErrInvalidRefresh
+	}
+	sess, err := sessions.Get(sid)
+	if errors.Is(err, ErrSessionNotFound) {
+		return Session{}, "", ErrInvalidRefresh
+	}
+	if err != nil {
+		return Session{}, "", err
+	}
+	now := time.Now().UTC()
+	if !sess.Active(now) {
+		return Session{}, "", ErrInvalidRefresh
+	}
+
+	switch hashToken(secret) {
+	case sess.RefreshHash:
+		newSecretValue, err := newSecret()
+		if err != nil {
+			return Session{}, "", err
+		}
+		sess.PrevRefreshHash = sess.RefreshHash
+		sess.RefreshHash = hashToken(newSecretValue)
+		sess.RotatedAt = now
+		sess.LastUsedAt = now
+		if err := sessions.Update(sess); err != nil {
+			return Session{}, "", err
+		}
+		return sess, sess.ID + "." + newSecretValue, nil
+	case sess.PrevRefreshHash:
+		if now.Sub(sess.RotatedAt) < refreshGrace {
+			return sess, "", nil
+		}
+		// Старый токен всплыл позже - скорее всего его украли. Отзываем всю сессию.
+		if err := revokeSession(sess); err != nil {
+			return Session{}, "", err
+		}
+		return Session{}, "", ErrRefreshReuse
+	}
+	return Session{}, "", ErrInvalidRefresh
+}
+
+// sessionByRefresh - сессия, к которой подходит refresh-токен: текущий или
+// только что замененный (как в rotateSession). Токен не меняется.
+func sessionByRefresh(token string) (Session, bool) {
+	sid, secret, ok := splitRefresh(token)
+	if !ok {
+		return Session{}, false
+	}
+	sess, err := sessions.Get(sid)
+	if err != nil {
+		return Session{}, false
+	}
+	hash := hashToken(secret)
+	return sess, hash == sess.RefreshHash || hash == sess.PrevRefreshHash
+}
+
+// revokeSession отзывает сессию: refresh-токен больше не принимается,
+// а выданные по ней токены доступа отсекает список отзыва в ValidateJWT
+func revokeSession(sess Session) error {
+	if sess.RevokedAt == nil {
+		now := time.Now().UTC()
+		sess.RevokedAt = &now
+		if err := sessions.Update(sess); err != nil {
+			return err
+		}
+	}
+	addRevoked(sess.ID, *sess.RevokedAt)
+	return nil
+}
+
+func addRevoked(sid string, at time.Time) {
+	revokedMu.Lock()
+	defer revokedMu.Unlock()
+	revoked[sid] = at
+	// Токены доступа живут не дольше tokenTTL, поэтому старые записи больше не нужны
+	for id, revokedAt := range revoked {
+		if time.Since(revokedAt) > tokenTTL {
+			delete(revoked, id)
+		}
+	}
+}
+
+func isRevoked(sid string) bool {
+	revokedMu.RLock()
+	defer revokedMu.RUnlock()
+	_, ok := revoked[sid]
+	return ok
+}
+
+// SessionActive - не отозвана и не истекла ли сессия. Долгим соединениям (поток
+// уведомлений) мало проверки токена при подключении: сессию могут завершить, пока оно открыто.
+func SessionActive(sid string) bool {
+	if isRevoked(sid) {
+		return false
+	}
+	sess, err := sessions.Get(sid)
+	return err == nil && sess.Active(time.Now())
+}
+
+// loadRevocations восстанавливает список отзыва из хранилища после перезапуска
+func loadRevocations() error {
+	list, err := sessions.List()
+	if err != nil {
+		return err
+	}
+	revokedMu.Lock()
+	defer revokedMu.Unlock()
+	revoked = map[string]time.Time{}
+	for _, sess := range list {
+		if sess.RevokedAt != nil && time.Since(*sess.RevokedAt) <= tokenTTL {
+			revoked[sess.ID] = *sess.RevokedAt
+		}
+	}
+	return nil
+}
+
+// issueTokens выставляет cookie с новым токеном доступа и, если он есть,
+// с новым refresh-токеном
+func issueTokens(w http.ResponseWriter, user User, sess Session, refresh string) error {
+	tokenString, err := GenerateJWT(user.Id, user.Username, sess.ID, user.Roles)
+	if err != nil {
+		return err
+	}
+	setCookie(w, "auth_token", tokenString, time.Now().Add(tokenTTL))
+	if refresh != "" {
+		setCookie(w, "refresh_token", refresh, sess.ExpiresAt)
+	}
+	return nil
+}
+
+// refreshFromRequest обновляет токены по cookie refresh_token. Используется
+// явным /refresh и RequireAuth, когда токен доступа истек.
+func refreshFromRequest(w http.ResponseWriter, r *http.Request) (CurrentUser, error) {
+	cookie, err := r.Cookie("refresh_token")
+	if err != nil || cookie.Value == "" {
+		return CurrentUser{}, ErrInvalidRefresh
+	}
+	sess, refresh, err := rotateSession(cookie.Value)
+	if err != nil {
+		return CurrentUser{}, err
+	}
+	user, err := users.GetByID(sess.UserID)
+	if err != nil || user.Ban != nil {
+		revokeSession(sess)
+		return CurrentUser{}, ErrInvalidRefresh
+	}
+	if err := issueTokens(w, user, sess, refresh); err != nil {
+		return CurrentUser{}, err
+	}
+	return CurrentUser{ID: user.Id, Username: user.Username, SessionID: sess.ID, Roles: user.Roles}, nil
+}
+
+// clearAuthCookies стирает cookie токенов у клиента
+func clearAuthCookies(w http.ResponseWriter) {
+	expired := time.Now().Add(-time.Hour)
+	setCookie(w, "auth_token", "", expired)
+	setCookie(w, "refresh_token", "", expired)
+	// id_cookie больше не выдается (пользователь берется только из JWT),
+	// но у старых клиентов он мог остаться - стираем
+	setCookie(w, "id_cookie", "", expired)
+}
+
+// RefreshHandler меняет refresh-токен на новую пару токенов
+func RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	_, err := refreshFromRequest(w, r)
+	if err != nil {
+		if errors.Is(err, ErrInvalidRefresh) || errors.Is(err, ErrRefreshReuse) {
+			clearAuthCookies(w)
+			http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+			return
+		}
+		http.Error(w, "Error refreshing token", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Token refreshed"))
+}
+
+// sessionView - сессия в ответе API, без хэшей токенов
+type sessionView struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	IP         string    `json:"ip,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// SessionsHandler возвращает активные сессии текущего пользователя
+func SessionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user, ok := MustUser(w, r)
+	if !ok {
+		return
+	}
+	list, err := sessions.ListByUser(user.ID)
+	if err != nil {
+		http.Error(w, "Error loading sessions", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	views := []sessionView{}
+	for _, sess := range list {
+		if !sess.Active(now) {
+			continue
+		}
+		views = append(views, sessionView{
+			ID:         sess.ID,
+			UserAgent:  sess.UserAgent,
+			IP:         sess.IP,
+			CreatedAt:  sess.CreatedAt,
+			LastUsedAt: sess.LastUsedAt,
+			ExpiresAt:  sess.ExpiresAt,
+			Current:    sess.ID == user.SessionID,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(views)
+}
+
+// RevokeSessionHandler отзывает одну сессию текущего пользователя
+func RevokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user, ok := MustUser(w, r)
+	if !ok {
+		return
+	}
+	sess, err := sessions.Get(r.PathValue("id"))
+	if errors.Is(err, ErrSessionNotFound) || (err == nil && sess.UserID != user.ID) {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error loading sessions", http.StatusInternalServerError)
+		return
+	}
+	if err := revokeSession(sess); err != nil {
+		http.Error(w, "Error revoking session", http.StatusInternalServerError)
+		return
+	}
+	if sess.ID == user.SessionID {
+		clearAuthCookies(w)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeAllSessionsHandler отзывает все сессии пользователя.
+// С ?except_current=true текущая сессия остается ("выйти на других устройствах").
+func RevokeAllSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user, ok := MustUser(w, r)
+	if !ok {
+		return
+	}
+	keepCurrent := r.URL.Query().Get("except_current") == "true"
+
+	count, err := revokeUserSessions(user.ID, func(sess Session) bool {
+		return keepCurrent && sess.ID == user.SessionID
+	})
+	if err != nil {
+		http.Error(w, "Error revoking sessions", http.StatusInternalServerError)
+		return
+	}
+	if !keepCurrent {
+		clearAuthCookies(w)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"revoked": count})
+}
+
+// revokeUserSessions отзывает активные сессии пользователя, кроме тех, что keep оставляет
+func revokeUserSessions(userID string, keep func(Session) bool) (int, error) {
+	list, err := sessions.ListByUser(userID)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	count := 0
+	for _, sess := range list {
+		if !sess.Active(now) || (keep != nil && keep(sess)) {
+			continue
+		}
+		if err := revokeSession(sess); err != nil {
+			return count, fmt.Errorf("сессия %s: %w", sess.ID, err)
+		}
+		count++
+	}
+	return count, nil
+}
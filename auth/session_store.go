@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"errors"
+	"talant/jsonfile"
+	"talant/memstore"
+	"time"
+)
+
+// ErrSessionNotFound возвращается хранилищем, если сессия не найдена
+var ErrSessionNotFound = errors.New("session not found")
+
+// Session - вход пользователя с одного устройства. Refresh-токен хранится
+// только в виде хэша; PrevRefreshHash нужен, чтобы заметить повторное
+// использование уже замененного токена.
+type Session struct {
+	ID              string     `json:"id"`
+	UserID          string     `json:"user_id"`
+	RefreshHash     string     `json:"refresh_hash"`
+	PrevRefreshHash string     `json:"prev_refresh_hash,omitempty"`
+	RotatedAt       time.Time  `json:"rotated_at"`
+	UserAgent       string     `json:"user_agent,omitempty"`
+	IP              string     `json:"ip,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	LastUsedAt      time.Time  `json:"last_used_at"`
+	ExpiresAt       time.Time  `json:"expires_at"`
+	RevokedAt       *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Active - сессия не отозвана и не истекла
+func (s Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// SessionStore - хранилище сессий
+type SessionStore interface {
+	List() ([]Session, error)
+	Get(id string) (Session, error)
+	ListByUser(userID string) ([]Session, error)
+	Create(s Session) error
+	Update(s Session) error
+}
+
+var sessions SessionStore = NewJSONSessionStore("sessions.json")
+
+// SetSessionStore подменяет хранилище сессий и заново загружает список отзыва
+func SetSessionStore(s SessionStore) error {
+	sessions = s
+	return loadRevocations()
+}
+
+// JSONSessionStore хранит все сессии одним массивом в JSON-файле
+type JSONSessionStore struct {
+	file *jsonfile.File[Session]
+}
+
+func NewJSONSessionStore(path string) *JSONSessionStore {
+	return &JSONSessionStore{file: jsonfile.New[Session](path)}
+}
+
+func (s *JSONSessionStore) List() ([]Session, error) {
+	return s.file.Load()
+}
+
+func (s *JSONSessionStore) Get(id string) (Session, error) {
+	list, err := s.file.Load()
+	if err != nil {
+		return Session{}, err
+	}
+	for _, sess := range list {
+		if sess.ID == id {
+			return sess, nil
+		}
+	}
+	return Session{}, ErrSessionNotFound
+}
+
+func (s *JSONSessionStore) ListByUser(userID string) ([]Session, error) {
+	list, err := s.file.Load()
+	if err != nil {
+		return nil, err
+	}
+	userSessions := []Session{}
+	for _, sess := range list {
+		if sess.UserID == userID {
+			userSessions = append(userSessions, sess)
+		}
+	}
+	return userSessions, nil
+}
+
+func (s *JSONSessionStore) Create(sess Session) error {
+	return s.file.Update(func(list []Session) ([]Session, error) {
+		return append(list, sess), nil
+	})
+}
+
+func (s *JSONSessionStore) Update(sess Session) error {
+	return s.file.Update(func(list []Session) ([]Session, error) {
+		for i := range list {
+			if list[i].ID == sess.ID {
+				list[i] = sess
+				return list, nil
+			}
+		}
+		return nil, ErrSessionNotFound
+	})
+}
+
+// CachedSessionStore держит сессии в памяти с индексом по пользователю
+type CachedSessionStore struct {
+	m *memstore.Store[Session]
+}
+
+// NewCachedSessionStore загружает сессии из path (и журнала path + ".journal")
+func NewCachedSessionStore(path string) (*CachedSessionStore, error) {
+	m, err := memstore.Open(memstore.Options[Session]{
+		Snapshot: path,
+		ID:       func(s Session) string { return s.ID },
+		Indexes: map[string]func(Session) string{
+			"user": func(s Session) string { return s.UserID },
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &CachedSessionStore{m: m}, nil
+}
+
+func (s *CachedSessionStore) Close() error {
+	return s.m.Close()
+}
+
+func (s *CachedSessionStore) List() ([]Session, error) {
+	return s.m.List(), nil
+}
+
+func (s *CachedSessionStore) Get(id string) (Session, error) {
+	sess, ok := s.m.Get(id)
+	if !ok {
+		return Session{}, ErrSessionNotFound
+	}
+	return sess, nil
+}
+
+func (s *CachedSessionStore) ListByUser(userID string) ([]Session, error) {
+	return s.m.Find("user", userID), nil
+}
+
+func (s *CachedSessionStore) Create(sess Session) error {
+	return s.m.Put(sess, nil)
+}
+
+func (s *CachedSessionStore) Update(sess Session) error {
+	return s.m.Put(sess, func(v memstore.View[Session]) error {
+		if _, ok := v.Get(sess.ID); !ok {
+			return ErrSessionNotFound
+		}
+		return nil
+	})
+}
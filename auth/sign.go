@@ -0,0 +1,24 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// Sign подписывает data секретом сервера (HMAC-SHA256) для коротких токенов
+// других пакетов, например билетов. purpose разделяет виды токенов: подпись
+// одного вида не подойдет для другого.
+func Sign(purpose, data string) string {
+	mac := hmac.New(sha256.New, jwtSecretKey)
+	mac.Write([]byte(purpose + "|" + data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature проверяет подпись, выданную Sign
+func VerifySignature(purpose, data, signature string) bool {
+	if len(jwtSecretKey) == 0 {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(purpose, data)), []byte(signature))
+}
@@ -0,0 +1,256 @@
+package auth
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"talant/jsonfile"
+	"talant/memstore"
+)
+
+var (
+	// ErrNotFound возвращается хранилищем, если пользователь не найден
+	ErrNotFound = errors.New("user not found")
+	// ErrUserExists возвращается при создании пользователя с занятым именем или почтой
+	ErrUserExists = errors.New("username or email already exists")
+)
+
+// UserStore - хранилище пользователей. Обработчики работают только через этот интерфейс,
+// поэтому JSON-файл можно заменить другой реализацией (БД, in-memory для тестов).
+type UserStore interface {
+	List() ([]User, error)
+	GetByID(id string) (User, error)
+	GetByUsername(username string) (User, error)
+	GetByEmail(email string) (User, error)
+	Create(u User) error
+	Update(u User) error
+}
+
+var users UserStore = NewJSONStore("data.json")
+
+// SetStore подменяет хранилище пользователей
+func SetStore(s UserStore) {
+	users = s
+}
+
+// GetUser возвращает пользователя по ID для других пакетов (например, почту участников)
+func GetUser(id string) (User, error) {
+	return users.GetByID(id)
+}
+
+// JSONStore хранит всех пользователей одним массивом в JSON-файле
+type JSONStore struct {
+	file *jsonfile.File[User]
+}
+
+func NewJSONStore(path string) *JSONStore {
+	return &JSONStore{file: jsonfile.New[User](path)}
+}
+
+func (s *JSONStore) find(match func(User) bool) (User, error) {
+	list, err := s.file.Load()
+	if err != nil {
+		return User{}, err
+	}
+	for _, u := range list {
+		if match(u) {
+			return u, nil
+		}
+	}
+	return User{}, ErrNotFound
+}
+
+func (s *JSONStore) List() ([]User, error) {
+	return s.file.Load()
+}
+
+func (s *JSONStore) GetByID(id string) (User, error) {
+	return s.find(func(u User) bool { return u.Id == id })
+}
+
+func (s *JSONStore) GetByUsername(username string) (User, error) {
+	return s.find(func(u User) bool { return u.Username == username })
+}
+
+func (s *JSONStore) GetByEmail(email string) (User, error) {
+	return s.find(func(u User) bool { return u.Usermail == email })
+}
+
+func (s *JSONStore) Create(u User) error {
+	// Проверка уникальности и запись идут под одной блокировкой,
+	// поэтому две параллельные регистрации не создадут дубликат
+	return s.file.Update(func(list []User) ([]User, error) {
+		for _, existing := range list {
+			if existing.Username == u.Username || existing.Usermail == u.Usermail {
+				return nil, ErrUserExists
+			}
+		}
+		return append(list, u), nil
+	})
+}
+
+func (s *JSONStore) Update(u User) error {
+	return s.file.Update(func(list []User) ([]User, error) {
+		for i := range list {
+			if list[i].Id == u.Id {
+				list[i] = u
+				return list, nil
+			}
+		}
+		return nil, ErrNotFound
+	})
+}
+
+// CachedStore держит всех пользователей в памяти с индексами по имени и почте.
+// Изменения пишутся в журнал и периодически сворачиваются в JSON-файл.
+type CachedStore struct {
+	m *memstore.Store[User]
+}
+
+// NewCachedStore загружает пользователей из path (и журнала path + ".journal")
+func NewCachedStore(path string) (*CachedStore, error) {
+	m, err := memstore.Open(memstore.Options[User]{
+		Snapshot: path,
+		ID:       func(u User) string { return u.Id },
+		Indexes: map[string]func(User) string{
+			"username": func(u User) string { return u.Username },
+			"email":    func(u User) string { return u.Usermail },
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &CachedStore{m: m}, nil
+}
+
+func (s *CachedStore) Close() error {
+	return s.m.Close()
+}
+
+func (s *CachedStore) List() ([]User, error) {
+	return s.m.List(), nil
+}
+
+func (s *CachedStore) first(index, key string) (User, error) {
+	found := s.m.Find(index, key)
+	if len(found) == 0 {
+		return User{}, ErrNotFound
+	}
+	return found[0], nil
+}
+
+func (s *CachedStore) GetByID(id string) (User, error) {
+	u, ok := s.m.Get(id)
+	if !ok {
+		return User{}, ErrNotFound
+	}
+	return u, nil
+}
+
+func (s *CachedStore) GetByUsername(username string) (User, error) {
+	return s.first("username", username)
+}
+
+func (s *CachedStore) GetByEmail(email string) (User, error) {
+	return s.first("email", email)
+}
+
+// taken сообщает, что имя или почта уже заняты другим пользователем
+func taken(v memstore.View[User], u User) bool {
+	for _, other := range v.Find("username", u.Username) {
+		if other.Id != u.Id {
+			return true
+		}
+	}
+	for _, other := range v.Find("email", u.Usermail) {
+		if other.Id != u.Id {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *CachedStore) Create(u User) error {
+	return s.m.Put(u, func(v memstore.View[User]) error {
+		if _, ok := v.Get(u.Id); ok || taken(v, u) {
+			return ErrUserExists
+		}
+		return nil
+	})
+}
+
+func (s *CachedStore) Update(u User) error {
+	return s.m.Put(u, func(v memstore.View[User]) error {
+		if _, ok := v.Get(u.Id); !ok {
+			return ErrNotFound
+		}
+		if taken(v, u) {
+			return ErrUserExists
+		}
+		return nil
+	})
+}
+
+// MemStore хранит пользователей только в памяти процесса: для тестов обработчиков
+type MemStore struct {
+	mu    sync.Mutex
+	users []User
+}
+
+// NewMemStore - хранилище с начальным набором пользователей
+func NewMemStore(users ...User) *MemStore {
+	return &MemStore{users: slices.Clone(users)}
+}
+
+func (s *MemStore) find(match func(User) bool) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if match(u) {
+			return u, nil
+		}
+	}
+	return User{}, ErrNotFound
+}
+
+func (s *MemStore) List() ([]User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.users), nil
+}
+
+func (s *MemStore) GetByID(id string) (User, error) {
+	return s.find(func(u User) bool { return u.Id == id })
+}
+
+func (s *MemStore) GetByUsername(username string) (User, error) {
+	return s.find(func(u User) bool { return u.Username == username })
+}
+
+func (s *MemStore) GetByEmail(email string) (User, error) {
+	return s.find(func(u User) bool { return u.Usermail == email })
+}
+
+func (s *MemStore) Create(u User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.users {
+		if existing.Username == u.Username || existing.Usermail == u.Usermail {
+			return ErrUserExists
+		}
+	}
+	s.users = append(s.users, u)
+	return nil
+}
+
+func (s *MemStore) Update(u User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.users {
+		if s.users[i].Id == u.Id {
+			s.users[i] = u
+			return nil
+		}
+	}
+	return ErrNotFound
+}
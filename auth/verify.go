@@ -0,0 +1,279 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"talant/mailer"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Назначение одноразового токена: токен одного вида нельзя предъявить вместо другого
+const (
+	purposeVerifyEmail   = "verify_email"
+	purposeResetPassword = "reset_password"
+	purposeCalendar      = "calendar"
+)
+
+var (
+	// mail по умолчанию печатает письма в stdout; main подставляет настроенный
+	mail mailer.Mailer = mailer.NewFile("", "talant@localhost")
+	// publicURL - адрес сайта для ссылок в письмах
+	publicURL = "http://localhost:8080"
+
+	verifyTTL = 48 * time.Hour
+	resetTTL  = time.Hour
+)
+
+var (
+	ErrInvalidActionToken = errors.New("invalid or expired token")
+	// ErrEmailNotVerified - письмо не отправлено: пользователь не подтвердил почту
+	ErrEmailNotVerified = errors.New("email is not verified")
+)
+
+// actionClaims - подписанный токен из письма. Fingerprint привязывает токен к
+// состоянию пользователя, которое он меняет (почта и флаг подтверждения, хэш
+// пароля): после использования состояние меняется, и токен перестает подходить.
+type actionClaims struct {
+	Purpose     string `json:"purpose"`
+	Fingerprint string `json:"fp"`
+	jwt.RegisteredClaims
+}
+
+func fingerprint(user User, purpose string) string {
+	var state string
+	switch purpose {
+	case purposeVerifyEmail:
+		state = fmt.Sprintf("%s|%s|%t", purpose, user.Usermail, user.EmailVerified)
+	case purposeResetPassword:
+		state = purpose + "|" + user.Password
+	case purposeCalendar:
+		state = purpose + "|" + user.CalendarKey
+	}
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateActionToken выпускает токен; ttl 0 - бессрочный (отзывается сменой состояния)
+func generateActionToken(user User, purpose string, ttl time.Duration) (string, error) {
+	if len(jwtSecretKey) == 0 {
+		return "", fmt.Errorf("jwt secret is not configured")
+	}
+	now := time.Now()
+	claims := &actionClaims{
+		Purpose:     purpose,
+		Fingerprint: fingerprint(user, purpose),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:  user.Id,
+			IssuedAt: jwt.NewNumericDate(now),
+		},
+	}
+	if ttl > 0 {
+		claims.ExpiresAt = jwt.NewNumericDate(now.Add(ttl))
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtSecretKey)
+}
+
+// parseActionToken проверяет подпись, срок и назначение токена и возвращает
+// пользователя, если токен еще не был использован
+func parseActionToken(tokenString, purpose string) (User, error) {
+	if tokenString == "" || len(jwtSecretKey) == 0 {
+		return User{}, ErrInvalidActionToken
+	}
+	claims := &actionClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return jwtSecretKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid || claims.Purpose != purpose {
+		return User{}, ErrInvalidActionToken
+	}
+	user, err := users.GetByID(claims.Subject)
+	if errors.Is(err, ErrNotFound) {
+		return User{}, ErrInvalidActionToken
+	}
+	if err != nil {
+		return User{}, err
+	}
+	if claims.Fingerprint != fingerprint(user, purpose) {
+		return User{}, ErrInvalidActionToken
+	}
+	return user, nil
+}
+
+func sendVerification(user User) error {
+	token, err := generateActionToken(user, purposeVerifyEmail, verifyTTL)
+	if err != nil {
+		return err
+	}
+	link := publicURL + "/verify?token=" + url.QueryEscape(token)
+	return mail.Send(mailer.Message{
+		To:      user.Usermail,
+		Subject: "Подтверждение почты",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\nЧтобы подтвердить почту, перейдите по ссылке:\n%s\n\n"+
+			"Ссылка действует %s. Если вы не регистрировались, просто проигнорируйте это письмо.\n",
+			user.Username, link, verifyTTL),
+	})
+}
+
+// MailUser отправляет пользователю письмо, только на подтвержденную почту.
+// path - страница сайта, ссылка на которую дописывается в конец письма.
+func MailUser(userID, subject, text, path string) error {
+	user, err := GetUser(userID)
+	if err != nil {
+		return err
+	}
+	if !user.EmailVerified {
+		return ErrEmailNotVerified
+	}
+	body := fmt.Sprintf("Здравствуйте, %s!\n\n%s\n", user.Username, text)
+	if path != "" {
+		body += "\n" + publicURL + path + "\n"
+	}
+	return mail.Send(mailer.Message{To: user.Usermail, Subject: subject, Body: body})
+}
+
+func sendPasswordReset(user User) error {
+	token, err := generateActionToken(user, purposeResetPassword, resetTTL)
+	if err != nil {
+		return err
+	}
+	// Страница сайта берет токен из адреса и отправляет новый пароль на /password/reset
+	link := publicURL + "/?reset_token=" + url.QueryEscape(token)
+	return mail.Send(mailer.Message{
+		To:      user.Usermail,
+		Subject: "Сброс пароля",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\nЧтобы задать новый пароль, перейдите по ссылке:\n%s\n\n"+
+			"Ссылка действует %s и сработает один раз. Если вы не запрашивали сброс, проигнорируйте это письмо.\n",
+			user.Username, link, resetTTL),
+	})
+}
+
+// VerifyEmailHandler подтверждает почту по ссылке из письма (GET /verify?token=...)
+func VerifyEmailHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := parseActionToken(r.URL.Query().Get("token"), purposeVerifyEmail)
+	if errors.Is(err, ErrInvalidActionToken) {
+		http.Error(w, "Invalid or expired token", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error loading users", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	user.EmailVerified = true
+	user.EmailVerifiedAt = &now
+	user.UpdatedAt = now
+	if err := users.Update(user); err != nil {
+		http.Error(w, "Error writing data file", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Email verified"))
+}
+
+// ResendVerificationHandler повторно отправляет письмо подтверждения текущему пользователю
+func ResendVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	current, ok := MustUser(w, r)
+	if !ok {
+		return
+	}
+	user, err := users.GetByID(current.ID)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if user.EmailVerified {
+		http.Error(w, "Email already verified", http.StatusConflict)
+		return
+	}
+	if err := sendVerification(user); err != nil {
+		fmt.Printf("Ошибка отправки письма подтверждения для %s: %v\n", user.Id, err)
+		http.Error(w, "Error sending email", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Verification email sent"))
+}
+
+// ForgotPasswordHandler отправляет ссылку для сброса пароля. Ответ одинаковый,
+// есть такой пользователь или нет, чтобы по нему нельзя было перебирать адреса.
+func ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	login := r.FormValue("usermail")
+	if login == "" {
+		login = r.FormValue("username")
+	}
+	if login == "" {
+		http.Error(w, "Missing fields", http.StatusBadRequest)
+		return
+	}
+
+	user, err := findByLogin(login)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		http.Error(w, "Error loading users", http.StatusInternalServerError)
+		return
+	}
+	if err == nil {
+		if err := sendPasswordReset(user); err != nil {
+			fmt.Printf("Ошибка отправки письма сброса пароля для %s: %v\n", user.Id, err)
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("If the account exists, a reset link has been sent"))
+}
+
+// ResetPasswordHandler задает новый пароль по токену из письма и завершает все
+// сессии пользователя: войти придется заново уже с новым паролем
+func ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	token := r.FormValue("token")
+	password := r.FormValue("password")
+	if token == "" || password == "" {
+		http.Error(w, "Missing fields", http.StatusBadRequest)
+		return
+	}
+
+	user, err := parseActionToken(token, purposeResetPassword)
+	if errors.Is(err, ErrInvalidActionToken) {
+		http.Error(w, "Invalid or expired token", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error loading users", http.StatusInternalServerError)
+		return
+	}
+
+	hashedPassword, err := HashPassword(password)
+	if err != nil {
+		http.Error(w, "Error hashing password", http.StatusInternalServerError)
+		return
+	}
+	now := time.Now()
+	user.Password = hashedPassword
+	user.UpdatedAt = now
+	// Ссылка пришла на почту - значит, адрес настоящий
+	if !user.EmailVerified {
+		user.EmailVerified = true
+		user.EmailVerifiedAt = &now
+	}
+	if err := users.Update(user); err != nil {
+		http.Error(w, "Error writing data file", http.StatusInternalServerError)
+		return
+	}
+	if _, err := revokeUserSessions(user.Id, nil); err != nil {
+		http.Error(w, "Error revoking sessions", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Password has been reset"))
+}
@@ -0,0 +1,464 @@
+// Package config собирает настройки сервера. Источники по возрастанию приоритета:
+// значения по умолчанию, YAML-файл (-config или TALANT_CONFIG), переменные
+// окружения TALANT_*, флаги командной строки.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultJWTSecret - ключ, который раньше был зашит в auth. С ним сервер не стартует.
+const DefaultJWTSecret = "YOUR_EXTREMELY_STRONG_SECRET_KEY"
+
+// Config - все настройки сервера
+type Config struct {
+	// Listen - адрес HTTP-сервера
+	Listen string `yaml:"listen"`
+	// Storage - хранилище данных: json, memory или sqlite
+	Storage string `yaml:"storage"`
+
+	// JWTSecret - ключ подписи токенов; задается только файлом или окружением
+	JWTSecret string `yaml:"jwt_secret"`
+	// TokenTTL - время жизни токена доступа
+	TokenTTL time.Duration `yaml:"token_ttl"`
+	// RefreshTTL - время жизни сессии (refresh-токена)
+	RefreshTTL time.Duration `yaml:"refresh_ttl"`
+	// CookieSecure выставляет Secure у cookie (нужно при работе по HTTPS)
+	CookieSecure bool `yaml:"cookie_secure"`
+	// CORSOrigins - разрешенные Origin; пустой список - только свой сайт,
+	// "*" - любой сайт, но без cookie
+	CORSOrigins []string `yaml:"cors_origins"`
+
+	// Admins - имена или почты пользователей, которым при старте выдается роль admin
+	Admins []string `yaml:"admins"`
+
+	// PublicURL - адрес сайта, на который ведут ссылки в письмах
+	PublicURL string `yaml:"public_url"`
+	Mail      Mail   `yaml:"mail"`
+
+	// Timezone - часовой пояс, в котором указываются дата и время мероприятий
+	Timezone string `yaml:"timezone"`
+	// EventReminder - за сколько до начала мероприятия напоминать записанным; 0 - не напоминать
+	EventReminder time.Duration `yaml:"event_reminder"`
+	// SavedSearchInterval - как часто проверять сохраненные поиски; 0 - не проверять
+	SavedSearchInterval time.Duration `yaml:"saved_search_interval"`
+
+	Data DataPaths `yaml:"data"`
+
+	// WebhooksAllowPrivate разрешает вебхуки на внутренние адреса (localhost, частные сети)
+	WebhooksAllowPrivate bool `yaml:"webhooks_allow_private"`
+
+	// Currency - курсы для сравнения зарплат в разных валютах
+	Currency Currency `yaml:"currency"`
+
+	// MaxUploadBytes - максимальный размер загружаемой фотографии
+	MaxUploadBytes int64 `yaml:"max_upload_bytes"`
+}
+
+// Mail - как отправлять письма. driver: file (в каталог dir, а с пустым dir -
+// в лог) или smtp.
+type Mail struct {
+	Driver   string `yaml:"driver"`
+	Dir      string `yaml:"dir"`
+	From     string `yaml:"from"`
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// Currency - таблица курсов: Rates - сколько единиц Base стоит единица валюты.
+// Зарплаты в валютах не из таблицы в фильтрах по зарплате не участвуют.
+type Currency struct {
+	Base  string             `yaml:"base"`
+	Rates map[string]float64 `yaml:"rates"`
+}
+
+// DataPaths - где лежат данные
+type DataPaths struct {
+	Users    string `yaml:"users"`
+	Jobs     string `yaml:"jobs"`
+	Ankety   string `yaml:"ankety"`
+	Sessions string `yaml:"sessions"`
+	Events   string `yaml:"events"`
+	// Registrations - записи на мероприятия
+	Registrations string `yaml:"registrations"`
+	// Applications - отклики на вакансии
+	Applications string `yaml:"applications"`
+	// Messages - каталог с JSON-файлами переписки (беседы, сообщения, блокировки)
+	Messages string `yaml:"messages"`
+	// Notifications - уведомления пользователей
+	Notifications string `yaml:"notifications"`
+	// Webhooks - вебхуки; журнал доставок лежит рядом, в <имя>_deliveries.json
+	Webhooks string `yaml:"webhooks"`
+	// Searches - сохраненные поиски
+	Searches string `yaml:"searches"`
+	SQLite   string `yaml:"sqlite"`
+	Uploads  string `yaml:"uploads"`
+}
+
+// Default возвращает настройки по умолчанию. Токен доступа живет недолго:
+// его продлевает refresh-токен сессии.
+func Default() Config {
+	return Config{
+		Listen:              ":8080",
+		Storage:             "json",
+		TokenTTL:            15 * time.Minute,
+		RefreshTTL:          30 * 24 * time.Hour,
+		PublicURL:           "http://localhost:8080",
+		Timezone:            "Europe/Moscow",
+		EventReminder:       24 * time.Hour,
+		SavedSearchInterval: 5 * time.Minute,
+		Mail: Mail{
+			Driver: "file",
+			Dir:    "mail",
+			From:   "talant@localhost",
+			Port:   587,
+		},
+		Data: DataPaths{
+			Users:         "data.json",
+			Jobs:          "job.json",
+			Ankety:        "ankety.json",
+			Sessions:      "sessions.json",
+			Events:        "events.json",
+			Registrations: "registrations.json",
+			Applications:  "applications.json",
+			Messages:      "chats",
+			Notifications: "notifications.json",
+			Webhooks:      "webhooks.json",
+			Searches:      "searches.json",
+			SQLite:        "talant.db",
+			Uploads:       "uploads",
+		},
+		Currency: Currency{
+			Base:  "RUB",
+			Rates: map[string]float64{"USD": 90, "EUR": 100},
+		},
+		MaxUploadBytes: 10 << 20,
+	}
+}
+
+// Load собирает настройки из всех источников и проверяет их
+func Load(args []string) (Config, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("talant", flag.ContinueOnError)
+	configPath := fs.String("config", os.Getenv("TALANT_CONFIG"), "YAML-файл с настройками")
+	listen := fs.String("listen", "", "адрес HTTP-сервера")
+	storage := fs.String("storage", "", "хранилище данных: json, memory или sqlite")
+	dbPath := fs.String("db", "", "файл базы SQLite")
+	usersFile := fs.String("users-file", "", "JSON-файл пользователей")
+	jobsFile := fs.String("jobs-file", "", "JSON-файл объявлений")
+	anketyFile := fs.String("ankety-file", "", "JSON-файл анкет")
+	sessionsFile := fs.String("sessions-file", "", "JSON-файл сессий")
+	eventsFile := fs.String("events-file", "", "JSON-файл мероприятий")
+	registrationsFile := fs.String("registrations-file", "", "JSON-файл записей на мероприятия")
+	applicationsFile := fs.String("applications-file", "", "JSON-файл откликов на вакансии")
+	messagesDir := fs.String("messages-dir", "", "каталог с файлами переписки")
+	notificationsFile := fs.String("notifications-file", "", "JSON-файл уведомлений")
+	webhooksFile := fs.String("webhooks-file", "", "JSON-файл вебхуков")
+	searchesFile := fs.String("searches-file", "", "JSON-файл сохраненных поисков")
+	savedSearchInterval := fs.Duration("saved-search-interval", 0, "как часто проверять сохраненные поиски (0 - не проверять)")
+	currencyBase := fs.String("currency-base", "", "базовая валюта для сравнения зарплат")
+	var currencyRates map[string]float64
+	fs.Func("currency-rates", "курсы валют к базовой: USD=90,EUR=100", func(v string) error {
+		rates, err := parseRates(v)
+		currencyRates = rates
+		return err
+	})
+	webhooksAllowPrivate := fs.Bool("webhooks-allow-private", false, "разрешить вебхуки на внутренние адреса")
+	timezone := fs.String("timezone", "", "часовой пояс мероприятий")
+	eventReminder := fs.Duration("event-reminder", 0, "за сколько до начала мероприятия напоминать (0 - не напоминать)")
+	uploads := fs.String("uploads", "", "каталог загруженных файлов")
+	tokenTTL := fs.Duration("token-ttl", 0, "время жизни токена доступа")
+	refreshTTL := fs.Duration("refresh-ttl", 0, "время жизни сессии (refresh-токена)")
+	cookieSecure := fs.Bool("cookie-secure", false, "выставлять Secure у cookie")
+	corsOrigins := fs.String("cors-origins", "", "разрешенные Origin через запятую")
+	admins := fs.String("admins", "", "администраторы (имена или почты) через запятую")
+	publicURL := fs.String("public-url", "", "адрес сайта для ссылок в письмах")
+	mailDriver := fs.String("mail-driver", "", "отправка писем: file или smtp")
+	mailDir := fs.String("mail-dir", "", "каталог писем для mail-driver=file")
+	maxUpload := fs.Int64("max-upload-bytes", 0, "максимальный размер загрузки")
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	if *configPath != "" {
+		if err := loadFile(*configPath, &cfg); err != nil {
+			return cfg, err
+		}
+	}
+	if err := loadEnv(&cfg); err != nil {
+		return cfg, err
+	}
+
+	// Флаги перекрывают все остальное, но только если их явно передали
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "listen":
+			cfg.Listen = *listen
+		case "storage":
+			cfg.Storage = *storage
+		case "db":
+			cfg.Data.SQLite = *dbPath
+		case "users-file":
+			cfg.Data.Users = *usersFile
+		case "jobs-file":
+			cfg.Data.Jobs = *jobsFile
+		case "ankety-file":
+			cfg.Data.Ankety = *anketyFile
+		case "sessions-file":
+			cfg.Data.Sessions = *sessionsFile
+		case "events-file":
+			cfg.Data.Events = *eventsFile
+		case "registrations-file":
+			cfg.Data.Registrations = *registrationsFile
+		case "applications-file":
+			cfg.Data.Applications = *applicationsFile
+		case "messages-dir":
+			cfg.Data.Messages = *messagesDir
+		case "notifications-file":
+			cfg.Data.Notifications = *notificationsFile
+		case "webhooks-file":
+			cfg.Data.Webhooks = *webhooksFile
+		case "searches-file":
+			cfg.Data.Searches = *searchesFile
+		case "saved-search-interval":
+			cfg.SavedSearchInterval = *savedSearchInterval
+		case "currency-base":
+			cfg.Currency.Base = *currencyBase
+		case "currency-rates":
+			cfg.Currency.Rates = currencyRates
+		case "webhooks-allow-private":
+			cfg.WebhooksAllowPrivate = *webhooksAllowPrivate
+		case "timezone":
+			cfg.Timezone = *timezone
+		case "event-reminder":
+			cfg.EventReminder = *eventReminder
+		case "uploads":
+			cfg.Data.Uploads = *uploads
+		case "token-ttl":
+			cfg.TokenTTL = *tokenTTL
+		case "refresh-ttl":
+			cfg.RefreshTTL = *refreshTTL
+		case "cookie-secure":
+			cfg.CookieSecure = *cookieSecure
+		case "cors-origins":
+			cfg.CORSOrigins = splitList(*corsOrigins)
+		case "admins":
+			cfg.Admins = splitList(*admins)
+		case "public-url":
+			cfg.PublicURL = *publicURL
+		case "mail-driver":
+			cfg.Mail.Driver = *mailDriver
+		case "mail-dir":
+			cfg.Mail.Dir = *mailDir
+		case "max-upload-bytes":
+			cfg.MaxUploadBytes = *maxUpload
+		}
+	})
+
+	return cfg, cfg.Validate()
+}
+
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения файла настроек %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("ошибка разбора файла настроек %s: %w", path, err)
+	}
+	return nil
+}
+
+func loadEnv(cfg *Config) error {
+	// PORT выставляют хостинги вроде Railway; TALANT_LISTEN важнее
+	if port := os.Getenv("PORT"); port != "" {
+		cfg.Listen = ":" + port
+	}
+	setString(&cfg.Listen, "TALANT_LISTEN")
+	setString(&cfg.Storage, "TALANT_STORAGE")
+	setString(&cfg.JWTSecret, "TALANT_JWT_SECRET")
+	setString(&cfg.Data.Users, "TALANT_USERS_FILE")
+	setString(&cfg.Data.Jobs, "TALANT_JOBS_FILE")
+	setString(&cfg.Data.Ankety, "TALANT_ANKETY_FILE")
+	setString(&cfg.Data.Sessions, "TALANT_SESSIONS_FILE")
+	setString(&cfg.Data.Events, "TALANT_EVENTS_FILE")
+	setString(&cfg.Data.Registrations, "TALANT_REGISTRATIONS_FILE")
+	setString(&cfg.Data.Applications, "TALANT_APPLICATIONS_FILE")
+	setString(&cfg.Data.Messages, "TALANT_MESSAGES_DIR")
+	setString(&cfg.Data.Notifications, "TALANT_NOTIFICATIONS_FILE")
+	setString(&cfg.Data.Webhooks, "TALANT_WEBHOOKS_FILE")
+	setString(&cfg.Data.Searches, "TALANT_SEARCHES_FILE")
+	setString(&cfg.Timezone, "TALANT_TIMEZONE")
+	setString(&cfg.Data.SQLite, "TALANT_DB")
+	setString(&cfg.Data.Uploads, "TALANT_UPLOADS")
+	if v := os.Getenv("TALANT_ADMINS"); v != "" {
+		cfg.Admins = splitList(v)
+	}
+	setString(&cfg.PublicURL, "TALANT_PUBLIC_URL")
+	setString(&cfg.Mail.Driver, "TALANT_MAIL_DRIVER")
+	setString(&cfg.Mail.Dir, "TALANT_MAIL_DIR")
+	setString(&cfg.Mail.From, "TALANT_MAIL_FROM")
+	setString(&cfg.Mail.Host, "TALANT_SMTP_HOST")
+	setString(&cfg.Mail.Username, "TALANT_SMTP_USERNAME")
+	setString(&cfg.Mail.Password, "TALANT_SMTP_PASSWORD")
+	if v := os.Getenv("TALANT_SMTP_PORT"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("TALANT_SMTP_PORT: %w", err)
+		}
+		cfg.Mail.Port = n
+	}
+	if v := os.Getenv("TALANT_CORS_ORIGINS"); v != "" {
+		cfg.CORSOrigins = splitList(v)
+	}
+	if v := os.Getenv("TALANT_TOKEN_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("TALANT_TOKEN_TTL: %w", err)
+		}
+		cfg.TokenTTL = d
+	}
+	if v := os.Getenv("TALANT_REFRESH_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("TALANT_REFRESH_TTL: %w", err)
+		}
+		cfg.RefreshTTL = d
+	}
+	if v := os.Getenv("TALANT_EVENT_REMINDER"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("TALANT_EVENT_REMINDER: %w", err)
+		}
+		cfg.EventReminder = d
+	}
+	if v := os.Getenv("TALANT_SAVED_SEARCH_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("TALANT_SAVED_SEARCH_INTERVAL: %w", err)
+		}
+		cfg.SavedSearchInterval = d
+	}
+	if v := os.Getenv("TALANT_COOKIE_SECURE"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("TALANT_COOKIE_SECURE: %w", err)
+		}
+		cfg.CookieSecure = b
+	}
+	if v := os.Getenv("TALANT_WEBHOOKS_ALLOW_PRIVATE"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("TALANT_WEBHOOKS_ALLOW_PRIVATE: %w", err)
+		}
+		cfg.WebhooksAllowPrivate = b
+	}
+	setString(&cfg.Currency.Base, "TALANT_CURRENCY_BASE")
+	if v := os.Getenv("TALANT_CURRENCY_RATES"); v != "" {
+		rates, err := parseRates(v)
+		if err != nil {
+			return fmt.Errorf("TALANT_CURRENCY_RATES: %w", err)
+		}
+		cfg.Currency.Rates = rates
+	}
+	if v := os.Getenv("TALANT_MAX_UPLOAD_BYTES"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("TALANT_MAX_UPLOAD_BYTES: %w", err)
+		}
+		cfg.MaxUploadBytes = n
+	}
+	return nil
+}
+
+func setString(dst *string, env string) {
+	if v := os.Getenv(env); v != "" {
+		*dst = v
+	}
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// parseRates читает курсы валют вида "USD=90,EUR=100"
+func parseRates(s string) (map[string]float64, error) {
+	rates := map[string]float64{}
+	for _, item := range splitList(s) {
+		code, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("ожидается КОД=курс: %q", item)
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("курс %s: %w", code, err)
+		}
+		rates[strings.ToUpper(strings.TrimSpace(code))] = rate
+	}
+	return rates, nil
+}
+
+// Validate проверяет настройки; сервер не стартует с ключом по умолчанию
+func (c Config) Validate() error {
+	if c.JWTSecret == "" || c.JWTSecret == DefaultJWTSecret {
+		return errors.New("не задан jwt_secret: укажите TALANT_JWT_SECRET или jwt_secret в файле настроек")
+	}
+	if c.TokenTTL <= 0 {
+		return errors.New("token_ttl должен быть положительным")
+	}
+	if c.RefreshTTL < c.TokenTTL {
+		return errors.New("refresh_ttl не может быть меньше token_ttl")
+	}
+	if c.EventReminder < 0 {
+		return errors.New("event_reminder не может быть отрицательным")
+	}
+	if c.SavedSearchInterval < 0 {
+		return errors.New("saved_search_interval не может быть отрицательным")
+	}
+	if len(c.Currency.Base) != 3 {
+		return fmt.Errorf("currency.base должен быть кодом валюты из трех букв: %q", c.Currency.Base)
+	}
+	for code, rate := range c.Currency.Rates {
+		if len(code) != 3 || rate <= 0 {
+			return fmt.Errorf("неверный курс валюты %s: %v", code, rate)
+		}
+	}
+	if c.MaxUploadBytes <= 0 {
+		return errors.New("max_upload_bytes должен быть положительным")
+	}
+	if _, err := time.LoadLocation(c.Timezone); err != nil {
+		return fmt.Errorf("неизвестный timezone %q: %w", c.Timezone, err)
+	}
+	switch c.Mail.Driver {
+	case "file":
+	case "smtp":
+		if c.Mail.Host == "" || c.Mail.From == "" {
+			return errors.New("для mail.driver=smtp нужны mail.host и mail.from")
+		}
+	default:
+		return fmt.Errorf("неизвестный mail.driver: %s", c.Mail.Driver)
+	}
+	switch c.Storage {
+	case "json", "memory", "sqlite":
+	default:
+		return fmt.Errorf("неизвестное хранилище: %s", c.Storage)
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"talant/auth"
+	"time"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// Билет - ID записи и подпись сервера над мероприятием и записью:
+// "<id записи>.<подпись>". Он короткий, чтобы QR-код читался с экрана
+// телефона. Отмененная запись удаляется, и ее билет перестает работать.
+
+const ticketPurpose = "ticket"
+
+var (
+	ErrInvalidTicket    = errors.New("invalid ticket")
+	ErrAlreadyCheckedIn = errors.New("already checked in")
+	ErrWaitlisted       = errors.New("registration is on the waitlist")
+)
+
+func ticketToken(reg Registration) string {
+	return reg.Id + "." + auth.Sign(ticketPurpose, reg.EventID+"|"+reg.Id)
+}
+
+// parseTicket проверяет подпись билета для мероприятия eventID и возвращает ID записи
+func parseTicket(eventID, token string) (string, error) {
+	id, signature, ok := strings.Cut(strings.TrimSpace(token), ".")
+	if !ok || !auth.VerifySignature(ticketPurpose, eventID+"|"+id, signature) {
+		return "", ErrInvalidTicket
+	}
+	return id, nil
+}
+
+// loadTicket находит запись текущего пользователя, по которой можно выдать билет
+func loadTicket(w http.ResponseWriter, r *http.Request) (Registration, bool) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return Registration{}, false
+	}
+	list, err := registrations.ListByEvent(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Error loading registrations", http.StatusInternalServerError)
+		return Registration{}, false
+	}
+	i := slices.IndexFunc(list, func(reg Registration) bool { return reg.UserID == user.ID })
+	if i < 0 {
+		http.Error(w, "Not registered", http.StatusNotFound)
+		return Registration{}, false
+	}
+	if list[i].Status != StatusRegistered {
+		http.Error(w, "Registration is on the waitlist", http.StatusConflict)
+		return Registration{}, false
+	}
+	return list[i], true
+}
+
+// TicketHandler - билет текущего пользователя (GET /api/events/{id}/ticket)
+func TicketHandler(w http.ResponseWriter, r *http.Request) {
+	reg, ok := loadTicket(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Registration
+		Ticket string `json:"ticket"`
+	}{reg, ticketToken(reg)})
+}
+
+// TicketQRHandler - билет QR-кодом в PNG (GET /api/events/{id}/ticket.png)
+func TicketQRHandler(w http.ResponseWriter, r *http.Request) {
+	reg, ok := loadTicket(w, r)
+	if !ok {
+		return
+	}
+	png, err := qrcode.Encode(ticketToken(reg), qrcode.Medium, 320)
+	if err != nil {
+		http.Error(w, "Error rendering ticket", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Write(png)
+}
+
+// CheckInHandler отмечает проход по билету (POST /api/events/{id}/checkin,
+// поле ticket). Доступно организатору; повторный проход по билету - 409.
+func CheckInHandler(w http.ResponseWriter, r *http.Request) {
+	e, ok := loadOwned(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseTicket(e.Id, r.FormValue("ticket"))
+	if err != nil {
+		http.Error(w, "Invalid ticket", http.StatusBadRequest)
+		return
+	}
+
+	var reg Registration
+	err = registrations.Change(e.Id, func(list []Registration) ([]Registration, error) {
+		i := slices.IndexFunc(list, func(reg Registration) bool { return reg.Id == id })
+		if i < 0 {
+			return nil, ErrNotRegistered
+		}
+		reg = list[i]
+		if reg.Status != StatusRegistered {
+			return nil, ErrWaitlisted
+		}
+		if reg.CheckedInAt != nil {
+			return nil, ErrAlreadyCheckedIn
+		}
+		now := time.Now()
+		list[i].CheckedInAt = &now
+		reg = list[i]
+		return list, nil
+	})
+	switch {
+	case errors.Is(err, ErrNotRegistered):
+		http.Error(w, "Registration not found", http.StatusNotFound)
+		return
+	case errors.Is(err, ErrWaitlisted):
+		http.Error(w, "Registration is on the waitlist", http.StatusConflict)
+		return
+	case errors.Is(err, ErrAlreadyCheckedIn):
+		http.Error(w, fmt.Sprintf("Already checked in at %s", reg.CheckedInAt.In(timezone).Format("15:04")),
+			http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, "Error saving registrations", http.StatusInternalServerError)
+		return
+	}
+	fmt.Printf("Отметка на мероприятии %s: %s\n", e.Id, reg.Username)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reg)
+}
+
+// StatsHandler - посещаемость мероприятия (GET /api/events/{id}/stats), для организатора
+func StatsHandler(w http.ResponseWriter, r *http.Request) {
+	e, ok := loadOwned(w, r)
+	if !ok {
+		return
+	}
+	list, err := registrations.ListByEvent(e.Id)
+	if err != nil {
+		http.Error(w, "Error loading registrations", http.StatusInternalServerError)
+		return
+	}
+
+	stats := struct {
+		EventID         string `json:"event_id"`
+		MaxParticipants int    `json:"max_participants"`
+		Registered      int    `json:"registered"`
+		Waitlisted      int    `json:"waitlisted"`
+		CheckedIn       int    `json:"checked_in"`
+		// AttendanceRate - доля пришедших среди записанных, от 0 до 1
+		AttendanceRate float64 `json:"attendance_rate"`
+	}{EventID: e.Id, MaxParticipants: e.MaxParticipants}
+	for _, reg := range list {
+		switch reg.Status {
+		case StatusRegistered:
+			stats.Registered++
+		case StatusWaitlisted:
+			stats.Waitlisted++
+		}
+		if reg.CheckedInAt != nil {
+			stats.CheckedIn++
+		}
+	}
+	if stats.Registered > 0 {
+		stats.AttendanceRate = float64(stats.CheckedIn) / float64(stats.Registered)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
@@ -0,0 +1,253 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Значения выпадающих списков формы frontend/events/create-event.html
+type (
+	Type      string // meetup, conference, ...
+	Format    string // online, offline, hybrid
+	Duration  string // часы 1-5 (5 - "5+ часов"), full-day, multi-day
+	PriceType string // free, paid, donation
+	Level     string // beginner ... all; пусто - любой уровень
+	Language  string // russian, english, mixed
+	Materials string // yes, no, online; пусто - не указано
+)
+
+var (
+	types      = []Type{"meetup", "conference", "webinar", "workshop", "hackathon", "networking", "training"}
+	formats    = []Format{"online", "offline", "hybrid"}
+	durations  = []Duration{"1", "2", "3", "4", "5", "full-day", "multi-day"}
+	priceTypes = []PriceType{"free", "paid", "donation"}
+	levels     = []Level{"", "beginner", "intermediate", "advanced", "expert", "all"}
+	languages  = []Language{"russian", "english", "mixed"}
+	materials  = []Materials{"", "yes", "no", "online"}
+	topics     = []string{"frontend", "backend", "mobile", "design", "data", "devops", "ai",
+		"blockchain", "cybersecurity", "cloud", "career", "startup"}
+)
+
+// Event - мероприятие (митап, конференция, ...). Поля повторяют форму создания.
+type Event struct {
+	Id string `json:"id"`
+	// UserID - ID пользователя, создавшего мероприятие
+	UserID string `json:"user_id"`
+
+	Title       string `json:"title"`
+	Type        Type   `json:"type"`
+	Format      Format `json:"format"`
+	Description string `json:"description"`
+
+	// Date и Time - как в форме (2006-01-02 и 15:04); StartsAt - они же в часовом поясе сервера
+	Date     string    `json:"date"`
+	Time     string    `json:"time"`
+	Duration Duration  `json:"duration"`
+	StartsAt time.Time `json:"starts_at"`
+
+	Location string `json:"location"`
+	City     string `json:"city,omitempty"`
+	MapLink  string `json:"map_link,omitempty"`
+
+	PriceType PriceType `json:"price_type"`
+	// Price - стоимость в рублях, только для price_type=paid
+	Price int `json:"price,omitempty"`
+	// MaxParticipants - 0 означает без ограничений
+	MaxParticipants  int    `json:"max_participants,omitempty"`
+	RegistrationLink string `json:"registration_link,omitempty"`
+
+	Topics     []string `json:"topics"`
+	CustomTags []string `json:"custom_tags"`
+
+	Organizer      string `json:"organizer"`
+	OrganizerEmail string `json:"organizer_email"`
+	ContactPhone   string `json:"contact_phone,omitempty"`
+	Website        string `json:"website,omitempty"`
+	Telegram       string `json:"telegram,omitempty"`
+	VK             string `json:"vk,omitempty"`
+	Instagram      string `json:"instagram,omitempty"`
+	Twitter        string `json:"twitter,omitempty"`
+
+	Level         Level     `json:"level,omitempty"`
+	Language      Language  `json:"language"`
+	Prerequisites string    `json:"prerequisites,omitempty"`
+	Materials     Materials `json:"materials,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+var (
+	// timezone - часовой пояс, в котором указаны дата и время мероприятий
+	timezone = time.Local
+	// publicURL - адрес сайта для ссылок на календари
+	publicURL = "http://localhost:8080"
+)
+
+// Configure задает часовой пояс мероприятий и адрес сайта
+func Configure(loc *time.Location, siteURL string) {
+	timezone = loc
+	publicURL = strings.TrimRight(siteURL, "/")
+}
+
+// EndsAt - окончание мероприятия по полю duration. Точного окончания форма не
+// спрашивает: "5" ("5+ часов") считается за 5 часов, full-day - за 8 часов,
+// multi-day - за двое суток.
+func (e Event) EndsAt() time.Time {
+	switch e.Duration {
+	case "full-day":
+		return e.StartsAt.Add(8 * time.Hour)
+	case "multi-day":
+		return e.StartsAt.AddDate(0, 0, 2)
+	}
+	hours, err := strconv.Atoi(string(e.Duration))
+	if err != nil {
+		hours = 1
+	}
+	return e.StartsAt.Add(time.Duration(hours) * time.Hour)
+}
+
+// errInvalid - ошибка заполнения формы, отдается клиенту как 400
+type errInvalid string
+
+func (e errInvalid) Error() string { return string(e) }
+
+func oneOf[T ~string](field string, value T, allowed []T) error {
+	if !slices.Contains(allowed, value) {
+		return errInvalid(fmt.Sprintf("Invalid %s: %q", field, value))
+	}
+	return nil
+}
+
+func checkURL(field, value string) error {
+	if value == "" {
+		return nil
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errInvalid(fmt.Sprintf("Invalid %s: must be an http(s) link", field))
+	}
+	return nil
+}
+
+// splitTags разбирает "JavaScript, React, ..." в список без пустых и повторов
+func splitTags(s string) []string {
+	tags := []string{}
+	for _, tag := range strings.Split(s, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// parseForm читает форму мероприятия (urlencoded или multipart, как шлет FormData)
+// и заполняет e. Ошибки заполнения - errInvalid.
+func parseForm(r *http.Request, e *Event) error {
+	if err := r.ParseMultipartForm(1 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return errInvalid("Error parsing form")
+	}
+	field := func(name string) string { return strings.TrimSpace(r.FormValue(name)) }
+
+	e.Title = field("title")
+	e.Type = Type(field("type"))
+	e.Format = Format(field("format"))
+	e.Description = field("description")
+	e.Date = field("date")
+	e.Time = field("time")
+	e.Duration = Duration(field("duration"))
+	e.Location = field("location")
+	e.City = field("city")
+	e.MapLink = field("map_link")
+	e.PriceType = PriceType(field("price_type"))
+	e.RegistrationLink = field("registration_link")
+	e.Topics = []string{}
+	for _, topic := range r.Form["topics"] {
+		if !slices.Contains(e.Topics, topic) {
+			e.Topics = append(e.Topics, topic)
+		}
+	}
+	e.CustomTags = splitTags(field("custom_tags"))
+	e.Organizer = field("organizer")
+	e.OrganizerEmail = field("organizer_email")
+	e.ContactPhone = field("contact_phone")
+	e.Website = field("website")
+	e.Telegram = field("telegram")
+	e.VK = field("vk")
+	e.Instagram = field("instagram")
+	e.Twitter = field("twitter")
+	e.Level = Level(field("level"))
+	e.Language = Language(field("language"))
+	if e.Language == "" {
+		e.Language = "russian" // первый вариант в форме
+	}
+	e.Prerequisites = field("prerequisites")
+	e.Materials = Materials(field("materials"))
+
+	if e.Title == "" || e.Description == "" || e.Date == "" || e.Time == "" || e.Location == "" ||
+		e.Organizer == "" || e.OrganizerEmail == "" {
+		return errInvalid("Missing required fields")
+	}
+	for _, err := range []error{
+		oneOf("type", e.Type, types),
+		oneOf("format", e.Format, formats),
+		oneOf("duration", e.Duration, durations),
+		oneOf("price_type", e.PriceType, priceTypes),
+		oneOf("level", e.Level, levels),
+		oneOf("language", e.Language, languages),
+		oneOf("materials", e.Materials, materials),
+		checkURL("map_link", e.MapLink),
+		checkURL("registration_link", e.RegistrationLink),
+		checkURL("website", e.Website),
+		checkURL("telegram", e.Telegram),
+		checkURL("vk", e.VK),
+		checkURL("instagram", e.Instagram),
+		checkURL("twitter", e.Twitter),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	for _, topic := range e.Topics {
+		if err := oneOf("topic", topic, topics); err != nil {
+			return err
+		}
+	}
+
+	startsAt, err := time.ParseInLocation("2006-01-02 15:04", e.Date+" "+e.Time, timezone)
+	if err != nil {
+		return errInvalid("Invalid date or time")
+	}
+	e.StartsAt = startsAt
+
+	if _, err := mail.ParseAddress(e.OrganizerEmail); err != nil {
+		return errInvalid("Invalid organizer_email")
+	}
+
+	e.Price = 0
+	if e.PriceType == "paid" {
+		price, err := strconv.Atoi(field("price"))
+		if err != nil || price <= 0 {
+			return errInvalid("Price is required for paid events")
+		}
+		e.Price = price
+	}
+
+	e.MaxParticipants = 0
+	if v := field("max_participants"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return errInvalid("Invalid max_participants")
+		}
+		e.MaxParticipants = n
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+// Package events - мероприятия (митапы, конференции, вебинары) для раздела frontend/events.
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"talant/auth"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// writeFormError отвечает 400 на ошибку заполнения формы и 500 на остальные
+func writeFormError(w http.ResponseWriter, err error) {
+	var invalid errInvalid
+	if errors.As(err, &invalid) {
+		http.Error(w, invalid.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Error(w, "Error parsing form", http.StatusInternalServerError)
+}
+
+// sortByStart - ближайшие мероприятия первыми
+func sortByStart(list []Event) {
+	slices.SortStableFunc(list, func(a, b Event) int {
+		return a.StartsAt.Compare(b.StartsAt)
+	})
+}
+
+// hasTag - тема или свой тег мероприятия, без учета регистра
+func (e Event) hasTag(tag string) bool {
+	for _, t := range slices.Concat(e.Topics, e.CustomTags) {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterEvents применяет фильтры из запроса: type, format, city, topic, tag
+// (тема или свой тег), upcoming=true (только еще не начавшиеся)
+func filterEvents(list []Event, query url.Values) []Event {
+	eventType := Type(query.Get("type"))
+	format := Format(query.Get("format"))
+	city := query.Get("city")
+	topic := query.Get("topic")
+	tag := query.Get("tag")
+	upcoming := query.Get("upcoming") == "true"
+	now := time.Now()
+
+	filtered := []Event{}
+	for _, e := range list {
+		if eventType != "" && e.Type != eventType {
+			continue
+		}
+		if format != "" && e.Format != format {
+			continue
+		}
+		if city != "" && !strings.EqualFold(e.City, city) {
+			continue
+		}
+		if topic != "" && !slices.Contains(e.Topics, topic) {
+			continue
+		}
+		if tag != "" && !e.hasTag(tag) {
+			continue
+		}
+		if upcoming && e.StartsAt.Before(now) {
+			continue
+		}
+		filtered = append(filtered, e)
+	}
+	sortByStart(filtered)
+	return filtered
+}
+
+// ListHandler - список мероприятий (GET /api/events), фильтры - см. filterEvents
+func ListHandler(w http.ResponseWriter, r *http.Request) {
+	list, err := store.List()
+	if err != nil {
+		http.Error(w, "Error loading events", http.StatusInternalServerError)
+		return
+	}
+	filtered := filterEvents(list, r.URL.Query())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(filtered)
+}
+
+// loadEvent загружает мероприятие из пути запроса
+func loadEvent(w http.ResponseWriter, r *http.Request) (Event, bool) {
+	e, err := store.Get(r.PathValue("id"))
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return Event{}, false
+	}
+	if err != nil {
+		http.Error(w, "Error loading events", http.StatusInternalServerError)
+		return Event{}, false
+	}
+	return e, true
+}
+
+// GetHandler - одно мероприятие (GET /api/events/{id})
+func GetHandler(w http.ResponseWriter, r *http.Request) {
+	e, ok := loadEvent(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(e)
+}
+
+// MyHandler - мероприятия текущего пользователя (GET /api/events/my)
+func MyHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	list, err := store.ListByUser(user.ID)
+	if err != nil {
+		http.Error(w, "Error loading events", http.StatusInternalServerError)
+		return
+	}
+	sortByStart(list)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// CreateHandler создает мероприятие из формы (POST /api/events)
+func CreateHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+
+	var e Event
+	if err := parseForm(r, &e); err != nil {
+		writeFormError(w, err)
+		return
+	}
+	now := time.Now()
+	e.Id = uuid.New().String()
+	e.UserID = user.ID
+	e.CreatedAt = now
+	e.UpdatedAt = now
+
+	if err := store.Create(e); err != nil {
+		http.Error(w, "Error saving events", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(e)
+}
+
+// loadOwned загружает мероприятие из пути и проверяет, что его может менять
+// текущий пользователь: организатор или модератор
+func loadOwned(w http.ResponseWriter, r *http.Request) (Event, bool) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return Event{}, false
+	}
+	e, ok := loadEvent(w, r)
+	if !ok {
+		return Event{}, false
+	}
+	if e.UserID != user.ID && !user.Can(auth.PermEventsModerate) {
+		http.Error(w, "Forbidden: cannot change other user's event", http.StatusForbidden)
+		return Event{}, false
+	}
+	return e, true
+}
+
+// UpdateHandler заменяет поля мероприятия данными формы (PUT /api/events/{id})
+func UpdateHandler(w http.ResponseWriter, r *http.Request) {
+	e, ok := loadOwned(w, r)
+	if !ok {
+		return
+	}
+	if err := parseForm(r, &e); err != nil {
+		writeFormError(w, err)
+		return
+	}
+	e.UpdatedAt = time.Now()
+
+	if err := store.Update(e); err != nil {
+		http.Error(w, "Error saving events", http.StatusInternalServerError)
+		return
+	}
+	// Если мест стало больше, их получает лист ожидания
+	if err := rebalance(e); err != nil {
+		fmt.Printf("Ошибка распределения мест на мероприятии %s: %v\n", e.Id, err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(e)
+}
+
+// DeleteHandler удаляет мероприятие (DELETE /api/events/{id})
+func DeleteHandler(w http.ResponseWriter, r *http.Request) {
+	e, ok := loadOwned(w, r)
+	if !ok {
+		return
+	}
+	if err := store.Delete(e.Id); err != nil {
+		http.Error(w, "Error saving events", http.StatusInternalServerError)
+		return
+	}
+	if err := registrations.DeleteByEvent(e.Id); err != nil {
+		fmt.Printf("Ошибка удаления записей на мероприятие %s: %v\n", e.Id, err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,213 @@
+package events
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"talant/auth"
+	"unicode/utf8"
+)
+
+// Календари в формате iCalendar (RFC 5545) для приложений календаря.
+// Время пишется в UTC, поэтому описание часового пояса (VTIMEZONE) не нужно.
+
+const icsTime = "20060102T150405Z"
+
+// icsEscape экранирует значение типа TEXT
+func icsEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", "").Replace(s)
+}
+
+// icsParam готовит значение параметра (CN=...): кавычки в нем запрещены
+func icsParam(s string) string {
+	return `"` + strings.NewReplacer(`"`, "", "\r", "", "\n", " ").Replace(s) + `"`
+}
+
+// icsWriter пишет строки календаря с CRLF, складывая их по 75 байт
+type icsWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (iw *icsWriter) line(name, value string) {
+	if iw.err != nil {
+		return
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range name + ":" + value {
+		// Переносим строку, не разрывая многобайтовые символы
+		size := utf8.RuneLen(r)
+		if n+size > 75 {
+			b.WriteString("\r\n ")
+			n = 1
+		}
+		b.WriteRune(r)
+		n += size
+	}
+	b.WriteString("\r\n")
+	_, iw.err = io.WriteString(iw.w, b.String())
+}
+
+func (iw *icsWriter) event(e Event, status string) {
+	iw.line("BEGIN", "VEVENT")
+	iw.line("UID", e.Id+"@talant")
+	iw.line("DTSTAMP", e.UpdatedAt.UTC().Format(icsTime))
+	iw.line("CREATED", e.CreatedAt.UTC().Format(icsTime))
+	iw.line("LAST-MODIFIED", e.UpdatedAt.UTC().Format(icsTime))
+	iw.line("DTSTART", e.StartsAt.UTC().Format(icsTime))
+	iw.line("DTEND", e.EndsAt().UTC().Format(icsTime))
+	iw.line("SUMMARY", icsEscape(e.Title))
+	iw.line("DESCRIPTION", icsEscape(e.Description))
+	location := e.Location
+	if e.City != "" && !strings.Contains(location, e.City) {
+		location = e.City + ", " + location
+	}
+	iw.line("LOCATION", icsEscape(location))
+	if tags := slices.Concat(e.Topics, e.CustomTags); len(tags) > 0 {
+		for i := range tags {
+			tags[i] = icsEscape(tags[i])
+		}
+		iw.line("CATEGORIES", strings.Join(tags, ","))
+	}
+	iw.line("ORGANIZER;CN="+icsParam(e.Organizer), "mailto:"+e.OrganizerEmail)
+	// Отдельной страницы мероприятия на сайте нет, поэтому ссылка - на сайт организатора
+	if link := cmp.Or(e.Website, e.RegistrationLink); link != "" {
+		iw.line("URL", link)
+	}
+	iw.line("STATUS", status)
+	iw.line("END", "VEVENT")
+}
+
+// writeCalendar отдает календарь; status задает STATUS каждого мероприятия
+func writeCalendar(w http.ResponseWriter, name string, list []Event, status func(Event) string) {
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	iw := &icsWriter{w: w}
+	iw.line("BEGIN", "VCALENDAR")
+	iw.line("VERSION", "2.0")
+	iw.line("PRODID", "-//Talant//Events//RU")
+	iw.line("CALSCALE", "GREGORIAN")
+	iw.line("METHOD", "PUBLISH")
+	iw.line("X-WR-CALNAME", icsEscape(name))
+	for _, e := range list {
+		iw.event(e, status(e))
+	}
+	iw.line("END", "VCALENDAR")
+	if iw.err != nil {
+		fmt.Printf("Ошибка отправки календаря: %v\n", iw.err)
+	}
+}
+
+func confirmed(Event) string { return "CONFIRMED" }
+
+// EventICSHandler - одно мероприятие файлом .ics (GET /api/events/{id}/event.ics)
+func EventICSHandler(w http.ResponseWriter, r *http.Request) {
+	e, ok := loadEvent(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=event_%s.ics", e.Id))
+	writeCalendar(w, e.Title, []Event{e}, confirmed)
+}
+
+// FeedHandler - лента предстоящих мероприятий (GET /api/events/feed.ics).
+// Фильтры те же, что у списка: city, type, format, topic, tag.
+func FeedHandler(w http.ResponseWriter, r *http.Request) {
+	list, err := store.List()
+	if err != nil {
+		http.Error(w, "Error loading events", http.StatusInternalServerError)
+		return
+	}
+	query := r.URL.Query()
+	query.Set("upcoming", "true")
+	writeCalendar(w, "Мероприятия Talant", filterEvents(list, query), confirmed)
+}
+
+// calendarURL - адрес личного календаря для подписки
+func calendarURL(token string) string {
+	return publicURL + "/api/events/calendar/" + url.PathEscape(token) + "/events.ics"
+}
+
+func writeCalendarURL(w http.ResponseWriter, token string) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"url": calendarURL(token)})
+}
+
+// CalendarLinkHandler - ссылка на личный календарь текущего пользователя
+// (GET /api/events/my/calendar)
+func CalendarLinkHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	token, err := auth.CalendarToken(user.ID)
+	if err != nil {
+		http.Error(w, "Error creating calendar link", http.StatusInternalServerError)
+		return
+	}
+	writeCalendarURL(w, token)
+}
+
+// ResetCalendarLinkHandler отзывает старую ссылку на личный календарь и
+// выдает новую (POST /api/events/my/calendar/reset)
+func ResetCalendarLinkHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	token, err := auth.ResetCalendarToken(user.ID)
+	if err != nil {
+		http.Error(w, "Error creating calendar link", http.StatusInternalServerError)
+		return
+	}
+	writeCalendarURL(w, token)
+}
+
+// UserFeedHandler - личный календарь: мероприятия, на которые записан
+// пользователь (GET /api/events/calendar/{token}/events.ics). Запись из листа
+// ожидания отмечается как предварительная (TENTATIVE).
+func UserFeedHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CalendarUser(r.PathValue("token"))
+	if errors.Is(err, auth.ErrInvalidActionToken) {
+		http.Error(w, "Invalid calendar link", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error loading calendar", http.StatusInternalServerError)
+		return
+	}
+	regs, err := registrations.ListByUser(userID)
+	if err != nil {
+		http.Error(w, "Error loading registrations", http.StatusInternalServerError)
+		return
+	}
+
+	statuses := make(map[string]Status, len(regs))
+	list := []Event{}
+	for _, reg := range regs {
+		e, err := store.Get(reg.EventID)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			http.Error(w, "Error loading events", http.StatusInternalServerError)
+			return
+		}
+		statuses[e.Id] = reg.Status
+		list = append(list, e)
+	}
+	sortByStart(list)
+
+	writeCalendar(w, "Мои мероприятия", list, func(e Event) string {
+		if statuses[e.Id] == StatusWaitlisted {
+			return "TENTATIVE"
+		}
+		return "CONFIRMED"
+	})
+}
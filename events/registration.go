@@ -0,0 +1,352 @@
+package events
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"talant/auth"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status - участник или в листе ожидания
+type Status string
+
+const (
+	StatusRegistered Status = "registered"
+	StatusWaitlisted Status = "waitlisted"
+)
+
+var (
+	ErrAlreadyRegistered = errors.New("already registered")
+	ErrNotRegistered     = errors.New("not registered")
+)
+
+// Registration - запись пользователя на мероприятие
+type Registration struct {
+	Id       string `json:"id"`
+	EventID  string `json:"event_id"`
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	Status   Status `json:"status"`
+	// CreatedAt задает очередь: кто раньше записался, тот раньше получит место
+	CreatedAt  time.Time  `json:"created_at"`
+	PromotedAt *time.Time `json:"promoted_at,omitempty"`
+	// CheckedInAt - когда участник прошел на мероприятие по билету
+	CheckedInAt *time.Time `json:"checked_in_at,omitempty"`
+}
+
+func (r Registration) equal(o Registration) bool {
+	return r.Status == o.Status && ptrTime(r.PromotedAt).Equal(ptrTime(o.PromotedAt)) &&
+		ptrTime(r.CheckedInAt).Equal(ptrTime(o.CheckedInAt))
+}
+
+func ptrTime(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+// formatTimePtr - время для CSV; пустая строка, если его нет
+func formatTimePtr(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// sortQueue упорядочивает записи по времени записи
+func sortQueue(list []Registration) {
+	slices.SortStableFunc(list, func(a, b Registration) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		if a.Id < b.Id {
+			return -1
+		}
+		if a.Id > b.Id {
+			return 1
+		}
+		return 0
+	})
+}
+
+func countRegistered(list []Registration) int {
+	n := 0
+	for _, reg := range list {
+		if reg.Status == StatusRegistered {
+			n++
+		}
+	}
+	return n
+}
+
+// promote переводит первых из листа ожидания в участники, пока есть места
+// (capacity 0 - без ограничений). Уже записанных участников не вытесняет,
+// даже если мест стало меньше. Возвращает продвинутые записи.
+func promote(list []Registration, capacity int, now time.Time) []Registration {
+	sortQueue(list)
+	free := capacity - countRegistered(list)
+	if capacity == 0 {
+		free = len(list)
+	}
+	var promoted []Registration
+	for i := range list {
+		if free <= 0 {
+			break
+		}
+		if list[i].Status == StatusWaitlisted {
+			list[i].Status = StatusRegistered
+			list[i].PromotedAt = &now
+			promoted = append(promoted, list[i])
+			free--
+		}
+	}
+	return promoted
+}
+
+// waitlistPosition - место в листе ожидания, начиная с 1; 0 - не в листе
+func waitlistPosition(list []Registration, userID string) int {
+	sortQueue(list)
+	pos := 0
+	for _, reg := range list {
+		if reg.Status != StatusWaitlisted {
+			continue
+		}
+		pos++
+		if reg.UserID == userID {
+			return pos
+		}
+	}
+	return 0
+}
+
+// registrationView - запись с местом в очереди для ответа пользователю
+type registrationView struct {
+	Registration
+	WaitlistPosition int `json:"waitlist_position,omitempty"`
+}
+
+// logPromoted пишет в лог, кто получил место из листа ожидания
+func logPromoted(e Event, promoted []Registration) {
+	for _, reg := range promoted {
+		fmt.Printf("Место на мероприятии %s получил %s (из листа ожидания)\n", e.Id, reg.Username)
+	}
+}
+
+// rebalance раздает освободившиеся места, например после увеличения max_participants
+func rebalance(e Event) error {
+	var promoted []Registration
+	err := registrations.Change(e.Id, func(list []Registration) ([]Registration, error) {
+		promoted = promote(list, e.MaxParticipants, time.Now())
+		return list, nil
+	})
+	if err == nil {
+		logPromoted(e, promoted)
+	}
+	return err
+}
+
+// RegisterHandler записывает текущего пользователя на мероприятие
+// (POST /api/events/{id}/register). Если мест нет - в лист ожидания.
+func RegisterHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	e, ok := loadEvent(w, r)
+	if !ok {
+		return
+	}
+	if !e.StartsAt.After(time.Now()) {
+		http.Error(w, "Event has already started", http.StatusConflict)
+		return
+	}
+
+	var view registrationView
+	err := registrations.Change(e.Id, func(list []Registration) ([]Registration, error) {
+		for _, reg := range list {
+			if reg.UserID == user.ID {
+				return nil, ErrAlreadyRegistered
+			}
+		}
+		reg := Registration{
+			Id:        uuid.New().String(),
+			EventID:   e.Id,
+			UserID:    user.ID,
+			Username:  user.Username,
+			Status:    StatusWaitlisted,
+			CreatedAt: time.Now(),
+		}
+		if e.MaxParticipants == 0 || countRegistered(list) < e.MaxParticipants {
+			reg.Status = StatusRegistered
+		}
+		list = append(list, reg)
+		view = registrationView{Registration: reg, WaitlistPosition: waitlistPosition(list, user.ID)}
+		return list, nil
+	})
+	if errors.Is(err, ErrAlreadyRegistered) {
+		http.Error(w, "Already registered", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error saving registrations", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(view)
+}
+
+// CancelRegistrationHandler отменяет запись (DELETE /api/events/{id}/register).
+// Освободившееся место получает первый из листа ожидания.
+func CancelRegistrationHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	e, ok := loadEvent(w, r)
+	if !ok {
+		return
+	}
+
+	var promoted []Registration
+	err := registrations.Change(e.Id, func(list []Registration) ([]Registration, error) {
+		i := slices.IndexFunc(list, func(reg Registration) bool { return reg.UserID == user.ID })
+		if i < 0 {
+			return nil, ErrNotRegistered
+		}
+		list = slices.Delete(list, i, i+1)
+		promoted = promote(list, e.MaxParticipants, time.Now())
+		return list, nil
+	})
+	if errors.Is(err, ErrNotRegistered) {
+		http.Error(w, "Not registered", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error saving registrations", http.StatusInternalServerError)
+		return
+	}
+	logPromoted(e, promoted)
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Registration cancelled"))
+}
+
+// MyRegistrationHandler - запись текущего пользователя на мероприятие
+// (GET /api/events/{id}/registration)
+func MyRegistrationHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	list, err := registrations.ListByEvent(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Error loading registrations", http.StatusInternalServerError)
+		return
+	}
+	i := slices.IndexFunc(list, func(reg Registration) bool { return reg.UserID == user.ID })
+	if i < 0 {
+		http.Error(w, "Not registered", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(registrationView{
+		Registration:     list[i],
+		WaitlistPosition: waitlistPosition(list, user.ID),
+	})
+}
+
+// MyRegistrationsHandler - все записи текущего пользователя (GET /api/events/my/registrations)
+func MyRegistrationsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	list, err := registrations.ListByUser(user.ID)
+	if err != nil {
+		http.Error(w, "Error loading registrations", http.StatusInternalServerError)
+		return
+	}
+	sortQueue(list)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// attendee - участник в списке для организатора
+type attendee struct {
+	Registration
+	Usermail string `json:"usermail,omitempty"`
+}
+
+// loadAttendees проверяет, что список смотрит организатор, и загружает записи
+// в порядке очереди вместе с почтой участников
+func loadAttendees(w http.ResponseWriter, r *http.Request) (Event, []attendee, bool) {
+	e, ok := loadOwned(w, r)
+	if !ok {
+		return Event{}, nil, false
+	}
+	list, err := registrations.ListByEvent(e.Id)
+	if err != nil {
+		http.Error(w, "Error loading registrations", http.StatusInternalServerError)
+		return Event{}, nil, false
+	}
+	sortQueue(list)
+	result := make([]attendee, 0, len(list))
+	for _, reg := range list {
+		a := attendee{Registration: reg}
+		if u, err := auth.GetUser(reg.UserID); err == nil {
+			a.Usermail = u.Usermail
+		}
+		result = append(result, a)
+	}
+	return e, result, true
+}
+
+// AttendeesHandler - участники и лист ожидания (GET /api/events/{id}/attendees),
+// только для организатора
+func AttendeesHandler(w http.ResponseWriter, r *http.Request) {
+	e, list, ok := loadAttendees(w, r)
+	if !ok {
+		return
+	}
+	response := struct {
+		EventID         string     `json:"event_id"`
+		MaxParticipants int        `json:"max_participants"`
+		Registered      []attendee `json:"registered"`
+		Waitlist        []attendee `json:"waitlist"`
+	}{EventID: e.Id, MaxParticipants: e.MaxParticipants, Registered: []attendee{}, Waitlist: []attendee{}}
+	for _, a := range list {
+		if a.Status == StatusRegistered {
+			response.Registered = append(response.Registered, a)
+		} else {
+			response.Waitlist = append(response.Waitlist, a)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// ExportAttendeesHandler выгружает участников в CSV (GET /api/events/{id}/attendees.csv)
+func ExportAttendeesHandler(w http.ResponseWriter, r *http.Request) {
+	e, list, ok := loadAttendees(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=attendees_%s.csv", e.Id))
+	out := csv.NewWriter(w)
+	out.Write([]string{"Username", "Email", "Status", "RegisteredAt", "PromotedAt", "CheckedInAt"})
+	for _, a := range list {
+		out.Write([]string{a.Username, a.Usermail, string(a.Status), a.CreatedAt.Format(time.RFC3339),
+			formatTimePtr(a.PromotedAt), formatTimePtr(a.CheckedInAt)})
+	}
+	out.Flush()
+}
@@ -0,0 +1,156 @@
+package events
+
+import (
+	"sync"
+	"talant/jsonfile"
+	"talant/memstore"
+)
+
+// RegistrationStore - хранилище записей на мероприятия. Места распределяются
+// в Change: все записи мероприятия меняются атомарно, поэтому два человека не
+// займут одно последнее место.
+type RegistrationStore interface {
+	ListByEvent(eventID string) ([]Registration, error)
+	ListByUser(userID string) ([]Registration, error)
+	// Change передает fn текущие записи мероприятия и сохраняет то, что она вернет
+	Change(eventID string, fn func([]Registration) ([]Registration, error)) error
+	DeleteByEvent(eventID string) error
+}
+
+var registrations RegistrationStore = NewJSONRegistrationStore("registrations.json")
+
+// SetRegistrationStore подменяет хранилище записей
+func SetRegistrationStore(s RegistrationStore) {
+	registrations = s
+}
+
+// JSONRegistrationStore хранит все записи одним массивом в JSON-файле
+type JSONRegistrationStore struct {
+	file *jsonfile.File[Registration]
+}
+
+func NewJSONRegistrationStore(path string) *JSONRegistrationStore {
+	return &JSONRegistrationStore{file: jsonfile.New[Registration](path)}
+}
+
+func (s *JSONRegistrationStore) filter(match func(Registration) bool) ([]Registration, error) {
+	list, err := s.file.Load()
+	if err != nil {
+		return nil, err
+	}
+	found := []Registration{}
+	for _, reg := range list {
+		if match(reg) {
+			found = append(found, reg)
+		}
+	}
+	return found, nil
+}
+
+func (s *JSONRegistrationStore) ListByEvent(eventID string) ([]Registration, error) {
+	return s.filter(func(reg Registration) bool { return reg.EventID == eventID })
+}
+
+func (s *JSONRegistrationStore) ListByUser(userID string) ([]Registration, error) {
+	return s.filter(func(reg Registration) bool { return reg.UserID == userID })
+}
+
+func (s *JSONRegistrationStore) Change(eventID string, fn func([]Registration) ([]Registration, error)) error {
+	return s.file.Update(func(list []Registration) ([]Registration, error) {
+		var own, rest []Registration
+		for _, reg := range list {
+			if reg.EventID == eventID {
+				own = append(own, reg)
+			} else {
+				rest = append(rest, reg)
+			}
+		}
+		own, err := fn(own)
+		if err != nil {
+			return nil, err
+		}
+		return append(rest, own...), nil
+	})
+}
+
+func (s *JSONRegistrationStore) DeleteByEvent(eventID string) error {
+	return s.Change(eventID, func([]Registration) ([]Registration, error) {
+		return nil, nil
+	})
+}
+
+// CachedRegistrationStore держит записи в памяти с индексами по мероприятию и пользователю
+type CachedRegistrationStore struct {
+	m *memstore.Store[Registration]
+	// mu делает Change атомарным: memstore защищает только отдельные записи
+	mu sync.Mutex
+}
+
+// NewCachedRegistrationStore загружает записи из path (и журнала path + ".journal")
+func NewCachedRegistrationStore(path string) (*CachedRegistrationStore, error) {
+	m, err := memstore.Open(memstore.Options[Registration]{
+		Snapshot: path,
+		ID:       func(reg Registration) string { return reg.Id },
+		Indexes: map[string]func(Registration) string{
+			"event": func(reg Registration) string { return reg.EventID },
+			"user":  func(reg Registration) string { return reg.UserID },
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &CachedRegistrationStore{m: m}, nil
+}
+
+func (s *CachedRegistrationStore) Close() error {
+	return s.m.Close()
+}
+
+func (s *CachedRegistrationStore) ListByEvent(eventID string) ([]Registration, error) {
+	return s.m.Find("event", eventID), nil
+}
+
+func (s *CachedRegistrationStore) ListByUser(userID string) ([]Registration, error) {
+	return s.m.Find("user", userID), nil
+}
+
+func (s *CachedRegistrationStore) Change(eventID string, fn func([]Registration) ([]Registration, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before := s.m.Find("event", eventID)
+	after, err := fn(append([]Registration(nil), before...))
+	if err != nil {
+		return err
+	}
+
+	// Пишем только то, что изменилось
+	kept := make(map[string]bool, len(after))
+	old := make(map[string]Registration, len(before))
+	for _, reg := range before {
+		old[reg.Id] = reg
+	}
+	for _, reg := range after {
+		kept[reg.Id] = true
+		if prev, ok := old[reg.Id]; ok && prev.equal(reg) {
+			continue
+		}
+		if err := s.m.Put(reg, nil); err != nil {
+			return err
+		}
+	}
+	for _, reg := range before {
+		if !kept[reg.Id] {
+			if _, err := s.m.Delete(reg.Id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *CachedRegistrationStore) DeleteByEvent(eventID string) error {
+	return s.Change(eventID, func([]Registration) ([]Registration, error) {
+		return nil, nil
+	})
+}
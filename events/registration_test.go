@@ -0,0 +1,128 @@
+package events
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"talant/auth"
+	"talant/auth/authtest"
+	"testing"
+	"time"
+)
+
+// useEvent подменяет хранилища мероприятий и записей на время теста и
+// создает мероприятие на maxParticipants мест
+func useEvent(t *testing.T, maxParticipants int) Event {
+	t.Helper()
+	prevStore, prevRegistrations := store, registrations
+	dir := t.TempDir()
+	SetStore(NewJSONStore(filepath.Join(dir, "events.json")))
+	SetRegistrationStore(NewJSONRegistrationStore(filepath.Join(dir, "registrations.json")))
+	t.Cleanup(func() { store, registrations = prevStore, prevRegistrations })
+
+	e := Event{Id: "event-1", UserID: authtest.Owner.ID, Title: "Митап",
+		StartsAt: time.Now().Add(24 * time.Hour), MaxParticipants: maxParticipants}
+	if err := store.Create(e); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func participant(i int) auth.CurrentUser {
+	return auth.CurrentUser{ID: fmt.Sprintf("user-%d", i), Username: fmt.Sprintf("user%d", i)}
+}
+
+// serveEvent вызывает обработчик мероприятия e от имени user
+func serveEvent(h http.HandlerFunc, method string, e Event, user auth.CurrentUser) *httptest.ResponseRecorder {
+	r := authtest.Request(method, "/api/events/"+e.Id+"/register", nil, &user)
+	r.SetPathValue("id", e.Id)
+	w := httptest.NewRecorder()
+	h(w, r)
+	return w
+}
+
+// statuses - статусы записей мероприятия по пользователям
+func statuses(t *testing.T, e Event) map[string]Status {
+	t.Helper()
+	list, err := registrations.ListByEvent(e.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := map[string]Status{}
+	for _, reg := range list {
+		result[reg.UserID] = reg.Status
+	}
+	return result
+}
+
+func TestCancelPromotesFirstFromWaitlist(t *testing.T) {
+	e := useEvent(t, 2)
+	for i := 1; i <= 4; i++ {
+		if w := serveEvent(RegisterHandler, http.MethodPost, e, participant(i)); w.Code != http.StatusCreated {
+			t.Fatalf("register user %d: %d %s", i, w.Code, w.Body)
+		}
+	}
+	want := map[string]Status{"user-1": StatusRegistered, "user-2": StatusRegistered,
+		"user-3": StatusWaitlisted, "user-4": StatusWaitlisted}
+	if got := statuses(t, e); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("before cancel: %v, want %v", got, want)
+	}
+
+	if w := serveEvent(CancelRegistrationHandler, http.MethodDelete, e, participant(1)); w.Code != http.StatusOK {
+		t.Fatalf("cancel: %d %s", w.Code, w.Body)
+	}
+	// Место получает первый в очереди, второй остается ждать
+	want = map[string]Status{"user-2": StatusRegistered, "user-3": StatusRegistered, "user-4": StatusWaitlisted}
+	if got := statuses(t, e); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("after cancel: %v, want %v", got, want)
+	}
+	w := serveEvent(MyRegistrationHandler, http.MethodGet, e, participant(4))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"waitlist_position":1`) {
+		t.Errorf("user-4 registration: %d %s, want waitlist position 1", w.Code, w.Body)
+	}
+
+	// Отмена из листа ожидания никого не продвигает
+	if w := serveEvent(CancelRegistrationHandler, http.MethodDelete, e, participant(4)); w.Code != http.StatusOK {
+		t.Fatalf("cancel waitlisted: %d %s", w.Code, w.Body)
+	}
+	if w := serveEvent(CancelRegistrationHandler, http.MethodDelete, e, participant(4)); w.Code != http.StatusNotFound {
+		t.Errorf("cancel twice: %d, want 404", w.Code)
+	}
+}
+
+func TestConcurrentRegistrationsKeepCapacity(t *testing.T) {
+	const capacity, users = 5, 40
+	e := useEvent(t, capacity)
+
+	var wg sync.WaitGroup
+	codes := make([]int, users)
+	for i := range users {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes[i] = serveEvent(RegisterHandler, http.MethodPost, e, participant(i)).Code
+		}()
+	}
+	wg.Wait()
+
+	for i, code := range codes {
+		if code != http.StatusCreated {
+			t.Errorf("register user %d: %d, want 201", i, code)
+		}
+	}
+	registered, waitlisted := 0, 0
+	for _, status := range statuses(t, e) {
+		switch status {
+		case StatusRegistered:
+			registered++
+		case StatusWaitlisted:
+			waitlisted++
+		}
+	}
+	if registered != capacity || waitlisted != users-capacity {
+		t.Errorf("registered %d, waitlisted %d; want %d and %d", registered, waitlisted, capacity, users-capacity)
+	}
+}
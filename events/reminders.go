@@ -0,0 +1,86 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// reminderInterval - как часто проверяются ближайшие мероприятия
+const reminderInterval = time.Minute
+
+// reminderHooks получают участника (не из листа ожидания), которому пора напомнить
+// о мероприятии. events не знает про уведомления, они подключаются через OnReminder.
+var reminderHooks []func(e Event, reg Registration) error
+
+// OnReminder подключает обработчик напоминания
+func OnReminder(hook func(e Event, reg Registration) error) {
+	reminderHooks = append(reminderHooks, hook)
+}
+
+// ReminderKey - ключ напоминания: при переносе мероприятия напоминание приходит снова
+func ReminderKey(e Event, reg Registration) string {
+	return fmt.Sprintf("%s@%d", reg.Id, e.StartsAt.Unix())
+}
+
+// RunReminders раз в минуту ищет мероприятия, которые начнутся в ближайшие ahead,
+// и вызывает хуки для их участников. Возвращается, когда ctx отменен.
+func RunReminders(ctx context.Context, ahead time.Duration) {
+	// Кому уже напомнили - чтобы не дергать хуки каждую минуту. После перезапуска
+	// набор пуст, и повторы отсекают сами хуки (по ReminderKey).
+	reminded := map[string]bool{}
+	ticker := time.NewTicker(reminderInterval)
+	defer ticker.Stop()
+	for {
+		var err error
+		if reminded, err = remind(ahead, reminded, time.Now()); err != nil {
+			fmt.Printf("Ошибка напоминаний о мероприятиях: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// remind напоминает о мероприятиях, начинающихся в (now, now+ahead], и возвращает
+// ключи напомненных: прошедшие мероприятия из набора выпадают
+func remind(ahead time.Duration, reminded map[string]bool, now time.Time) (map[string]bool, error) {
+	list, err := store.List()
+	if err != nil {
+		return reminded, err
+	}
+	next := map[string]bool{}
+	for _, e := range list {
+		if !e.StartsAt.After(now) || e.StartsAt.After(now.Add(ahead)) {
+			continue
+		}
+		regs, err := registrations.ListByEvent(e.Id)
+		if err != nil {
+			return reminded, err
+		}
+		for _, reg := range regs {
+			if reg.Status != StatusRegistered {
+				continue
+			}
+			key := ReminderKey(e, reg)
+			if reminded[key] {
+				next[key] = true
+				continue
+			}
+			ok := true
+			for _, hook := range reminderHooks {
+				if err := hook(e, reg); err != nil {
+					fmt.Printf("Ошибка напоминания о мероприятии %s для %s: %v\n", e.Id, reg.Username, err)
+					ok = false
+				}
+			}
+			// Не получилось - попробуем на следующей проверке
+			if ok {
+				next[key] = true
+			}
+		}
+	}
+	return next, nil
+}
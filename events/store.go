@@ -0,0 +1,161 @@
+package events
+
+import (
+	"errors"
+	"talant/jsonfile"
+	"talant/memstore"
+)
+
+// ErrNotFound возвращается хранилищем, если мероприятие не найдено
+var ErrNotFound = errors.New("event not found")
+
+// Store - хранилище мероприятий
+type Store interface {
+	List() ([]Event, error)
+	Get(id string) (Event, error)
+	ListByUser(userID string) ([]Event, error)
+	Create(e Event) error
+	Update(e Event) error
+	Delete(id string) error
+}
+
+var store Store = NewJSONStore("events.json")
+
+// SetStore подменяет хранилище, с которым работают обработчики
+func SetStore(s Store) {
+	store = s
+}
+
+// JSONStore хранит все мероприятия одним массивом в JSON-файле
+type JSONStore struct {
+	file *jsonfile.File[Event]
+}
+
+func NewJSONStore(path string) *JSONStore {
+	return &JSONStore{file: jsonfile.New[Event](path)}
+}
+
+func (s *JSONStore) List() ([]Event, error) {
+	return s.file.Load()
+}
+
+func (s *JSONStore) Get(id string) (Event, error) {
+	list, err := s.file.Load()
+	if err != nil {
+		return Event{}, err
+	}
+	for _, e := range list {
+		if e.Id == id {
+			return e, nil
+		}
+	}
+	return Event{}, ErrNotFound
+}
+
+func (s *JSONStore) ListByUser(userID string) ([]Event, error) {
+	list, err := s.file.Load()
+	if err != nil {
+		return nil, err
+	}
+	userEvents := []Event{}
+	for _, e := range list {
+		if e.UserID == userID {
+			userEvents = append(userEvents, e)
+		}
+	}
+	return userEvents, nil
+}
+
+func (s *JSONStore) Create(e Event) error {
+	return s.file.Update(func(list []Event) ([]Event, error) {
+		return append(list, e), nil
+	})
+}
+
+func (s *JSONStore) Update(e Event) error {
+	return s.file.Update(func(list []Event) ([]Event, error) {
+		for i := range list {
+			if list[i].Id == e.Id {
+				list[i] = e
+				return list, nil
+			}
+		}
+		return nil, ErrNotFound
+	})
+}
+
+func (s *JSONStore) Delete(id string) error {
+	return s.file.Update(func(list []Event) ([]Event, error) {
+		for i := range list {
+			if list[i].Id == id {
+				return append(list[:i], list[i+1:]...), nil
+			}
+		}
+		return nil, ErrNotFound
+	})
+}
+
+// CachedStore держит все мероприятия в памяти с индексом по организатору.
+// Изменения пишутся в журнал и периодически сворачиваются в JSON-файл.
+type CachedStore struct {
+	m *memstore.Store[Event]
+}
+
+// NewCachedStore загружает мероприятия из path (и журнала path + ".journal")
+func NewCachedStore(path string) (*CachedStore, error) {
+	m, err := memstore.Open(memstore.Options[Event]{
+		Snapshot: path,
+		ID:       func(e Event) string { return e.Id },
+		Indexes: map[string]func(Event) string{
+			"user": func(e Event) string { return e.UserID },
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &CachedStore{m: m}, nil
+}
+
+func (s *CachedStore) Close() error {
+	return s.m.Close()
+}
+
+func (s *CachedStore) List() ([]Event, error) {
+	return s.m.List(), nil
+}
+
+func (s *CachedStore) Get(id string) (Event, error) {
+	e, ok := s.m.Get(id)
+	if !ok {
+		return Event{}, ErrNotFound
+	}
+	return e, nil
+}
+
+func (s *CachedStore) ListByUser(userID string) ([]Event, error) {
+	return s.m.Find("user", userID), nil
+}
+
+func (s *CachedStore) Create(e Event) error {
+	return s.m.Put(e, nil)
+}
+
+func (s *CachedStore) Update(e Event) error {
+	return s.m.Put(e, func(v memstore.View[Event]) error {
+		if _, ok := v.Get(e.Id); !ok {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (s *CachedStore) Delete(id string) error {
+	ok, err := s.m.Delete(id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFound
+	}
+	return nil
+}
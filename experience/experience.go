@@ -0,0 +1,231 @@
+// Package experience - опыт работы в вакансиях и анкетах: диапазон лет и уровень
+// (junior, middle, ...). Здесь же разбор свободного текста ("3-5 лет", "до 1 года",
+// "без опыта") и поиск уровня в должности или названии вакансии.
+package experience
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// maxYears - больше лет опыта не бывает
+const maxYears = 50
+
+// Range - опыт в полных годах. Нулевая верхняя граница - открытая:
+// {Min: 3} - "от 3 лет"; исключение - {0, 0}, это "без опыта".
+type Range struct {
+	Min int `json:"min"`
+	Max int `json:"max,omitempty"`
+}
+
+// None - без опыта
+var None = Range{}
+
+// open - нет верхней границы
+func (r Range) open() bool {
+	return r.Max == 0 && r.Min > 0
+}
+
+func (r Range) upper() int {
+	if r.open() {
+		return math.MaxInt
+	}
+	return r.Max
+}
+
+// Overlaps - пересекаются ли диапазоны опыта
+func (r Range) Overlaps(other Range) bool {
+	return r.Min <= other.upper() && other.Min <= r.upper()
+}
+
+// Gap - на сколько лет опыт r не дотягивает до требуемого (меньше нуля) или
+// превышает его (больше нуля); 0 - диапазоны пересекаются
+func (r Range) Gap(required Range) int {
+	switch {
+	case r.upper() < required.Min:
+		return r.upper() - required.Min
+	case r.Min > required.upper():
+		return r.Min - required.upper()
+	}
+	return 0
+}
+
+// Группы опыта для статистики
+const (
+	GroupNone    = "no_experience"
+	GroupUnder1  = "under_1"
+	Group1To3    = "1_3"
+	Group3To6    = "3_6"
+	Group6Plus   = "6_plus"
+	GroupUnknown = "unknown"
+)
+
+// Group - группа опыта для статистики по нижней границе; nil - GroupUnknown
+func Group(r *Range) string {
+	switch {
+	case r == nil:
+		return GroupUnknown
+	case *r == None:
+		return GroupNone
+	case r.Min < 1:
+		return GroupUnder1
+	case r.Min < 3:
+		return Group1To3
+	case r.Min < 6:
+		return Group3To6
+	default:
+		return Group6Plus
+	}
+}
+
+// years - "N лет" в нужном падеже: именительном (2 года) или родительном (от 2 лет)
+func years(n int, genitive bool) string {
+	word := "лет"
+	switch {
+	case n%100 >= 11 && n%100 <= 14:
+	case n%10 == 1:
+		word = "год"
+		if genitive {
+			word = "года"
+		}
+	case n%10 >= 2 && n%10 <= 4 && !genitive:
+		word = "года"
+	}
+	return strconv.Itoa(n) + " " + word
+}
+
+// String - опыт текстом, как его показывают пользователю
+func (r Range) String() string {
+	switch {
+	case r == None:
+		return "без опыта"
+	case r.open():
+		return "от " + years(r.Min, true)
+	case r.Min == 0:
+		return "до " + years(r.Max, true)
+	case r.Min == r.Max:
+		return years(r.Min, false)
+	default:
+		return strconv.Itoa(r.Min) + "–" + years(r.Max, false)
+	}
+}
+
+// Validate проверяет границы опыта
+func (r Range) Validate() error {
+	if r.Min < 0 || r.Max < 0 {
+		return errors.New("Experience must not be negative")
+	}
+	if r.Min > maxYears || r.Max > maxYears {
+		return fmt.Errorf("Experience is too long (max %d years)", maxYears)
+	}
+	if r.Max > 0 && r.Min > r.Max {
+		return errors.New("experience_min is greater than experience_max")
+	}
+	return nil
+}
+
+// Level - уровень специалиста
+type Level string
+
+const (
+	LevelIntern Level = "intern"
+	LevelJunior Level = "junior"
+	LevelMiddle Level = "middle"
+	LevelSenior Level = "senior"
+	LevelLead   Level = "lead"
+)
+
+// Levels - уровни по возрастанию
+var Levels = []Level{LevelIntern, LevelJunior, LevelMiddle, LevelSenior, LevelLead}
+
+// ParseLevel проверяет название уровня из формы или запроса
+func ParseLevel(s string) (Level, error) {
+	l := Level(strings.ToLower(strings.TrimSpace(s)))
+	for _, known := range Levels {
+		if l == known {
+			return l, nil
+		}
+	}
+	return "", fmt.Errorf("Invalid seniority %q: must be intern, junior, middle, senior or lead", s)
+}
+
+// ParseLevels читает уровни через запятую из запроса: "junior,middle"
+func ParseLevels(s string) ([]Level, error) {
+	var list []Level
+	for _, name := range strings.Split(s, ",") {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		level, err := ParseLevel(name)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, level)
+	}
+	return list, nil
+}
+
+// ParseQuery читает диапазон опыта из параметра запроса ("3-5 лет", "от 2",
+// "без опыта"); пустой параметр - nil
+func ParseQuery(s string) (*Range, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	r, ok := Parse(s)
+	if !ok {
+		return nil, fmt.Errorf("Invalid experience %q: use e.g. \"3-5\", \"от 2\" or \"без опыта\"", s)
+	}
+	return r, nil
+}
+
+// FormFields - поля формы с типизированным опытом
+var FormFields = []string{"experience_min", "experience_max"}
+
+// Read читает опыт из формы: поля experience_min и experience_max, а без них -
+// разобранный текст поля experience. Как и у зарплаты, возвращается текст для
+// показа: пустой собирается из полей. Текст, в котором не нашлось опыта, -
+// ошибка. Опыта нет - nil.
+func Read(form url.Values) (string, *Range, error) {
+	text := strings.TrimSpace(form.Get("experience"))
+	if form.Get("experience_min") == "" && form.Get("experience_max") == "" {
+		if text == "" {
+			return "", nil, nil
+		}
+		r, ok := Parse(text)
+		if !ok {
+			return "", nil, fmt.Errorf("Cannot parse experience %q: use e.g. \"3-5 лет\", \"от 2 лет\" or \"без опыта\"", text)
+		}
+		return text, r, nil
+	}
+	var r Range
+	for field, dst := range map[string]*int{"experience_min": &r.Min, "experience_max": &r.Max} {
+		if v := form.Get(field); v != "" {
+			n, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil {
+				return "", nil, fmt.Errorf("Invalid %s", field)
+			}
+			*dst = n
+		}
+	}
+	if err := r.Validate(); err != nil {
+		return "", nil, err
+	}
+	if text == "" {
+		text = r.String()
+	}
+	return text, &r, nil
+}
+
+// ReadLevel читает уровень из поля seniority, а без него ищет его в тексте
+// должности или названия (DetectLevel). Уровня нет - пустая строка.
+func ReadLevel(form url.Values, title string) (Level, error) {
+	if v := form.Get("seniority"); v != "" {
+		return ParseLevel(v)
+	}
+	level, _ := DetectLevel(title)
+	return level, nil
+}
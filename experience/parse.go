@@ -0,0 +1,124 @@
+package experience
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// number - число лет или месяцев в тексте: "3", "1,5", "5+"
+var number = regexp.MustCompile(`(\d+(?:[.,]\d+)?)\s*(\+)?`)
+
+// Признаки границ и единиц в тексте (текст уже в нижнем регистре)
+var (
+	noneWords  = []string{"без опыта", "нет опыта", "не требуется", "не нужен", "no experience"}
+	lowerWords = map[string]bool{"от": true, "более": true, "больше": true, "свыше": true,
+		"from": true, "over": true, "more": true}
+	upperWords = map[string]bool{"до": true, "менее": true, "меньше": true,
+		"up": true, "under": true, "less": true}
+	// "5 лет и более"
+	lowerSuffixes = []string{"и более", "и больше", "or more"}
+	monthWords    = []string{"мес", "month"}
+)
+
+func containsAny(s string, words []string) bool {
+	for _, w := range words {
+		if strings.Contains(s, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// words разбивает текст на слова из букв и цифр
+func words(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func hasWord(s string, set map[string]bool) bool {
+	for _, w := range words(s) {
+		if set[w] {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse разбирает опыт из свободного текста: "2 года", "3-5 лет", "до 1 года",
+// "от 1 года до 3 лет", "5+ лет", "полгода", "без опыта". Дробные годы
+// округляются наружу: "1,5 года" - 1-2 года. ok == false, если опыта в тексте нет.
+func Parse(text string) (*Range, bool) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	text = strings.NewReplacer("ё", "е", "–", "-", "—", "-", "полгода", "6 месяцев").Replace(text)
+	if containsAny(text, noneWords) {
+		r := None
+		return &r, true
+	}
+	matches := number.FindAllStringSubmatchIndex(text, 2)
+	if len(matches) == 0 {
+		return nil, false
+	}
+	perYear := 1.0
+	if containsAny(text, monthWords) {
+		perYear = 12
+	}
+	var nums []float64
+	for _, m := range matches {
+		n, err := strconv.ParseFloat(strings.Replace(text[m[2]:m[3]], ",", ".", 1), 64)
+		if err != nil {
+			return nil, false
+		}
+		nums = append(nums, n/perYear)
+	}
+
+	var r Range
+	prefix := text[:matches[0][0]]
+	switch {
+	case len(nums) == 2:
+		r = Range{Min: int(math.Floor(nums[0])), Max: int(math.Ceil(nums[1]))}
+	case matches[0][4] >= 0 || hasWord(prefix, lowerWords) || containsAny(text, lowerSuffixes):
+		r = Range{Min: int(math.Floor(nums[0]))}
+	case hasWord(prefix, upperWords):
+		r = Range{Max: int(math.Ceil(nums[0]))}
+	default:
+		r = Range{Min: int(math.Floor(nums[0])), Max: int(math.Ceil(nums[0]))}
+	}
+	if r.Validate() != nil {
+		return nil, false
+	}
+	return &r, true
+}
+
+// levelWords - слова, по которым уровень узнается в должности или названии
+var levelWords = map[string]Level{
+	"стажер": LevelIntern, "стажерка": LevelIntern, "практикант": LevelIntern,
+	"intern": LevelIntern, "internship": LevelIntern, "trainee": LevelIntern,
+
+	"junior": LevelJunior, "jr": LevelJunior, "джуниор": LevelJunior, "джун": LevelJunior,
+	"младший": LevelJunior, "младшая": LevelJunior, "начинающий": LevelJunior,
+
+	"middle": LevelMiddle, "mid": LevelMiddle, "мидл": LevelMiddle, "средний": LevelMiddle,
+
+	"senior": LevelSenior, "sr": LevelSenior, "сеньор": LevelSenior, "синьор": LevelSenior,
+	"старший": LevelSenior, "старшая": LevelSenior, "ведущий": LevelSenior, "ведущая": LevelSenior,
+
+	"lead": LevelLead, "teamlead": LevelLead, "techlead": LevelLead, "тимлид": LevelLead,
+	"техлид": LevelLead, "лид": LevelLead, "руководитель": LevelLead, "head": LevelLead,
+	"principal": LevelLead,
+}
+
+// DetectLevel ищет уровень в тексте должности или названия вакансии ("Junior",
+// "Senior Go Developer", "Ведущий аналитик"); берется первое найденное слово
+func DetectLevel(text string) (Level, bool) {
+	text = strings.ReplaceAll(strings.ToLower(text), "ё", "е")
+	for _, w := range words(text) {
+		if level, ok := levelWords[w]; ok {
+			return level, true
+		}
+	}
+	return "", false
+}
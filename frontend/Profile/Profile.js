@@ -233,7 +233,7 @@ function updateProfileDisplay(anketa) {
         anketa.description || 'Нет описания';
     
     // Обновляем навыки
-    updateSkillsDisplay(anketa.skills);
+    updateSkillsDisplay(anketa.skill_names);
     
     // Обновляем приветственное сообщение
     updateWelcomeMessage(anketa.name || authUser?.username || 'Пользователь');
@@ -277,13 +277,12 @@ function updateProfileStats(anketa) {
 
 // Обновление отображения навыков
 function updateSkillsDisplay(skills) {
-    if (!skills) {
+    if (!skills || skills.length === 0) {
         document.getElementById('profile-skills-display').innerHTML = 'Навыки не указаны';
         return;
     }
     
-    const skillsArray = skills.split(',').map(skill => skill.trim()).filter(skill => skill);
-    const skillsHTML = skillsArray.map(skill => 
+    const skillsHTML = skills.map(skill => 
         `<span class="skill-tag">${skill}</span>`
     ).join('');
     
@@ -392,7 +391,7 @@ function fillProfileForm(anketa) {
     document.getElementById('profile-position').value = anketa.position || '';
     document.getElementById('profile-job').value = anketa.job || '';
     document.getElementById('profile-school').value = anketa.school || '';
-    document.getElementById('profile-skills').value = anketa.skills || '';
+    document.getElementById('profile-skills').value = (anketa.skill_names || []).join(', ');
     document.getElementById('profile-experience').value = anketa.experience || '';
     document.getElementById('profile-jobtype').value = anketa.jobtype || '';
     document.getElementById('profile-salary').value = anketa.salary || '';
@@ -40,7 +40,7 @@ function goToProfile() {
             level: getLevelFromExperience(anketa.experience),
             experience: anketa.experience || getExperienceFromAge(anketa.age),
             salary: anketa.salary ? `от ${formatSalary(anketa.salary)} ₽` : 'Не указано',
-            skills: anketa.skills ? anketa.skills.split(',').map(s => s.trim()) : [],
+            skills: anketa.skill_names || [],
             city: anketa.city || 'Не указан',
             jobType: getJobTypeFromFormat(anketa.jobtype),
             education: anketa.school,
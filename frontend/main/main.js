@@ -160,8 +160,10 @@ function createJobCard(job) {
     jobCard.dataset.type = job.job_type || 'full'; 
     jobCard.dataset.salary = parseSalary(job.salary);
 
-    const skills = job.skills
-        ? job.skills.split(",").map(s => `<span class="skill-tag">${s.trim()}</span>`).join("")
+    // skill_names - названия навыков для показа (в skills - их ID)
+    const skillNames = job.skill_names || [];
+    const skills = skillNames.length
+        ? skillNames.map(s => `<span class="skill-tag">${s}</span>`).join("")
         : "<span class='skill-tag'>Навыки не указаны</span>";
     
     const jobTypeDisplay = {
@@ -145,8 +145,10 @@ function jobCard(job) {
     div.className = "job-card";
     const id = job.id || job.Id;
 
-    const skills = job.skills
-        ? job.skills.split(",").map(s => `<span class="skill-tag">${s.trim()}</span>`).join("")
+    // skill_names - названия навыков для показа (в skills - их ID)
+    const skillNames = job.skill_names || [];
+    const skills = skillNames.length
+        ? skillNames.map(s => `<span class="skill-tag">${s}</span>`).join("")
         : "<span class='skill-tag'>Без навыков</span>";
 
     div.innerHTML = `
@@ -191,7 +193,7 @@ function openEditModal(jobData) {
     document.getElementById('edit-title').value = jobData.title;
     document.getElementById('edit-company').value = jobData.company;
     document.getElementById('edit-salary').value = jobData.salary;
-    document.getElementById('edit-skills').value = jobData.skills;
+    document.getElementById('edit-skills').value = (jobData.skill_names || []).join(', ');
     document.getElementById('edit-description').value = jobData.description;
 
     modal.style.display = 'flex';
@@ -0,0 +1,147 @@
+// Package fulltext - полнотекстовый поиск по вакансиям и анкетам: обратный
+// индекс с русским и английским стеммингом (поиск "разработчика" находит
+// "разработчик"), весами полей и ранжированием по BM25. Записи добавляются,
+// меняются и удаляются по одной, без перестройки индекса.
+package fulltext
+
+import (
+	"math"
+	"slices"
+	"sync"
+)
+
+// Параметры BM25: k1 - насыщение частоты терма, b - учет длины записи
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Fields - текст записи по полям: "title" - "Go-разработчик", "description" - ...
+type Fields map[string]string
+
+// document - проиндексированная запись: взвешенная частота каждого терма
+// (сумма по полям с весами полей) и такая же взвешенная длина
+type document struct {
+	terms  map[string]float64
+	length float64
+}
+
+// Index - обратный индекс: терм - записи, где он встречается. Совпадение в поле
+// с большим весом (название) дает больше, чем в поле с меньшим (описание), как в
+// BM25F. Можно использовать из нескольких горутин.
+type Index struct {
+	mu       sync.RWMutex
+	boosts   map[string]float64
+	docs     map[string]document
+	postings map[string]map[string]bool // терм - ID записей с ним
+	total    float64                    // сумма длин записей, для средней длины
+}
+
+// New создает пустой индекс; boosts - веса полей, у полей не из boosts вес 1
+func New(boosts map[string]float64) *Index {
+	return &Index{
+		boosts:   boosts,
+		docs:     map[string]document{},
+		postings: map[string]map[string]bool{},
+	}
+}
+
+func (ix *Index) boost(field string) float64 {
+	if w, ok := ix.boosts[field]; ok {
+		return w
+	}
+	return 1
+}
+
+// Put добавляет запись или заменяет ее прежний текст
+func (ix *Index) Put(id string, fields Fields) {
+	doc := document{terms: map[string]float64{}}
+	for field, text := range fields {
+		w := ix.boost(field)
+		for _, term := range Terms(text) {
+			doc.terms[term] += w
+			doc.length += w
+		}
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.remove(id)
+	if len(doc.terms) == 0 {
+		return
+	}
+	ix.docs[id] = doc
+	ix.total += doc.length
+	for term := range doc.terms {
+		if ix.postings[term] == nil {
+			ix.postings[term] = map[string]bool{}
+		}
+		ix.postings[term][id] = true
+	}
+}
+
+// Delete убирает запись из индекса
+func (ix *Index) Delete(id string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.remove(id)
+}
+
+func (ix *Index) remove(id string) {
+	doc, ok := ix.docs[id]
+	if !ok {
+		return
+	}
+	for term := range doc.terms {
+		delete(ix.postings[term], id)
+		if len(ix.postings[term]) == 0 {
+			delete(ix.postings, term)
+		}
+	}
+	ix.total -= doc.length
+	delete(ix.docs, id)
+}
+
+// Len - сколько записей в индексе
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.docs)
+}
+
+// Search - записи, в которых есть все термы запроса, с оценкой BM25: чем выше,
+// тем лучше запись подходит. Запрос без термов (пустой или из одних служебных
+// слов) ничего не находит. Результат не nil.
+func (ix *Index) Search(query string) map[string]float64 {
+	terms := Terms(query)
+	slices.Sort(terms)
+	terms = slices.Compact(terms)
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	scores := map[string]float64{}
+	if len(terms) == 0 {
+		return scores
+	}
+	// Начинаем с самого редкого терма: записей-кандидатов меньше всего
+	slices.SortFunc(terms, func(a, b string) int { return len(ix.postings[a]) - len(ix.postings[b]) })
+	for id := range ix.postings[terms[0]] {
+		scores[id] = 0
+	}
+	n := float64(len(ix.docs))
+	avgLength := ix.total / n
+	for _, term := range terms {
+		ids := ix.postings[term]
+		idf := math.Log(1 + (n-float64(len(ids))+0.5)/(float64(len(ids))+0.5))
+		for id := range scores {
+			if !ids[id] {
+				delete(scores, id)
+				continue
+			}
+			doc := ix.docs[id]
+			tf := doc.terms[term]
+			scores[id] += idf * tf * (k1 + 1) / (tf + k1*(1-b+b*doc.length/avgLength))
+		}
+	}
+	return scores
+}
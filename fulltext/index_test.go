@@ -0,0 +1,228 @@
+package fulltext
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestStem(t *testing.T) {
+	tests := map[string]string{
+		"разработчика":   "разработчик",
+		"разработчики":   "разработчик",
+		"разработчиков":  "разработчик",
+		"программистом":  "программист",
+		"тестирования":   "тестирован",
+		"красивейший":    "красив",
+		"developers":     "develop",
+		"developing":     "develop",
+		"relational":     "relat",
+		"generalization": "gener",
+		"node.js":        "node.js",
+		"c++":            "c++",
+	}
+	for word, want := range tests {
+		if got := stem(word); got != want {
+			t.Errorf("stem(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
+
+func TestTerms(t *testing.T) {
+	got := Terms("Веб-разработчик и тестировщик: C++, C#, Node.js.")
+	want := []string{"веб", "разработчик", "тестировщик", "c++", "c#", "node.js"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Terms = %q, want %q", got, want)
+	}
+}
+
+func TestSearchRanksByFieldBoost(t *testing.T) {
+	ix := New(map[string]float64{"title": 3, "description": 1})
+	ix.Put("desc", Fields{"title": "Бухгалтер", "description": "Разработчики не нужны"})
+	ix.Put("title", Fields{"title": "Ведущий разработчик Go", "description": "Пишем сервисы"})
+	ix.Put("other", Fields{"title": "Дизайнер", "description": "Рисуем макеты"})
+
+	scores := ix.Search("разработчика")
+	if len(scores) != 2 || scores["title"] <= scores["desc"] {
+		t.Fatalf("Search = %v, want title ranked above desc, other not found", scores)
+	}
+	if got := ix.Search("разработчик go"); len(got) != 1 || got["title"] == 0 {
+		t.Errorf("all terms must match: Search = %v", got)
+	}
+	if got := ix.Search("и в"); len(got) != 0 {
+		t.Errorf("stop words only: Search = %v, want none", got)
+	}
+
+	ix.Put("title", Fields{"title": "Тестировщик"})
+	if got := ix.Search("разработчик"); len(got) != 1 {
+		t.Errorf("after update: Search = %v, want only desc", got)
+	}
+	ix.Delete("desc")
+	if got := ix.Search("разработчик"); len(got) != 0 || ix.Len() != 2 {
+		t.Errorf("after delete: Search = %v, Len = %d", got, ix.Len())
+	}
+}
+
+func TestSearchBM25(t *testing.T) {
+	ix := New(nil)
+	ix.Put("once", Fields{"text": "go сервис база очередь"})
+	ix.Put("twice", Fields{"text": "go go база очередь"})
+	ix.Put("four", Fields{"text": "go go go go"})
+	ix.Put("long", Fields{"text": "go сервис база очередь кафка докер линукс"})
+	ix.Put("other", Fields{"text": "python сервис"})
+
+	// Частота терма поднимает оценку, но с насыщением
+	scores := ix.Search("go")
+	if !(scores["once"] < scores["twice"] && scores["twice"] < scores["four"]) {
+		t.Fatalf("Search = %v, want once < twice < four", scores)
+	}
+	if scores["twice"] >= 2*scores["once"] {
+		t.Errorf("tf does not saturate: twice %v, once %v", scores["twice"], scores["once"])
+	}
+	// При той же частоте короткая запись выше длинной
+	if scores["long"] >= scores["once"] {
+		t.Errorf("length not normalized: long %v, once %v", scores["long"], scores["once"])
+	}
+	// Редкий терм весит больше частого: "кафка" есть в одной записи, "база" - в трех
+	if rare, common := ix.Search("кафка")["long"], ix.Search("база")["long"]; rare <= common {
+		t.Errorf("idf: rare term %v, common term %v", rare, common)
+	}
+	if got := ix.Search("сервис go")["once"]; got <= scores["once"] {
+		t.Errorf("second term adds nothing: %v, go alone %v", got, scores["once"])
+	}
+}
+
+type doc struct {
+	id, text string
+}
+
+// memStore - хранилище для Indexed в тестах
+type memStore struct {
+	mu   sync.Mutex
+	docs map[string]doc
+}
+
+var errMissing = errors.New("missing")
+
+func (s *memStore) list() ([]doc, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []doc
+	for _, d := range s.docs {
+		list = append(list, d)
+	}
+	return list, nil
+}
+
+func (s *memStore) update(d doc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.docs[d.id]; !ok {
+		return errMissing
+	}
+	s.docs[d.id] = d
+	return nil
+}
+
+func (s *memStore) delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.docs[id]; !ok {
+		return errMissing
+	}
+	delete(s.docs, id)
+	return nil
+}
+
+func newIndexed(s *memStore) *Indexed[doc] {
+	return NewIndexed(s.list, func(d doc) string { return d.id },
+		func(d doc) Fields { return Fields{"text": d.text} }, nil)
+}
+
+func TestIndexedFollowsStore(t *testing.T) {
+	s := &memStore{docs: map[string]doc{"1": {"1", "разработчик"}, "2": {"2", "дизайнер"}}}
+	x := newIndexed(s)
+
+	if r, _ := x.Search(""); r != nil || !r.Has("any") {
+		t.Fatalf("empty query: ranking %v, want nil matching everything", r)
+	}
+	if r, _ := x.Search("разработчики"); !r.Has("1") || r.Has("2") {
+		t.Fatalf("Search = %v, want only 1", r)
+	}
+	if err := x.Put(doc{"2", "разработчик"}, s.update); err != nil {
+		t.Fatal(err)
+	}
+	// Неудачная запись в хранилище не меняет индекс
+	if err := x.Put(doc{"3", "разработчик"}, s.update); !errors.Is(err, errMissing) {
+		t.Fatalf("Put of missing doc = %v, want errMissing", err)
+	}
+	if err := x.Delete("1", s.delete); err != nil {
+		t.Fatal(err)
+	}
+	r, _ := x.Search("разработчик")
+	if r.Has("1") || !r.Has("2") || r.Has("3") {
+		t.Fatalf("Search = %v, want only 2", r)
+	}
+}
+
+func TestIndexedConcurrentUpdateDelete(t *testing.T) {
+	s := &memStore{docs: map[string]doc{}}
+	for i := range 100 {
+		id := strconv.Itoa(i)
+		s.docs[id] = doc{id, "разработчик"}
+	}
+	x := newIndexed(s)
+	if _, err := x.Search("разработчик"); err != nil { // строим индекс
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 100 {
+		id := strconv.Itoa(i)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			x.Put(doc{id, "разработчик go"}, s.update)
+		}()
+		go func() {
+			defer wg.Done()
+			x.Delete(id, s.delete)
+		}()
+	}
+	wg.Wait()
+
+	// Что бы ни победило, индекс совпадает с хранилищем
+	r, _ := x.Search("разработчик")
+	for i := range 100 {
+		id := strconv.Itoa(i)
+		_, inStore := s.docs[id]
+		if r.Has(id) != inStore {
+			t.Errorf("doc %s: in index %v, in store %v", id, r.Has(id), inStore)
+		}
+	}
+}
+
+func TestIndexedSort(t *testing.T) {
+	x := newIndexed(&memStore{})
+	list := []doc{{id: "a"}, {id: "b"}, {id: "c"}, {id: "d"}}
+
+	x.Sort(list, nil)
+	if got := ids(list); got != "abcd" {
+		t.Errorf("nil ranking: %s, want order unchanged", got)
+	}
+	// Равные оценки сохраняют прежний порядок
+	x.Sort(list, Ranking{"a": 1, "b": 3, "c": 1, "d": 2})
+	if got := ids(list); got != "bdac" {
+		t.Errorf("Sort = %s, want bdac", got)
+	}
+}
+
+func ids(list []doc) string {
+	var s string
+	for _, d := range list {
+		s += d.id
+	}
+	return s
+}
@@ -0,0 +1,117 @@
+package fulltext
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Ranking - оценки найденных записей по ID; nil - текста в запросе не было,
+// и подходят все записи
+type Ranking map[string]float64
+
+// Has - найдена ли запись
+func (r Ranking) Has(id string) bool {
+	if r == nil {
+		return true
+	}
+	_, ok := r[id]
+	return ok
+}
+
+// Indexed - индекс записей типа T из хранилища. Изменение записи в хранилище и
+// в индексе идут под одной блокировкой, поэтому индекс меняется в том же
+// порядке, что и хранилище: одновременные правка и удаление не вернут в индекс
+// удаленную запись. Сам индекс строится из хранилища при первом поиске.
+type Indexed[T any] struct {
+	mu     sync.RWMutex
+	index  *Index // nil - еще не построен
+	boosts map[string]float64
+	list   func() ([]T, error)
+	id     func(T) string
+	fields func(T) Fields
+}
+
+// NewIndexed - индекс записей хранилища: list - все записи, id и fields - ID
+// записи и ее текст по полям, boosts - веса полей (см. New)
+func NewIndexed[T any](list func() ([]T, error), id func(T) string, fields func(T) Fields,
+	boosts map[string]float64) *Indexed[T] {
+	return &Indexed[T]{boosts: boosts, list: list, id: id, fields: fields}
+}
+
+// Put записывает v через write (создание или правка в хранилище) и, если
+// запись удалась, обновляет индекс
+func (x *Indexed[T]) Put(v T, write func(T) error) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if err := write(v); err != nil {
+		return err
+	}
+	if x.index != nil {
+		x.index.Put(x.id(v), x.fields(v))
+	}
+	return nil
+}
+
+// Delete удаляет запись через remove и, если удаление удалось, убирает ее из индекса
+func (x *Indexed[T]) Delete(id string, remove func(string) error) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if err := remove(id); err != nil {
+		return err
+	}
+	if x.index != nil {
+		x.index.Delete(id)
+	}
+	return nil
+}
+
+// built - индекс, при первом обращении построенный из хранилища
+func (x *Indexed[T]) built() (*Index, error) {
+	x.mu.RLock()
+	ix := x.index
+	x.mu.RUnlock()
+	if ix != nil {
+		return ix, nil
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.index != nil {
+		return x.index, nil
+	}
+	list, err := x.list()
+	if err != nil {
+		return nil, err
+	}
+	ix = New(x.boosts)
+	for _, v := range list {
+		ix.Put(x.id(v), x.fields(v))
+	}
+	x.index = ix
+	return ix, nil
+}
+
+// Search ищет query (см. Index.Search); пустой query - nil, подходит все
+func (x *Indexed[T]) Search(query string) (Ranking, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, nil
+	}
+	ix, err := x.built()
+	if err != nil {
+		return nil, err
+	}
+	return ix.Search(query), nil
+}
+
+// Sort упорядочивает записи по убыванию оценки; при равной - в прежнем порядке.
+// С nil ranking порядок не меняется.
+func (x *Indexed[T]) Sort(list []T, ranking Ranking) {
+	if ranking == nil {
+		return
+	}
+	slices.SortStableFunc(list, func(a, b T) int {
+		return cmp.Compare(ranking[x.id(b)], ranking[x.id(a)])
+	})
+}
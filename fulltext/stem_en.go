@@ -0,0 +1,165 @@
+package fulltext
+
+import "strings"
+
+// Английский стеммер по алгоритму Портера: "developers", "developing" и
+// "developed" дают одну основу "develop". Слово - латиница в нижнем регистре.
+
+// enConsonant - согласная ли буква w[i]; y - согласная в начале слова и после гласной
+func enConsonant(w string, i int) bool {
+	switch w[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !enConsonant(w, i-1)
+	}
+	return true
+}
+
+// enMeasure - число m в записи слова [C](VC)^m[V]
+func enMeasure(w string) int {
+	m, i, n := 0, 0, len(w)
+	for i < n && enConsonant(w, i) {
+		i++
+	}
+	for i < n {
+		for i < n && !enConsonant(w, i) {
+			i++
+		}
+		if i == n {
+			break
+		}
+		for i < n && enConsonant(w, i) {
+			i++
+		}
+		m++
+	}
+	return m
+}
+
+func enHasVowel(w string) bool {
+	for i := range len(w) {
+		if !enConsonant(w, i) {
+			return true
+		}
+	}
+	return false
+}
+
+func enDoubleConsonant(w string) bool {
+	n := len(w)
+	return n >= 2 && w[n-1] == w[n-2] && enConsonant(w, n-1)
+}
+
+// enCVC - слово кончается на согласную-гласную-согласную, и последняя не w, x, y
+func enCVC(w string) bool {
+	n := len(w)
+	if n < 3 || !enConsonant(w, n-3) || enConsonant(w, n-2) || !enConsonant(w, n-1) {
+		return false
+	}
+	c := w[n-1]
+	return c != 'w' && c != 'x' && c != 'y'
+}
+
+// enReplace меняет первое подходящее окончание из пар {окончание, замена}, если
+// у основы m > minMeasure; ok - окончание нашлось (даже если основа коротка)
+func enReplace(w string, pairs [][2]string, minMeasure int) (string, bool) {
+	for _, p := range pairs {
+		if stem, found := strings.CutSuffix(w, p[0]); found {
+			if enMeasure(stem) > minMeasure {
+				return stem + p[1], true
+			}
+			return w, true
+		}
+	}
+	return w, false
+}
+
+var (
+	enStep2 = [][2]string{
+		{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"}, {"izer", "ize"},
+		{"abli", "able"}, {"alli", "al"}, {"entli", "ent"}, {"eli", "e"}, {"ousli", "ous"},
+		{"ization", "ize"}, {"ation", "ate"}, {"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"},
+		{"fulness", "ful"}, {"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+	}
+	enStep3 = [][2]string{
+		{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"}, {"ical", "ic"},
+		{"ful", ""}, {"ness", ""},
+	}
+	enStep4 = []string{
+		"ement", "ance", "ence", "able", "ible", "ment", "ant", "ent", "ism", "ate", "iti", "ous",
+		"ive", "ize", "ion", "al", "er", "ic", "ou",
+	}
+)
+
+func stemEnglish(w string) string {
+	if len(w) <= 2 {
+		return w
+	}
+
+	// Шаг 1a: множественное число
+	switch {
+	case strings.HasSuffix(w, "sses"), strings.HasSuffix(w, "ies"):
+		w = w[:len(w)-2]
+	case strings.HasSuffix(w, "ss"):
+	case strings.HasSuffix(w, "s"):
+		w = w[:len(w)-1]
+	}
+
+	// Шаг 1b: -ed и -ing
+	if stem, ok := strings.CutSuffix(w, "eed"); ok {
+		if enMeasure(stem) > 0 {
+			w = stem + "ee"
+		}
+	} else {
+		var cutOff bool
+		for _, suffix := range []string{"ed", "ing"} {
+			if stem, ok := strings.CutSuffix(w, suffix); ok && enHasVowel(stem) {
+				w, cutOff = stem, true
+				break
+			}
+		}
+		if cutOff {
+			switch {
+			case strings.HasSuffix(w, "at"), strings.HasSuffix(w, "bl"), strings.HasSuffix(w, "iz"):
+				w += "e"
+			case enDoubleConsonant(w) && !strings.ContainsAny(w[len(w)-1:], "lsz"):
+				w = w[:len(w)-1]
+			case enMeasure(w) == 1 && enCVC(w):
+				w += "e"
+			}
+		}
+	}
+
+	// Шаг 1c: y - i после гласной в основе
+	if stem, ok := strings.CutSuffix(w, "y"); ok && enHasVowel(stem) {
+		w = stem + "i"
+	}
+
+	// Шаги 2-3: двойные и одиночные суффиксы
+	w, _ = enReplace(w, enStep2, 0)
+	w, _ = enReplace(w, enStep3, 0)
+
+	// Шаг 4: суффиксы при m > 1
+	for _, suffix := range enStep4 {
+		stem, ok := strings.CutSuffix(w, suffix)
+		if !ok {
+			continue
+		}
+		if enMeasure(stem) > 1 && (suffix != "ion" || strings.HasSuffix(stem, "s") || strings.HasSuffix(stem, "t")) {
+			w = stem
+		}
+		break
+	}
+
+	// Шаг 5: конечная e и двойная l
+	if stem, ok := strings.CutSuffix(w, "e"); ok {
+		if m := enMeasure(stem); m > 1 || (m == 1 && !enCVC(stem)) {
+			w = stem
+		}
+	}
+	if enMeasure(w) > 1 && strings.HasSuffix(w, "ll") {
+		w = w[:len(w)-1]
+	}
+	return w
+}
@@ -0,0 +1,139 @@
+package fulltext
+
+import "strings"
+
+// Русский стеммер по алгоритму Snowball (Портер для русского языка): отрезает
+// окончания, чтобы "разработчика", "разработчики" и "разработчик" давали одну
+// основу. Слово - в нижнем регистре, ё уже заменена на е.
+
+var (
+	ruPerfectiveGerund1 = []string{"вшись", "вши", "в"} // после а или я
+	ruPerfectiveGerund2 = []string{"ившись", "ывшись", "ивши", "ывши", "ив", "ыв"}
+	ruReflexive         = []string{"ся", "сь"}
+	ruAdjective         = []string{"ими", "ыми", "его", "ого", "ему", "ому", "ее", "ие", "ые", "ое",
+		"ей", "ий", "ый", "ой", "ем", "им", "ым", "ом", "их", "ых", "ую", "юю", "ая", "яя", "ою", "ею"}
+	ruParticiple1 = []string{"ем", "нн", "вш", "ющ", "щ"} // после а или я
+	ruParticiple2 = []string{"ивш", "ывш", "ующ"}
+	ruVerb1       = []string{"ете", "йте", "ешь", "нно", "ла", "на", "ли", "ем", "ло", "но", "ет", "ют",
+		"ны", "ть", "й", "л", "н"} // после а или я
+	ruVerb2 = []string{"ейте", "уйте", "ила", "ыла", "ена", "ите", "или", "ыли", "ило", "ыло", "ено",
+		"ует", "уют", "ены", "ить", "ыть", "ишь", "ей", "уй", "ил", "ыл", "им", "ым", "ен", "ят", "ит",
+		"ыт", "ую", "ю"}
+	ruNoun = []string{"иями", "ями", "ами", "ией", "иям", "ием", "иях", "ев", "ов", "ие", "ье", "еи",
+		"ии", "ей", "ой", "ий", "ям", "ем", "ам", "ом", "ах", "ях", "ию", "ью", "ия", "ья",
+		"а", "е", "и", "й", "о", "у", "ы", "ь", "ю", "я"}
+	ruSuperlative  = []string{"ейше", "ейш"}
+	ruDerivational = []string{"ость", "ост"}
+)
+
+func ruVowel(r rune) bool {
+	return strings.ContainsRune("аеиоуыэюя", r)
+}
+
+// ruRegions - начало областей RV (после первой гласной) и R2 (см. Snowball) в рунах
+func ruRegions(w []rune) (rv, r2 int) {
+	rv, r1, r2 := len(w), len(w), len(w)
+	for i, r := range w {
+		if ruVowel(r) {
+			rv = i + 1
+			break
+		}
+	}
+	next := func(from int) int {
+		for i := from + 1; i < len(w); i++ {
+			if !ruVowel(w[i]) && ruVowel(w[i-1]) {
+				return i + 1
+			}
+		}
+		return len(w)
+	}
+	r1 = next(0)
+	if r1 < len(w) {
+		r2 = next(r1)
+	}
+	return rv, r2
+}
+
+// cut отрезает самое длинное окончание из списка, целиком лежащее после start;
+// с afterAOrYa окончание должно идти после а или я (они остаются и тоже должны
+// лежать после start)
+func cut(w []rune, start int, endings []string, afterAOrYa bool) ([]rune, bool) {
+	if start > len(w) {
+		return w, false
+	}
+	best := ""
+	s := string(w[start:])
+	for _, e := range endings {
+		if len(e) <= len(best) || !strings.HasSuffix(s, e) {
+			continue
+		}
+		if afterAOrYa {
+			rest := []rune(strings.TrimSuffix(s, e))
+			if len(rest) == 0 || (rest[len(rest)-1] != 'а' && rest[len(rest)-1] != 'я') {
+				continue
+			}
+		}
+		best = e
+	}
+	if best == "" {
+		return w, false
+	}
+	return w[:len(w)-len([]rune(best))], true
+}
+
+// cutAny - cut сначала по окончаниям после а/я, потом по остальным; берется более длинное
+func cutAny(w []rune, start int, afterAOrYa, other []string) ([]rune, bool) {
+	w1, ok1 := cut(w, start, afterAOrYa, true)
+	w2, ok2 := cut(w, start, other, false)
+	switch {
+	case ok1 && ok2:
+		return w[:min(len(w1), len(w2))], true
+	case ok1:
+		return w1, true
+	default:
+		return w2, ok2
+	}
+}
+
+func stemRussian(word string) string {
+	w := []rune(word)
+	rv, r2 := ruRegions(w)
+	if rv >= len(w) {
+		return word
+	}
+
+	// Шаг 1: деепричастие; иначе возвратная частица, затем прилагательное
+	// (с причастием), глагол или существительное
+	if rest, ok := cutAny(w, rv, ruPerfectiveGerund1, ruPerfectiveGerund2); ok {
+		w = rest
+	} else {
+		w, _ = cut(w, rv, ruReflexive, false)
+		if rest, ok := cut(w, rv, ruAdjective, false); ok {
+			w = rest
+			if rest, ok := cutAny(w, rv, ruParticiple1, ruParticiple2); ok {
+				w = rest
+			}
+		} else if rest, ok := cutAny(w, rv, ruVerb1, ruVerb2); ok {
+			w = rest
+		} else {
+			w, _ = cut(w, rv, ruNoun, false)
+		}
+	}
+
+	// Шаг 2: и на конце
+	w, _ = cut(w, rv, []string{"и"}, false)
+
+	// Шаг 3: словообразовательное окончание в R2
+	if r2 < len(w) {
+		w, _ = cut(w, r2, ruDerivational, false)
+	}
+
+	// Шаг 4: превосходная степень, двойное н, мягкий знак
+	w, superlative := cut(w, rv, ruSuperlative, false)
+	if rest, ok := cut(w, rv, []string{"нн"}, false); ok {
+		w = append(rest, 'н')
+	} else if !superlative {
+		w, _ = cut(w, rv, []string{"ь"}, false)
+	}
+	return string(w)
+}
@@ -0,0 +1,93 @@
+package fulltext
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxTermLength - более длинные слова (ссылки, мусор) не индексируются
+const maxTermLength = 40
+
+// stopWords - служебные слова, которые встречаются почти везде и ничего не
+// говорят о записи; в индекс и в запрос они не попадают
+var stopWords = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`
+		а без в во да для до же за и из или к как ко ли на над не нет ни но о об
+		от по под при про с со так то у уже чем что это
+		a an and are as at be by for from in is of on or the to with`) {
+		stopWords[w] = true
+	}
+}
+
+// wordRune - буква или цифра
+func wordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// words делит текст на слова в нижнем регистре. Внутри слова сохраняется точка
+// между буквами ("node.js"), а на конце - плюсы и решетка ("c++", "c#"), чтобы
+// названия технологий не терялись. Дефис делит слово: "веб-разработчик".
+func words(text string) []string {
+	text = strings.ReplaceAll(strings.ToLower(text), "ё", "е")
+	var list []string
+	var b strings.Builder
+	flush := func() {
+		if b.Len() > 0 {
+			list = append(list, b.String())
+			b.Reset()
+		}
+	}
+	for i, r := range text {
+		switch {
+		case wordRune(r):
+			b.WriteRune(r)
+		case r == '.' && b.Len() > 0:
+			next, _ := utf8.DecodeRuneInString(text[i+1:])
+			if !wordRune(next) {
+				flush()
+				continue
+			}
+			b.WriteRune(r)
+		case (r == '+' || r == '#') && b.Len() > 0:
+			b.WriteRune(r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return list
+}
+
+// stem - основа слова: русского или английского по первой букве; слова с
+// цифрами и знаками (1c, c++, node.js) не меняются
+func stem(word string) string {
+	for _, r := range word {
+		if !unicode.IsLetter(r) {
+			return word
+		}
+	}
+	first, _ := utf8.DecodeRuneInString(word)
+	switch {
+	case unicode.Is(unicode.Cyrillic, first):
+		return stemRussian(word)
+	case first < utf8.RuneSelf:
+		return stemEnglish(word)
+	}
+	return word
+}
+
+// Terms - термы текста для индекса и запроса: слова без служебных, приведенные
+// к основе
+func Terms(text string) []string {
+	var terms []string
+	for _, w := range words(text) {
+		if stopWords[w] || utf8.RuneCountInString(w) > maxTermLength {
+			continue
+		}
+		terms = append(terms, stem(w))
+	}
+	return terms
+}
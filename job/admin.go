@@ -0,0 +1,135 @@
+package job
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"talant/auth"
+	"talant/moderation"
+)
+
+// listVisible - объявления для публичных страниц, без скрытых модератором
+func listVisible() ([]Job, error) {
+	jobs, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	visible := make([]Job, 0, len(jobs))
+	for _, j := range jobs {
+		if j.Moderation == nil {
+			visible = append(visible, j)
+		}
+	}
+	return visible, nil
+}
+
+// AdminListHandler - все объявления, включая скрытые (GET /admin/jobs?status=hidden|visible)
+func AdminListHandler(w http.ResponseWriter, r *http.Request) {
+	filter, ok := moderation.FilterFromRequest(r)
+	if !ok {
+		http.Error(w, "Invalid status", http.StatusBadRequest)
+		return
+	}
+	jobs, err := store.List()
+	if err != nil {
+		http.Error(w, "Error loading jobs: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	list := []Job{}
+	for _, j := range jobs {
+		if filter.Match(j.Moderation) {
+			list = append(list, j)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// moderate загружает объявление из пути, меняет его и сохраняет
+func moderate(w http.ResponseWriter, r *http.Request, change func(*Job)) {
+	job, err := store.Get(r.PathValue("id"))
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error loading jobs: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	change(&job)
+	if err := store.Update(job); err != nil {
+		http.Error(w, "Error saving jobs: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// HideHandler скрывает объявление с указанием причины (POST /admin/jobs/{id}/hide, поле reason)
+func HideHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	reason := r.FormValue("reason")
+	if reason == "" {
+		http.Error(w, "Reason is required", http.StatusBadRequest)
+		return
+	}
+	moderate(w, r, func(j *Job) {
+		j.Moderation = moderation.New(reason, user.ID, false)
+	})
+}
+
+// RestoreHandler возвращает скрытое объявление (POST /admin/jobs/{id}/restore)
+func RestoreHandler(w http.ResponseWriter, r *http.Request) {
+	moderate(w, r, func(j *Job) {
+		j.Moderation = nil
+	})
+}
+
+// PurgeHandler удаляет любое объявление без возможности восстановления (DELETE /admin/jobs/{id})
+func PurgeHandler(w http.ResponseWriter, r *http.Request) {
+	j, err := store.Get(r.PathValue("id"))
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error loading jobs", http.StatusInternalServerError)
+		return
+	}
+	err = deleteJob(j)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error saving jobs", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetUserBan скрывает объявления забаненного пользователя (ban != nil) или
+// возвращает скрытые из-за бана, когда его сняли (ban == nil). Подключается через auth.OnBan.
+func SetUserBan(userID string, ban *moderation.State) error {
+	jobs, err := store.ListByUser(userID)
+	if err != nil {
+		return err
+	}
+	for _, j := range jobs {
+		switch {
+		case ban != nil && j.Moderation == nil:
+			j.Moderation = ban
+		case ban == nil && j.Moderation != nil && j.Moderation.Ban:
+			j.Moderation = nil
+		default:
+			continue
+		}
+		if err := store.Update(j); err != nil {
+			return err
+		}
+	}
+	return nil
+}
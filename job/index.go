@@ -0,0 +1,39 @@
+package job
+
+import "talant/fulltext"
+
+// fieldBoosts - веса полей в поиске по q: совпадение в названии важнее, чем в описании
+var fieldBoosts = map[string]float64{"title": 3, "skills": 2, "company": 1.5, "description": 1}
+
+func searchFields(j Job) fulltext.Fields {
+	return fulltext.Fields{
+		"title":       j.Title,
+		"company":     j.Company,
+		"skills":      j.Skills.String(),
+		"description": j.Description,
+	}
+}
+
+// indexedStore - хранилище, изменения в котором сразу попадают в поисковый индекс
+type indexedStore struct {
+	Store
+	index *fulltext.Indexed[Job]
+}
+
+// withIndex оборачивает хранилище и возвращает его вместе с индексом для q
+func withIndex(s Store) (Store, *fulltext.Indexed[Job]) {
+	index := fulltext.NewIndexed(s.List, func(j Job) string { return j.Id }, searchFields, fieldBoosts)
+	return indexedStore{s, index}, index
+}
+
+func (s indexedStore) Create(j Job) error {
+	return s.index.Put(j, s.Store.Create)
+}
+
+func (s indexedStore) Update(j Job) error {
+	return s.index.Put(j, s.Store.Update)
+}
+
+func (s indexedStore) Delete(id string) error {
+	return s.index.Delete(id, s.Store.Delete)
+}
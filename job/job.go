@@ -2,10 +2,15 @@ package job
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"os"
 	"strings"
+	"talant/auth"
+	"talant/experience"
+	"talant/moderation"
+	"talant/salary"
+	"talant/skills"
 
 	"github.com/google/uuid"
 )
@@ -19,59 +24,82 @@ type Job struct {
 	Company     string `json:"company"`
 	School      string `json:"school"`
 	Description string `json:"description"`
-	Salary      string `json:"salary"`
-	Skills      string `json:"skills"`
-	Location    string `json:"location,omitempty"`
-	Experience  string `json:"experience,omitempty"`
-	JobType     string `json:"job_type,omitempty"`
-	Telegram    string `json:"telegram,omitempty"`
+	// Salary - зарплата текстом, как ее ввел автор; SalaryRange - она же для
+	// сравнения и фильтров (нет, если в тексте не нашлось сумм)
+	Salary      string         `json:"salary"`
+	SalaryRange *salary.Salary `json:"salary_range,omitempty"`
+	// Skills - ID навыков из словаря skills
+	Skills   skills.List `json:"skills"`
+	Location string      `json:"location,omitempty"`
+	// Experience - требуемый опыт текстом; ExperienceRange - он же в годах для
+	// фильтров, Seniority - уровень из формы или из названия
+	Experience      string            `json:"experience,omitempty"`
+	ExperienceRange *experience.Range `json:"experience_range,omitempty"`
+	Seniority       experience.Level  `json:"seniority,omitempty"`
+	JobType         string            `json:"job_type,omitempty"`
+	Telegram        string            `json:"telegram,omitempty"`
+
+	// Moderation - объявление скрыто модератором; причину видит владелец в /myjobs
+	Moderation *moderation.State `json:"moderation,omitempty"`
 }
 
-var db string = "job.json"
+// jobJSON - вакансия в ответах: ее поля и skill_names - названия навыков для
+// показа (skills.Name), чтобы страницам не переводить ID самим
+type jobJSON struct {
+	plainJob
+	SkillNames []string `json:"skill_names"`
+}
 
-func LoadJobs() ([]Job, error) {
-	data, err := os.ReadFile(db)
-	if err != nil {
-		if os.IsNotExist(err) {
-			// Если файл не существует, возвращаем пустой список
-			return []Job{}, nil
-		}
-		return nil, fmt.Errorf("ошибка чтения файла %s: %w", db, err)
-	}
+// plainJob - Job без MarshalJSON
+type plainJob Job
 
-	// Если файл пустой
-	if len(data) == 0 {
-		return []Job{}, nil
-	}
+func (j Job) view() jobJSON {
+	return jobJSON{plainJob(j), j.Skills.Names()}
+}
 
-	var jobs []Job
-	err = json.Unmarshal(data, &jobs)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка разбора JSON из файла %s: %w", db, err)
-	}
-	return jobs, nil
+func (j Job) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.view())
 }
 
-func SaveJobs(jobs []Job) error {
-	data, err := json.MarshalIndent(jobs, "", "  ")
-	if err != nil {
-		return fmt.Errorf("ошибка сериализации JSON: %w", err)
+// Event - что случилось с вакансией; передается хукам OnChange
+type Event string
+
+const (
+	EventCreated Event = "created"
+	EventUpdated Event = "updated"
+	EventDeleted Event = "deleted"
+)
+
+// changeHooks вызываются после создания, правки и удаления вакансии (вебхуки).
+// job не знает, кто на них подписан, они подключаются через OnChange.
+var changeHooks []func(e Event, j Job) error
+
+// OnChange подключает обработчик изменений вакансий
+func OnChange(hook func(e Event, j Job) error) {
+	changeHooks = append(changeHooks, hook)
+}
+
+// runChangeHooks вызывает хуки; их ошибки только пишутся в лог - вакансия уже сохранена
+func runChangeHooks(e Event, j Job) {
+	for _, hook := range changeHooks {
+		if err := hook(e, j); err != nil {
+			fmt.Printf("Ошибка обработчика вакансии %s (%s): %v\n", j.Id, e, err)
+		}
 	}
-	// os.WriteFile создает файл, если его нет, и перезаписывает, если есть
-	return os.WriteFile(db, data, 0644)
 }
+
 func UpdateHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	userIDCookie, err := r.Cookie("id_cookie")
-	if err != nil {
-		http.Error(w, "Unauthorized: Missing user ID cookie", http.StatusUnauthorized)
+	// Пользователь уже проверен auth.RequireAuth
+	user, ok := auth.MustUser(w, r)
+	if !ok {
 		return
 	}
-	currentUserID := userIDCookie.Value
+	currentUserID := user.ID
 
 	jobID := strings.TrimPrefix(r.URL.Path, "/job/")
 	if jobID == "" {
@@ -84,43 +112,63 @@ func UpdateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jobs, err := LoadJobs()
+	job, err := store.Get(jobID)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Load error", http.StatusInternalServerError)
 		return
 	}
 
-	updated := false
-
-	for i := range jobs {
-		if jobs[i].Id == jobID {
-			if jobs[i].UserID != currentUserID {
-				http.Error(w, "Forbidden: cannot edit other user's job", http.StatusForbidden)
-				return
-			}
-
-			jobs[i].Title = r.FormValue("title")
-			jobs[i].Company = r.FormValue("company")
-			jobs[i].School = r.FormValue("school")
-			jobs[i].Description = r.FormValue("description")
-			jobs[i].Salary = r.FormValue("salary")
-			jobs[i].Skills = r.FormValue("skills")
-			jobs[i].Telegram = r.FormValue("telegram")
+	// Чужие объявления может править только модератор
+	if job.UserID != currentUserID && !user.Can(auth.PermJobsModerate) {
+		http.Error(w, "Forbidden: cannot edit other user's job", http.StatusForbidden)
+		return
+	}
 
-			updated = true
-			break
+	salaryText, salaryRange, err := salary.Read(r.Form)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	jobSkills, err := skills.Read(r.Form)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// Опыт меняется, только если передан
+	if r.Form.Has("experience") || r.Form.Has("experience_min") || r.Form.Has("experience_max") {
+		job.Experience, job.ExperienceRange, err = experience.Read(r.Form)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
 	}
-
-	if !updated {
-		http.Error(w, "Job not found", http.StatusNotFound)
+	// Уровень - из формы или нового названия; не найден - остается прежним
+	seniority, err := experience.ReadLevel(r.Form, r.FormValue("title"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if seniority != "" {
+		job.Seniority = seniority
+	}
 
-	if err := SaveJobs(jobs); err != nil {
+	job.Title = r.FormValue("title")
+	job.Company = r.FormValue("company")
+	job.School = r.FormValue("school")
+	job.Description = r.FormValue("description")
+	job.Salary, job.SalaryRange = salaryText, salaryRange
+	job.Skills = jobSkills
+	job.Telegram = r.FormValue("telegram")
+
+	if err := store.Update(job); err != nil {
 		http.Error(w, "Save error", http.StatusInternalServerError)
 		return
 	}
+	runChangeHooks(EventUpdated, job)
 
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Updated"))
@@ -132,22 +180,14 @@ func CreateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 1. Получаем ID пользователя из куки
-	userIDCookie, err := r.Cookie("id_cookie")
-	if err != nil {
-		http.Error(w, "Unauthorized: Missing user ID cookie", http.StatusUnauthorized)
+	// Пользователь уже проверен auth.RequireAuth
+	user, ok := auth.MustUser(w, r)
+	if !ok {
 		return
 	}
-	currentUserID := userIDCookie.Value
+	currentUserID := user.ID
 
-	// 2. Загружаем существующие объявления
-	jobs, err := LoadJobs()
-	if err != nil {
-		http.Error(w, "Error loading jobs: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	// 3. Собираем данные из формы
+	// 2. Собираем данные из формы
 	if err := r.ParseForm(); err != nil {
 		http.Error(w, "Error parsing form: "+err.Error(), http.StatusBadRequest)
 		return
@@ -159,33 +199,58 @@ func CreateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 4. Создаем новую объявления
+	// Зарплата - текстом или полями salary_min, salary_max, salary_currency, ...
+	salaryText, salaryRange, err := salary.Read(r.Form)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	jobSkills, err := skills.Read(r.Form)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// Опыт - текстом или полями experience_min, experience_max; уровень -
+	// полем seniority или по названию
+	experienceText, experienceRange, err := experience.Read(r.Form)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	seniority, err := experience.ReadLevel(r.Form, r.FormValue("title"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// 3. Создаем новую объявления
 	newJob := Job{
-		Id:          uuid.New().String(), // Генерируем новый UUID
-		UserID:      currentUserID,       // Привязываем к текущему пользователю
-		Title:       r.FormValue("title"),
-		Company:     r.FormValue("company"),
-		School:      r.FormValue("school"), // Убедитесь, что это поле есть во фронте, если оно нужно
-		Description: r.FormValue("description"),
-		Salary:      r.FormValue("salary"),
-		Skills:      r.FormValue("skills"),
-		Location:    r.FormValue("location"),
-		Experience:  r.FormValue("experience"),
-		JobType:     r.FormValue("job_type"),
-		Telegram:    r.FormValue("telegram"),
-	}
-
-	// 5. Добавляем новую объявления в список
-	jobs = append(jobs, newJob)
-
-	// 6. Сохраняем обновленный список
-	err = SaveJobs(jobs)
+		Id:              uuid.New().String(), // Генерируем новый UUID
+		UserID:          currentUserID,       // Привязываем к текущему пользователю
+		Title:           r.FormValue("title"),
+		Company:         r.FormValue("company"),
+		School:          r.FormValue("school"), // Убедитесь, что это поле есть во фронте, если оно нужно
+		Description:     r.FormValue("description"),
+		Salary:          salaryText,
+		SalaryRange:     salaryRange,
+		Skills:          jobSkills,
+		Location:        r.FormValue("location"),
+		Experience:      experienceText,
+		ExperienceRange: experienceRange,
+		Seniority:       seniority,
+		JobType:         r.FormValue("job_type"),
+		Telegram:        r.FormValue("telegram"),
+	}
+
+	// 4. Сохраняем объявление в хранилище
+	err = store.Create(newJob)
 	if err != nil {
 		http.Error(w, "Error saving jobs: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	runChangeHooks(EventCreated, newJob)
 
-	// 7. Успешный ответ
+	// 5. Успешный ответ
 	w.WriteHeader(http.StatusCreated)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(newJob)
@@ -204,25 +269,19 @@ func OpenHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jobs, err := LoadJobs()
-	if err != nil {
-		http.Error(w, "Error loading jobs: "+err.Error(), http.StatusInternalServerError)
-		return
+	// Ищем объявления по JobID; скрытые модератором для всех как удаленные
+	foundJob, err := store.Get(jobID)
+	if err == nil && foundJob.Moderation != nil {
+		err = ErrNotFound
 	}
-
-	// Ищем объявления по JobID
-	var foundJob *Job
-	for _, job := range jobs {
-		if jobID == job.Id {
-			foundJob = &job
-			break
-		}
-	}
-
-	if foundJob == nil {
+	if errors.Is(err, ErrNotFound) {
 		http.Error(w, "Job not found", http.StatusNotFound)
 		return
 	}
+	if err != nil {
+		http.Error(w, "Error loading jobs: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	// ЭТО ИСПРАВЛЯЕТ ПРОБЛЕМУ "НЕЛЬЗЯ РАЗВЕРНУТЬ"
 	w.Header().Set("Content-Type", "application/json")
@@ -237,14 +296,14 @@ func GetAllHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jobs, err := LoadJobs()
+	jobs, err := listVisible()
 	if err != nil {
 		http.Error(w, "Error loading jobs: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	// Просто возвращаем весь список объявления
+	// Возвращаем весь список объявлений, кроме скрытых модератором
 	json.NewEncoder(w).Encode(jobs)
 }
 
@@ -260,49 +319,30 @@ func DeleteHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userIDCookie, err := r.Cookie("id_cookie")
-	if err != nil {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	// Пользователь уже проверен auth.RequireAuth
+	user, ok := auth.MustUser(w, r)
+	if !ok {
 		return
 	}
-	currentUserID := userIDCookie.Value
+	currentUserID := user.ID
 
-	jobs, err := LoadJobs()
+	job, err := store.Get(jobID)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Error loading jobs: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	updatedJobs := []Job{}
-	found := false
-	unauthorized := false
-
-	for _, job := range jobs {
-		if job.Id == jobID {
-			// Проверка прав
-			if job.UserID == currentUserID {
-				found = true
-				// Не добавляем в updatedJobs (удаляем)
-			} else {
-				unauthorized = true
-				updatedJobs = append(updatedJobs, job) // Нельзя удалять чужую
-			}
-		} else {
-			updatedJobs = append(updatedJobs, job) // Оставляем
-		}
-	}
-
-	if unauthorized {
+	// Проверка прав: нельзя удалять чужую
+	if job.UserID != currentUserID && !user.Can(auth.PermJobsModerate) {
 		http.Error(w, "Forbidden: You can only delete your own jobs", http.StatusForbidden)
 		return
 	}
 
-	if !found {
-		http.Error(w, "Job not found", http.StatusNotFound)
-		return
-	}
-
-	err = SaveJobs(updatedJobs)
+	err = deleteJob(job)
 	if err != nil {
 		http.Error(w, "Error saving jobs: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -310,33 +350,48 @@ func DeleteHandler(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// deleteJob удаляет объявление и сообщает об этом хукам; общий путь для
+// владельца и модератора
+func deleteJob(j Job) error {
+	if err := store.Delete(j.Id); err != nil {
+		return err
+	}
+	runChangeHooks(EventDeleted, j)
+	return nil
+}
 func MyjobHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	userIDCookie, err := r.Cookie("id_cookie")
-	if err != nil {
-		http.Error(w, "Unauthorized: Missing user ID cookie", http.StatusUnauthorized)
+	// Пользователь уже проверен auth.RequireAuth
+	user, ok := auth.MustUser(w, r)
+	if !ok {
 		return
 	}
-	currentUserID := userIDCookie.Value
+	currentUserID := user.ID
 
-	jobs, err := LoadJobs()
+	// ИСПРАВЛЕНИЕ: Ищем ВСЕ объявления, созданные текущим пользователем
+	userJobs, err := store.ListByUser(currentUserID)
 	if err != nil {
 		http.Error(w, "Error loading jobs: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// ИСПРАВЛЕНИЕ: Ищем ВСЕ объявления, созданные текущим пользователем
-	var userJobs []Job
-	for _, job := range jobs {
-		if currentUserID == job.UserID { // Ищем по UserID
-			userJobs = append(userJobs, job)
-		}
-	}
-
 	// ИСПРАВЛЕНИЕ: Возвращаем массив (даже если он пустой [])
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(userJobs)
 }
+
+// GetJobByID - объявление для других пакетов; nil, если его нет или оно скрыто модератором
+func GetJobByID(id string) (*Job, error) {
+	j, err := store.Get(id)
+	if errors.Is(err, ErrNotFound) || (err == nil && j.Moderation != nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
@@ -0,0 +1,219 @@
+package job
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"talant/auth"
+	"talant/auth/authtest"
+	"talant/skills"
+	"testing"
+)
+
+var (
+	owner    = &authtest.Owner
+	stranger = &authtest.Stranger
+	admin    = &authtest.Admin
+	serve    = authtest.Serve
+)
+
+// useStore подменяет хранилище на время теста
+func useStore(t *testing.T, jobs ...Job) *MemStore {
+	t.Helper()
+	prevStore, prevIndex := store, index
+	s := NewMemStore(jobs...)
+	SetStore(s)
+	t.Cleanup(func() { store, index = prevStore, prevIndex })
+	return s
+}
+
+func TestCreateHandler(t *testing.T) {
+	s := useStore(t)
+
+	form := url.Values{"title": {"Go-разработчик"}, "description": {"Пишем сервисы"}}
+	w := serve(CreateHandler, http.MethodPost, "/create", form, owner)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body)
+	}
+	var created Job
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	if created.UserID != owner.ID || created.Title != "Go-разработчик" {
+		t.Errorf("created = %+v", created)
+	}
+	if stored, err := s.Get(created.Id); err != nil || stored.Title != created.Title {
+		t.Errorf("stored = %+v, %v", stored, err)
+	}
+
+	if w := serve(CreateHandler, http.MethodPost, "/create", url.Values{"title": {"Без описания"}}, owner); w.Code != http.StatusBadRequest {
+		t.Errorf("create without description: %d, want 400", w.Code)
+	}
+	if w := serve(CreateHandler, http.MethodPost, "/create", form, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("create without user: %d, want 401", w.Code)
+	}
+}
+
+func TestCreateHandlerSkills(t *testing.T) {
+	s := useStore(t)
+
+	form := url.Values{"title": {"Go"}, "description": {"Сервисы"}, "skills": {"golang, Postgres"}}
+	w := serve(CreateHandler, http.MethodPost, "/create", form, owner)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body)
+	}
+	// Навыки хранятся ID словаря, а в ответе есть и их названия
+	var created struct {
+		Id         string   `json:"id"`
+		SkillNames []string `json:"skill_names"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Go", "PostgreSQL"}; !slices.Equal(created.SkillNames, want) {
+		t.Errorf("skill_names = %v, want %v", created.SkillNames, want)
+	}
+	if j, _ := s.Get(created.Id); !slices.Equal(j.Skills, skills.List{"go", "postgresql"}) {
+		t.Errorf("stored skills = %v", j.Skills)
+	}
+}
+
+func TestOpenAndMyjobHandlers(t *testing.T) {
+	useStore(t,
+		Job{Id: "1", UserID: owner.ID, Title: "Первая"},
+		Job{Id: "2", UserID: stranger.ID, Title: "Чужая"},
+	)
+
+	w := serve(OpenHandler, http.MethodGet, "/job/1", nil, nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Первая") {
+		t.Errorf("open: %d %s", w.Code, w.Body)
+	}
+	if w := serve(OpenHandler, http.MethodGet, "/job/missing", nil, nil); w.Code != http.StatusNotFound {
+		t.Errorf("open missing: %d, want 404", w.Code)
+	}
+
+	w = serve(MyjobHandler, http.MethodGet, "/myjobs", nil, owner)
+	var mine []Job
+	if err := json.NewDecoder(w.Body).Decode(&mine); err != nil {
+		t.Fatal(err)
+	}
+	if len(mine) != 1 || mine[0].Id != "1" {
+		t.Errorf("myjobs = %+v, want only job 1", mine)
+	}
+}
+
+func TestUpdateHandlerOwnership(t *testing.T) {
+	s := useStore(t, Job{Id: "1", UserID: owner.ID, Title: "Старое"})
+	form := url.Values{"title": {"Новое"}, "description": {"Описание"}}
+
+	if w := serve(UpdateHandler, http.MethodPut, "/job/1", form, stranger); w.Code != http.StatusForbidden {
+		t.Errorf("update by stranger: %d, want 403", w.Code)
+	}
+	if j, _ := s.Get("1"); j.Title != "Старое" {
+		t.Errorf("stranger changed the job: %+v", j)
+	}
+	if w := serve(UpdateHandler, http.MethodPut, "/job/missing", form, owner); w.Code != http.StatusNotFound {
+		t.Errorf("update missing: %d, want 404", w.Code)
+	}
+
+	if w := serve(UpdateHandler, http.MethodPut, "/job/1", form, owner); w.Code != http.StatusOK {
+		t.Fatalf("update by owner: %d %s", w.Code, w.Body)
+	}
+	if j, _ := s.Get("1"); j.Title != "Новое" || j.UserID != owner.ID {
+		t.Errorf("after update: %+v", j)
+	}
+}
+
+func TestDeleteHandlerOwnership(t *testing.T) {
+	s := useStore(t, Job{Id: "1", UserID: owner.ID})
+
+	if w := serve(DeleteHandler, http.MethodDelete, "/job/1", nil, stranger); w.Code != http.StatusForbidden {
+		t.Errorf("delete by stranger: %d, want 403", w.Code)
+	}
+	if _, err := s.Get("1"); err != nil {
+		t.Errorf("stranger deleted the job: %v", err)
+	}
+
+	if w := serve(DeleteHandler, http.MethodDelete, "/job/1", nil, owner); w.Code != http.StatusNoContent {
+		t.Errorf("delete by owner: %d %s", w.Code, w.Body)
+	}
+	if _, err := s.Get("1"); err != ErrNotFound {
+		t.Errorf("after delete: %v, want ErrNotFound", err)
+	}
+	if w := serve(DeleteHandler, http.MethodDelete, "/job/1", nil, owner); w.Code != http.StatusNotFound {
+		t.Errorf("delete again: %d, want 404", w.Code)
+	}
+}
+
+func TestModeratorEditsAndDeletesOthersJobs(t *testing.T) {
+	s := useStore(t, Job{Id: "1", UserID: owner.ID, Title: "Старое"})
+
+	form := url.Values{"title": {"Поправил модератор"}, "description": {"Описание"}}
+	if w := serve(UpdateHandler, http.MethodPut, "/job/1", form, admin); w.Code != http.StatusOK {
+		t.Fatalf("update by moderator: %d %s", w.Code, w.Body)
+	}
+	if j, _ := s.Get("1"); j.Title != "Поправил модератор" || j.UserID != owner.ID {
+		t.Errorf("after moderator update: %+v", j)
+	}
+	if w := serve(DeleteHandler, http.MethodDelete, "/job/1", nil, admin); w.Code != http.StatusNoContent {
+		t.Errorf("delete by moderator: %d %s", w.Code, w.Body)
+	}
+	if _, err := s.Get("1"); err != ErrNotFound {
+		t.Errorf("after moderator delete: %v, want ErrNotFound", err)
+	}
+
+	// Роль без права модерации чужое не трогает
+	recruiter := *stranger
+	recruiter.Roles = []auth.Role{auth.RoleRecruiter}
+	s.Create(Job{Id: "2", UserID: owner.ID})
+	if w := serve(DeleteHandler, http.MethodDelete, "/job/2", nil, &recruiter); w.Code != http.StatusForbidden {
+		t.Errorf("delete by recruiter: %d, want 403", w.Code)
+	}
+}
+
+// recordEvents подменяет хуки изменений на время теста и собирает их события
+func recordEvents(t *testing.T) *[]Event {
+	t.Helper()
+	prev := changeHooks
+	var events []Event
+	changeHooks = nil
+	OnChange(func(e Event, j Job) error {
+		events = append(events, e)
+		return nil
+	})
+	t.Cleanup(func() { changeHooks = prev })
+	return &events
+}
+
+func TestChangeHooks(t *testing.T) {
+	useStore(t, Job{Id: "2", UserID: stranger.ID})
+	events := recordEvents(t)
+
+	w := serve(CreateHandler, http.MethodPost, "/create", url.Values{"title": {"Go"}, "description": {"Сервисы"}}, owner)
+	var created Job
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	form := url.Values{"title": {"Go-разработчик"}, "description": {"Сервисы"}}
+	serve(UpdateHandler, http.MethodPut, "/job/"+created.Id, form, owner)
+	// Отказ в доступе ничего не меняет и хуки не вызывает
+	serve(DeleteHandler, http.MethodDelete, "/job/"+created.Id, nil, stranger)
+	serve(DeleteHandler, http.MethodDelete, "/job/"+created.Id, nil, owner)
+
+	// Удаление модератором идет тем же путем, что и владельцем
+	r := authtest.Request(http.MethodDelete, "/admin/jobs/2", nil, admin)
+	r.SetPathValue("id", "2")
+	pw := httptest.NewRecorder()
+	PurgeHandler(pw, r)
+	if pw.Code != http.StatusNoContent {
+		t.Fatalf("purge: %d %s", pw.Code, pw.Body)
+	}
+
+	want := []Event{EventCreated, EventUpdated, EventDeleted, EventDeleted}
+	if !slices.Equal(*events, want) {
+		t.Errorf("events = %v, want %v", *events, want)
+	}
+}
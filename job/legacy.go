@@ -0,0 +1,50 @@
+package job
+
+import (
+	"talant/experience"
+	"talant/salary"
+)
+
+// parseLegacy дополняет объявление разобранными зарплатой, опытом и уровнем,
+// если их еще нет; возвращает, изменилось ли оно
+func parseLegacy(j *Job) bool {
+	changed := false
+	if j.SalaryRange == nil && j.Salary != "" {
+		if s, ok := salary.Parse(j.Salary); ok {
+			j.SalaryRange, changed = s, true
+		}
+	}
+	if j.ExperienceRange == nil && j.Experience != "" {
+		if r, ok := experience.Parse(j.Experience); ok {
+			j.ExperienceRange, changed = r, true
+		}
+	}
+	if j.Seniority == "" {
+		if level, ok := experience.DetectLevel(j.Title); ok {
+			j.Seniority, changed = level, true
+		}
+	}
+	return changed
+}
+
+// ParseLegacy разбирает текстовые зарплату и опыт у объявлений, созданных до
+// появления SalaryRange, ExperienceRange и Seniority, и возвращает, сколько
+// объявлений дополнено. Хуки изменений не вызываются: для владельца
+// объявление не меняется.
+func ParseLegacy() (int, error) {
+	jobs, err := store.List()
+	if err != nil {
+		return 0, err
+	}
+	parsed := 0
+	for _, j := range jobs {
+		if !parseLegacy(&j) {
+			continue
+		}
+		if err := store.Update(j); err != nil {
+			return parsed, err
+		}
+		parsed++
+	}
+	return parsed, nil
+}
@@ -0,0 +1,303 @@
+package job
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"talant/experience"
+	"talant/fulltext"
+	"talant/salary"
+	"talant/skills"
+)
+
+// FilterParams - параметры фильтра поиска объявлений (GET /api/jobs/search)
+var FilterParams = []string{"q", "company", "location", "skills", "skills_mode", "remote", "job_type",
+	"experience", "seniority", "salary_min", "salary_max", "salary_currency"}
+
+// Filter - условия поиска объявлений; пустое условие ничего не отсеивает.
+// Строки сравниваются без учета регистра, по вхождению.
+type Filter struct {
+	// Q - слова в названии, компании, навыках или описании; ищутся по
+	// поисковому индексу с учетом словоформ, нужны все
+	Q        string
+	Company  string
+	Location string
+	Skills   skills.List // ID навыков, сравниваются целиком
+	// AnySkill - достаточно одного навыка из Skills (skills_mode=any); по умолчанию нужны все
+	AnySkill bool
+	// Remote - только удаленные (true) или только не удаленные (false) объявления
+	Remote  *bool
+	JobType string // точное значение: full, part, remote, internship
+	// Experience - диапазон лет, с которым должен пересекаться требуемый опыт;
+	// Seniority - подходящие уровни. Объявления без них при этом не подходят.
+	Experience *experience.Range
+	Seniority  []experience.Level
+	// SalaryMin и SalaryMax - месячный диапазон в валюте SalaryCurrency (по
+	// умолчанию базовой), с которым должна пересекаться зарплата; объявления без
+	// SalaryRange при этом не подходят
+	SalaryMin      int
+	SalaryMax      int
+	SalaryCurrency string
+}
+
+// FilterFromQuery собирает фильтр из параметров запроса
+func FilterFromQuery(query url.Values) (Filter, error) {
+	f := Filter{
+		Q:        strings.TrimSpace(query.Get("q")),
+		Company:  strings.TrimSpace(query.Get("company")),
+		Location: strings.TrimSpace(query.Get("location")),
+		JobType:  strings.TrimSpace(query.Get("job_type")),
+	}
+	var err error
+	if f.Experience, err = experience.ParseQuery(query.Get("experience")); err != nil {
+		return f, err
+	}
+	if f.Seniority, err = experience.ParseLevels(query.Get("seniority")); err != nil {
+		return f, err
+	}
+	f.Skills = skills.Parse(query.Get("skills"))
+	switch query.Get("skills_mode") {
+	case "", "all":
+	case "any":
+		f.AnySkill = true
+	default:
+		return f, errors.New("Invalid skills_mode: must be all or any")
+	}
+	if v := query.Get("remote"); v != "" {
+		remote, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, errors.New("Invalid remote")
+		}
+		f.Remote = &remote
+	}
+	for param, dst := range map[string]*int{"salary_min": &f.SalaryMin, "salary_max": &f.SalaryMax} {
+		if v := query.Get(param); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return f, fmt.Errorf("Invalid %s", param)
+			}
+			*dst = n
+		}
+	}
+	if f.SalaryMax > 0 && f.SalaryMin > f.SalaryMax {
+		return f, errors.New("salary_min is greater than salary_max")
+	}
+	f.SalaryCurrency = strings.ToUpper(query.Get("salary_currency"))
+	if f.SalaryCurrency == "" {
+		f.SalaryCurrency = salary.Base()
+	}
+	if !salary.Known(f.SalaryCurrency) {
+		return f, fmt.Errorf("Unknown currency: %s", f.SalaryCurrency)
+	}
+	return f, nil
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// IsRemote - удаленная работа: такой тип занятости или такое местоположение
+func (j Job) IsRemote() bool {
+	if j.JobType == "remote" {
+		return true
+	}
+	location := strings.ToLower(j.Location)
+	for _, word := range []string{"удален", "удалён", "дистанц", "remote"} {
+		if strings.Contains(location, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSkills - есть ли у объявления все (или, с AnySkill, хотя бы один) навыки
+func (f Filter) matchSkills(j Job) bool {
+	if len(f.Skills) == 0 {
+		return true
+	}
+	for _, skill := range f.Skills {
+		found := j.Skills.Has(skill)
+		if found && f.AnySkill {
+			return true
+		}
+		if !found && !f.AnySkill {
+			return false
+		}
+	}
+	return !f.AnySkill
+}
+
+// Match - подходит ли объявление под фильтр, кроме Q: текст проверяет Search по индексу
+func (f Filter) Match(j Job) bool {
+	if f.Company != "" && !containsFold(j.Company, f.Company) {
+		return false
+	}
+	if f.Location != "" && !containsFold(j.Location, f.Location) {
+		return false
+	}
+	if f.Remote != nil && j.IsRemote() != *f.Remote {
+		return false
+	}
+	if f.JobType != "" && !strings.EqualFold(j.JobType, f.JobType) {
+		return false
+	}
+	if f.Experience != nil && (j.ExperienceRange == nil || !j.ExperienceRange.Overlaps(*f.Experience)) {
+		return false
+	}
+	if len(f.Seniority) > 0 && !slices.Contains(f.Seniority, j.Seniority) {
+		return false
+	}
+	if f.SalaryMin > 0 || f.SalaryMax > 0 {
+		if j.SalaryRange == nil ||
+			!j.SalaryRange.Overlaps(float64(f.SalaryMin), float64(f.SalaryMax), f.SalaryCurrency) {
+			return false
+		}
+	}
+	return f.matchSkills(j)
+}
+
+// search - видимые объявления, подходящие под фильтр, в порядке создания, и
+// оценки релевантности Q (без Q - nil)
+func search(f Filter) ([]Job, fulltext.Ranking, error) {
+	jobs, err := listVisible()
+	if err != nil {
+		return nil, nil, err
+	}
+	ranking, err := index.Search(f.Q)
+	if err != nil {
+		return nil, nil, err
+	}
+	found := []Job{}
+	for _, j := range jobs {
+		if ranking.Has(j.Id) && f.Match(j) {
+			found = append(found, j)
+		}
+	}
+	return found, ranking, nil
+}
+
+// Search - видимые объявления, подходящие под фильтр: с Q - самые релевантные
+// первыми, без Q - в порядке создания
+func Search(f Filter) ([]Job, error) {
+	found, ranking, err := search(f)
+	if err != nil {
+		return nil, err
+	}
+	index.Sort(found, ranking)
+	return found, nil
+}
+
+// salaryKey - зарплата для сортировки: нижняя граница в месяц в базовой валюте
+func salaryKey(j Job) (float64, bool) {
+	if j.SalaryRange == nil {
+		return 0, false
+	}
+	min, _, ok := j.SalaryRange.Monthly(salary.Base())
+	return min, ok
+}
+
+// sorts - порядок результатов поиска; список из хранилища идет в порядке создания
+var sorts = map[string]func(jobs []Job){
+	"newest": slices.Reverse[[]Job],
+	"oldest": func([]Job) {},
+	"title": func(jobs []Job) {
+		slices.SortStableFunc(jobs, func(a, b Job) int {
+			return strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+		})
+	},
+	// Объявления без зарплаты - в конце при любом направлении
+	"salary_asc":  func(jobs []Job) { sortBySalary(jobs, 1) },
+	"salary_desc": func(jobs []Job) { sortBySalary(jobs, -1) },
+}
+
+func sortBySalary(jobs []Job, dir int) {
+	slices.SortStableFunc(jobs, func(a, b Job) int {
+		sa, okA := salaryKey(a)
+		sb, okB := salaryKey(b)
+		switch {
+		case okA != okB:
+			if okA {
+				return -1
+			}
+			return 1
+		case !okA:
+			return 0
+		}
+		return dir * cmp.Compare(sa, sb)
+	})
+}
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// SearchHandler ищет объявления (GET /api/jobs/search). Фильтры - FilterParams;
+// sort: relevance (по умолчанию с q), newest (по умолчанию без q), oldest,
+// title, salary_asc, salary_desc;
+// страница - limit (до 100) и offset. count - сколько найдено всего.
+func SearchHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	f, err := FilterFromQuery(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sortName := query.Get("sort")
+	switch {
+	case sortName == "" && f.Q != "":
+		sortName = "relevance"
+	case sortName == "", sortName == "relevance" && f.Q == "":
+		// Без q релевантности нет
+		sortName = "newest"
+	}
+	sortJobs, ok := sorts[sortName]
+	if !ok && sortName != "relevance" {
+		http.Error(w, "Invalid sort: must be relevance, newest, oldest, title, salary_asc or salary_desc", http.StatusBadRequest)
+		return
+	}
+	limit, offset := defaultPageSize, 0
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxPageSize)
+	}
+	if v := query.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "Invalid offset", http.StatusBadRequest)
+			return
+		}
+		offset = n
+	}
+
+	found, ranking, err := search(f)
+	if err != nil {
+		http.Error(w, "Error loading jobs", http.StatusInternalServerError)
+		return
+	}
+	if sortName == "relevance" {
+		index.Sort(found, ranking)
+	} else {
+		sortJobs(found)
+	}
+	start := min(offset, len(found))
+	page := found[start:min(start+limit, len(found))]
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Count   int   `json:"count"`
+		Limit   int   `json:"limit"`
+		Offset  int   `json:"offset"`
+		Results []Job `json:"results"`
+	}{len(found), limit, offset, page})
+}
@@ -0,0 +1,236 @@
+package job
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"talant/jsonfile"
+	"talant/memstore"
+)
+
+// ErrNotFound возвращается хранилищем, если объявление не найдено
+var ErrNotFound = errors.New("job not found")
+
+// Store - хранилище объявлений. Обработчики работают только через этот интерфейс,
+// поэтому JSON-файл можно заменить другой реализацией (БД, in-memory для тестов).
+type Store interface {
+	List() ([]Job, error)
+	Get(id string) (Job, error)
+	ListByUser(userID string) ([]Job, error)
+	Create(j Job) error
+	Update(j Job) error
+	Delete(id string) error
+}
+
+// index - полнотекстовый индекс записей store для поиска по q
+var store, index = withIndex(NewJSONStore("job.json"))
+
+// SetStore подменяет хранилище, с которым работают обработчики
+func SetStore(s Store) {
+	store, index = withIndex(s)
+}
+
+// JSONStore хранит все объявления одним массивом в JSON-файле
+type JSONStore struct {
+	file *jsonfile.File[Job]
+}
+
+func NewJSONStore(path string) *JSONStore {
+	return &JSONStore{file: jsonfile.New[Job](path)}
+}
+
+func (s *JSONStore) List() ([]Job, error) {
+	return s.file.Load()
+}
+
+func (s *JSONStore) Get(id string) (Job, error) {
+	jobs, err := s.file.Load()
+	if err != nil {
+		return Job{}, err
+	}
+	for _, j := range jobs {
+		if j.Id == id {
+			return j, nil
+		}
+	}
+	return Job{}, ErrNotFound
+}
+
+func (s *JSONStore) ListByUser(userID string) ([]Job, error) {
+	jobs, err := s.file.Load()
+	if err != nil {
+		return nil, err
+	}
+	userJobs := []Job{}
+	for _, j := range jobs {
+		if j.UserID == userID {
+			userJobs = append(userJobs, j)
+		}
+	}
+	return userJobs, nil
+}
+
+func (s *JSONStore) Create(j Job) error {
+	return s.file.Update(func(jobs []Job) ([]Job, error) {
+		return append(jobs, j), nil
+	})
+}
+
+func (s *JSONStore) Update(j Job) error {
+	return s.file.Update(func(jobs []Job) ([]Job, error) {
+		for i := range jobs {
+			if jobs[i].Id == j.Id {
+				jobs[i] = j
+				return jobs, nil
+			}
+		}
+		return nil, ErrNotFound
+	})
+}
+
+func (s *JSONStore) Delete(id string) error {
+	return s.file.Update(func(jobs []Job) ([]Job, error) {
+		for i := range jobs {
+			if jobs[i].Id == id {
+				return append(jobs[:i], jobs[i+1:]...), nil
+			}
+		}
+		return nil, ErrNotFound
+	})
+}
+
+// CachedStore держит все объявления в памяти с индексом по пользователю.
+// Изменения пишутся в журнал и периодически сворачиваются в JSON-файл.
+type CachedStore struct {
+	m *memstore.Store[Job]
+}
+
+// NewCachedStore загружает объявления из path (и журнала path + ".journal")
+func NewCachedStore(path string) (*CachedStore, error) {
+	m, err := memstore.Open(memstore.Options[Job]{
+		Snapshot: path,
+		ID:       func(j Job) string { return j.Id },
+		Indexes: map[string]func(Job) string{
+			"user": func(j Job) string { return j.UserID },
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &CachedStore{m: m}, nil
+}
+
+func (s *CachedStore) Close() error {
+	return s.m.Close()
+}
+
+func (s *CachedStore) List() ([]Job, error) {
+	return s.m.List(), nil
+}
+
+func (s *CachedStore) Get(id string) (Job, error) {
+	j, ok := s.m.Get(id)
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	return j, nil
+}
+
+func (s *CachedStore) ListByUser(userID string) ([]Job, error) {
+	return s.m.Find("user", userID), nil
+}
+
+func (s *CachedStore) Create(j Job) error {
+	return s.m.Put(j, nil)
+}
+
+func (s *CachedStore) Update(j Job) error {
+	return s.m.Put(j, func(v memstore.View[Job]) error {
+		if _, ok := v.Get(j.Id); !ok {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (s *CachedStore) Delete(id string) error {
+	ok, err := s.m.Delete(id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// MemStore хранит объявления только в памяти процесса: для тестов обработчиков
+type MemStore struct {
+	mu   sync.Mutex
+	jobs []Job
+}
+
+// NewMemStore - хранилище с начальным набором объявлений
+func NewMemStore(jobs ...Job) *MemStore {
+	return &MemStore{jobs: slices.Clone(jobs)}
+}
+
+func (s *MemStore) List() ([]Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.jobs), nil
+}
+
+func (s *MemStore) Get(id string) (Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.jobs {
+		if j.Id == id {
+			return j, nil
+		}
+	}
+	return Job{}, ErrNotFound
+}
+
+func (s *MemStore) ListByUser(userID string) ([]Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	userJobs := []Job{}
+	for _, j := range s.jobs {
+		if j.UserID == userID {
+			userJobs = append(userJobs, j)
+		}
+	}
+	return userJobs, nil
+}
+
+func (s *MemStore) Create(j Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, j)
+	return nil
+}
+
+func (s *MemStore) Update(j Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.jobs {
+		if s.jobs[i].Id == j.Id {
+			s.jobs[i] = j
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (s *MemStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.jobs {
+		if s.jobs[i].Id == id {
+			s.jobs = slices.Delete(s.jobs, i, i+1)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
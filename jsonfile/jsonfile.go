@@ -0,0 +1,145 @@
+// Package jsonfile - JSON-файл с массивом записей, который безопасно читать и
+// переписывать из конкурентных обработчиков.
+//
+// Все операции над одним путем сериализуются общей блокировкой, а запись идет
+// во временный файл с fsync и атомарным rename, так что падение процесса
+// посреди записи не оставляет обрезанный файл.
+package jsonfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var (
+	locksMu sync.Mutex
+	locks   = map[string]*sync.RWMutex{}
+)
+
+// lockFor возвращает блокировку файла. Она общая для всех File с одним путем,
+// даже если они созданы независимо друг от друга.
+func lockFor(path string) *sync.RWMutex {
+	key, err := filepath.Abs(path)
+	if err != nil {
+		key = filepath.Clean(path)
+	}
+	locksMu.Lock()
+	defer locksMu.Unlock()
+	l, ok := locks[key]
+	if !ok {
+		l = &sync.RWMutex{}
+		locks[key] = l
+	}
+	return l
+}
+
+// File - JSON-массив значений T в одном файле
+type File[T any] struct {
+	path string
+	mu   *sync.RWMutex
+}
+
+func New[T any](path string) *File[T] {
+	return &File[T]{path: path, mu: lockFor(path)}
+}
+
+func (f *File[T]) Path() string {
+	return f.path
+}
+
+// Load читает весь файл. Отсутствующий или пустой файл - это пустой список.
+func (f *File[T]) Load() ([]T, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.read()
+}
+
+// Update выполняет цикл чтение-изменение-запись под блокировкой файла.
+// Если fn возвращает ошибку, файл не перезаписывается и ошибка возвращается как есть.
+func (f *File[T]) Update(fn func(list []T) ([]T, error)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	list, err := f.read()
+	if err != nil {
+		return err
+	}
+	list, err = fn(list)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации JSON: %w", err)
+	}
+	return WriteAtomic(f.path, data, 0644)
+}
+
+func (f *File[T]) read() ([]T, error) {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []T{}, nil
+		}
+		return nil, fmt.Errorf("ошибка чтения файла %s: %w", f.path, err)
+	}
+	if len(data) == 0 {
+		return []T{}, nil
+	}
+
+	var list []T
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("ошибка разбора JSON из файла %s: %w", f.path, err)
+	}
+	if list == nil {
+		list = []T{}
+	}
+	return list, nil
+}
+
+// WriteAtomic записывает данные во временный файл рядом с path, делает fsync
+// и переименовывает его поверх path. Читатели видят либо старое, либо новое
+// содержимое целиком.
+func WriteAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("ошибка создания временного файла: %w", err)
+	}
+	tmpName := tmp.Name()
+	// Если что-то пошло не так, не оставляем мусор рядом с основным файлом
+	defer func() {
+		if err != nil {
+			os.Remove(tmpName)
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("ошибка записи в %s: %w", tmpName, err)
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("ошибка fsync %s: %w", tmpName, err)
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmpName, perm); err != nil {
+		return err
+	}
+	if err = os.Rename(tmpName, path); err != nil {
+		return fmt.Errorf("ошибка переименования %s: %w", tmpName, err)
+	}
+
+	// fsync каталога фиксирует сам rename; на части систем это не поддерживается
+	if d, dirErr := os.Open(dir); dirErr == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
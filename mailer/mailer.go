@@ -0,0 +1,110 @@
+// Package mailer отправляет письма. SMTPMailer - для продакшена, FileMailer
+// складывает письма в каталог (или печатает в лог) для локальной разработки.
+package mailer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"talant/jsonfile"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Message - простое текстовое письмо
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer - способ доставки писем
+type Mailer interface {
+	Send(m Message) error
+}
+
+// ErrBadHeader - в адресе или теме есть перевод строки (попытка подменить заголовки)
+var ErrBadHeader = errors.New("mailer: invalid header value")
+
+// format собирает письмо в формате RFC 5322 с телом в UTF-8
+func format(from string, m Message) ([]byte, error) {
+	for _, v := range []string{from, m.To, m.Subject} {
+		if strings.ContainsAny(v, "\r\n") {
+			return nil, ErrBadHeader
+		}
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", m.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(m.Body, "\n", "\r\n"))
+	return b.Bytes(), nil
+}
+
+// SMTPMailer отправляет письма через SMTP-сервер (STARTTLS, если сервер его поддерживает)
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTP создает отправителя. Без username письма отправляются без авторизации.
+func NewSMTP(host string, port int, username, password, from string) *SMTPMailer {
+	s := &SMTPMailer{addr: net.JoinHostPort(host, strconv.Itoa(port)), from: from}
+	if username != "" {
+		s.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return s
+}
+
+func (s *SMTPMailer) Send(m Message) error {
+	data, err := format(s.from, m)
+	if err != nil {
+		return err
+	}
+	return smtp.SendMail(s.addr, s.auth, s.from, []string{m.To}, data)
+}
+
+// FileMailer сохраняет каждое письмо в отдельный .eml-файл каталога dir.
+// С пустым dir письма печатаются в stdout.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFile(dir, from string) *FileMailer {
+	return &FileMailer{dir: dir, from: from}
+}
+
+func (f *FileMailer) Send(m Message) error {
+	data, err := format(f.from, m)
+	if err != nil {
+		return err
+	}
+	if f.dir == "" {
+		fmt.Printf("Письмо:\n%s\n", data)
+		return nil
+	}
+	if err := os.MkdirAll(f.dir, 0755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405"), uuid.New().String())
+	path := filepath.Join(f.dir, name)
+	if err := jsonfile.WriteAtomic(path, data, 0600); err != nil {
+		return err
+	}
+	fmt.Printf("Письмо для %s сохранено в %s\n", m.To, path)
+	return nil
+}
@@ -2,45 +2,224 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"talant/ankety"
+	"talant/applications"
 	"talant/auth"
+	"talant/config"
+	"talant/events"
 	"talant/job"
+	"talant/mailer"
+	"talant/matching"
+	"talant/messages"
+	"talant/notifications"
+	"talant/salary"
+	"talant/searches"
+	"talant/skills"
+	"talant/sqlstore"
+	"talant/webhooks"
+	"time"
+	_ "time/tzdata" // часовые пояса не зависят от системы (в контейнере их может не быть)
 )
 
 func main() {
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatalf("Ошибка настроек: %v", err)
+	}
+
+	auth.Configure(auth.Settings{
+		JWTSecret:    cfg.JWTSecret,
+		TokenTTL:     cfg.TokenTTL,
+		RefreshTTL:   cfg.RefreshTTL,
+		PublicURL:    cfg.PublicURL,
+		Mailer:       newMailer(cfg.Mail),
+		CookieSecure: cfg.CookieSecure,
+		CORSOrigins:  cfg.CORSOrigins,
+	})
+	ankety.Configure(cfg.Data.Uploads, cfg.MaxUploadBytes)
+	loc, _ := time.LoadLocation(cfg.Timezone) // проверен в config.Validate
+	events.Configure(loc, cfg.PublicURL)
+	webhooks.Configure(cfg.WebhooksAllowPrivate)
+	salary.Configure(cfg.Currency.Base, cfg.Currency.Rates)
+
+	closers, err := openStorage(cfg)
+	if err != nil {
+		log.Fatalf("Ошибка открытия хранилища: %v", err)
+	}
+	// Текстовые зарплаты, сохраненные до появления SalaryRange, разбираются один раз
+	parseLegacy("объявлений", job.ParseLegacy)
+	parseLegacy("анкет", ankety.ParseLegacy)
+	// При бане автора скрываются его объявления и анкета
+	auth.OnBan(job.SetUserBan)
+	auth.OnBan(ankety.SetUserBan)
+	// Уведомления об откликах, сообщениях, скором начале мероприятий и новых
+	// результатах сохраненных поисков
+	applications.OnApply(notifications.Applied)
+	applications.OnStatusChange(notifications.StatusChanged)
+	messages.OnMessage(notifications.MessageSent)
+	events.OnReminder(notifications.EventReminder)
+	searches.OnMatch(notifications.SearchMatched)
+	// Вебхуки о вакансиях и анкетах
+	job.OnChange(webhooks.JobChanged)
+	ankety.OnChange(webhooks.AnketaChanged)
+	if err := auth.BootstrapAdmins(cfg.Admins); err != nil {
+		log.Fatalf("Ошибка назначения администраторов: %v", err)
+	}
+
 	mux := http.NewServeMux()
 
+	// public - маршрут доступен всем; private - только с валидным JWT,
+	// проверенный пользователь попадает в контекст запроса (auth.UserFromContext)
+	public := func(pattern string, h http.HandlerFunc) {
+		mux.HandleFunc(pattern, h)
+	}
+	private := func(pattern string, h http.HandlerFunc) {
+		mux.HandleFunc(pattern, auth.RequireAuth(h))
+	}
+	// permitted - как private, но еще требует право у ролей пользователя
+	permitted := func(pattern string, p auth.Permission, h http.HandlerFunc) {
+		mux.HandleFunc(pattern, auth.RequirePermission(p, h))
+	}
+
 	// Обработчики для вакансий (jobs)
-	mux.HandleFunc("GET /job/{id}", job.OpenHandler)
-	mux.HandleFunc("POST /createjob", job.CreateHandler)
-	mux.HandleFunc("GET /showjobs", job.GetAllHandler)
-	mux.HandleFunc("GET /myjobs", job.MyjobHandler)
-	mux.HandleFunc("PUT /job/{id}", job.UpdateHandler)
-	mux.HandleFunc("DELETE /job/{id}", job.DeleteHandler)
+	public("GET /job/{id}", job.OpenHandler)
+	permitted("POST /createjob", auth.PermJobsWrite, job.CreateHandler)
+	public("GET /showjobs", job.GetAllHandler)
+	public("GET /api/jobs/search", job.SearchHandler)
+	private("GET /myjobs", job.MyjobHandler)
+	private("PUT /job/{id}", job.UpdateHandler)
+	private("DELETE /job/{id}", job.DeleteHandler)
+
+	// Отклики на вакансии
+	permitted("POST /job/{id}/apply", auth.PermAnketyWrite, applications.ApplyHandler)
+	private("GET /job/{id}/applications", applications.JobApplicationsHandler)
+	private("GET /api/applications/my", applications.MyApplicationsHandler)
+	private("GET /api/applications/{id}", applications.GetHandler)
+	private("PUT /api/applications/{id}/status", applications.SetStatusHandler)
+	private("DELETE /api/applications/{id}", applications.WithdrawHandler)
+
+	// Подбор: кандидаты к вакансии и вакансии к своей анкете
+	private("GET /job/{id}/matches", matching.JobMatchesHandler)
+	private("GET /api/ankety/my/matches", matching.MyAnketaMatchesHandler)
+
+	// Личные сообщения и блокировки собеседников
+	private("POST /api/conversations", messages.StartHandler)
+	private("GET /api/conversations", messages.ListHandler)
+	private("GET /api/conversations/unread", messages.UnreadHandler)
+	private("GET /api/conversations/{id}/messages", messages.MessagesHandler)
+	private("POST /api/conversations/{id}/messages", messages.SendHandler)
+	private("POST /api/conversations/{id}/read", messages.ReadHandler)
+	private("GET /api/blocks", messages.BlocksHandler)
+	private("POST /api/blocks/{user_id}", messages.BlockHandler)
+	private("DELETE /api/blocks/{user_id}", messages.UnblockHandler)
+
+	// Уведомления: история, отметка прочитанными и поток Server-Sent Events
+	private("GET /api/notifications", notifications.ListHandler)
+	private("GET /api/notifications/unread", notifications.UnreadHandler)
+	private("GET /api/notifications/stream", notifications.StreamHandler)
+	private("POST /api/notifications/{id}/read", notifications.ReadHandler)
+	private("POST /api/notifications/read", notifications.ReadAllHandler)
+
+	// Вебхуки: подписка на события своих вакансий и анкет, журнал и тестовая доставка
+	private("POST /api/webhooks", webhooks.CreateHandler)
+	private("GET /api/webhooks", webhooks.ListHandler)
+	private("GET /api/webhooks/{id}", webhooks.GetHandler)
+	private("PUT /api/webhooks/{id}", webhooks.UpdateHandler)
+	private("DELETE /api/webhooks/{id}", webhooks.DeleteHandler)
+	private("GET /api/webhooks/{id}/deliveries", webhooks.DeliveriesHandler)
+	private("POST /api/webhooks/{id}/test", webhooks.TestHandler)
+
+	// Сохраненные поиски анкет и вакансий с оповещениями о новых совпадениях
+	private("POST /api/searches", searches.CreateHandler)
+	private("GET /api/searches", searches.ListHandler)
+	private("GET /api/searches/{id}", searches.GetHandler)
+	private("PUT /api/searches/{id}", searches.UpdateHandler)
+	private("DELETE /api/searches/{id}", searches.DeleteHandler)
+	private("GET /api/searches/{id}/results", searches.ResultsHandler)
 
 	// Обработчики аутентификации
-	mux.HandleFunc("POST /singin", auth.SingInHandler)
-	mux.HandleFunc("POST /login", auth.LoaginHandler)
-	mux.HandleFunc("GET /checkauth", auth.CheckAuthHandler)
-	mux.HandleFunc("POST /logout", auth.LogOutHandler)
+	public("POST /singin", auth.SingInHandler)
+	public("POST /login", auth.LoaginHandler)
+	public("GET /checkauth", auth.CheckAuthHandler)
+	public("POST /logout", auth.LogOutHandler)
+	public("POST /refresh", auth.RefreshHandler)
+
+	// Сессии пользователя: список устройств и выход с них
+	private("GET /sessions", auth.SessionsHandler)
+	private("DELETE /sessions/{id}", auth.RevokeSessionHandler)
+	private("DELETE /sessions", auth.RevokeAllSessionsHandler)
+
+	// Подтверждение почты и сброс пароля по ссылкам из писем
+	public("GET /verify", auth.VerifyEmailHandler)
+	private("POST /verify/resend", auth.ResendVerificationHandler)
+	public("POST /password/forgot", auth.ForgotPasswordHandler)
+	public("POST /password/reset", auth.ResetPasswordHandler)
 
 	// Основные обработчики анкет (ankety)
-	mux.HandleFunc("POST /api/ankety/create", ankety.CreateHandler)
-	mux.HandleFunc("PUT /api/ankety/update", ankety.UpdateAnketyHandler)
-	mux.HandleFunc("GET /api/ankety/show", ankety.ShowAnketyHandler)
-	mux.HandleFunc("GET /api/ankety/my", ankety.GetMyAnketaHandler)
-	mux.HandleFunc("DELETE /api/ankety/delete", ankety.DeleteAnketyHandler)
-	mux.HandleFunc("GET /api/ankety/search", ankety.SearchAnketyHandler)
-	mux.HandleFunc("GET /api/ankety/stats", ankety.GetStatsHandler)
-	mux.HandleFunc("GET /api/ankety/export", ankety.ExportCSVHandler)
-	mux.HandleFunc("GET /api/ankety/get", ankety.GetAnketaByIDHandler)
+	permitted("POST /api/ankety/create", auth.PermAnketyWrite, ankety.CreateHandler)
+	permitted("PUT /api/ankety/update", auth.PermAnketyWrite, ankety.UpdateAnketyHandler)
+	public("GET /api/ankety/show", ankety.ShowAnketyHandler)
+	private("GET /api/ankety/my", ankety.GetMyAnketaHandler)
+	private("DELETE /api/ankety/delete", ankety.DeleteAnketyHandler)
+	public("GET /api/ankety/search", ankety.SearchAnketyHandler)
+	public("GET /api/ankety/stats", ankety.GetStatsHandler)
+	public("GET /api/ankety/export", ankety.ExportCSVHandler)
+	public("GET /api/ankety/get", ankety.GetAnketaByIDHandler)
+
+	// Словарь навыков: подсказки при вводе
+	public("GET /api/skills", skills.AutocompleteHandler)
 
 	// Обработчики фотографий анкет (только один набор маршрутов)
-	mux.HandleFunc("POST /api/ankety/photo/upload", ankety.UploadPhotoHandler)
-	mux.HandleFunc("GET /api/ankety/photo/get", ankety.GetPhotoHandler)
-	mux.HandleFunc("DELETE /api/ankety/photo/delete", ankety.DeletePhotoHandler)
+	permitted("POST /api/ankety/photo/upload", auth.PermAnketyWrite, ankety.UploadPhotoHandler)
+	public("GET /api/ankety/photo/get", ankety.GetPhotoHandler)
+	private("DELETE /api/ankety/photo/delete", ankety.DeletePhotoHandler)
+
+	// Мероприятия
+	public("GET /api/events", events.ListHandler)
+	public("GET /api/events/feed.ics", events.FeedHandler)
+	public("GET /api/events/calendar/{token}/events.ics", events.UserFeedHandler)
+	private("GET /api/events/my", events.MyHandler)
+	private("GET /api/events/my/registrations", events.MyRegistrationsHandler)
+	private("GET /api/events/my/calendar", events.CalendarLinkHandler)
+	private("POST /api/events/my/calendar/reset", events.ResetCalendarLinkHandler)
+	public("GET /api/events/{id}", events.GetHandler)
+	public("GET /api/events/{id}/event.ics", events.EventICSHandler)
+	private("POST /api/events", events.CreateHandler)
+	private("PUT /api/events/{id}", events.UpdateHandler)
+	private("DELETE /api/events/{id}", events.DeleteHandler)
+	private("POST /api/events/{id}/register", events.RegisterHandler)
+	private("DELETE /api/events/{id}/register", events.CancelRegistrationHandler)
+	private("GET /api/events/{id}/registration", events.MyRegistrationHandler)
+	private("GET /api/events/{id}/attendees", events.AttendeesHandler)
+	private("GET /api/events/{id}/attendees.csv", events.ExportAttendeesHandler)
+	private("GET /api/events/{id}/ticket", events.TicketHandler)
+	private("GET /api/events/{id}/ticket.png", events.TicketQRHandler)
+	private("POST /api/events/{id}/checkin", events.CheckInHandler)
+	private("GET /api/events/{id}/stats", events.StatsHandler)
+
+	// Администрирование пользователей
+	permitted("GET /admin/users", auth.PermUsersManage, auth.ListUsersHandler)
+	permitted("PUT /admin/users/{id}/roles", auth.PermUsersManage, auth.SetRolesHandler)
+	permitted("POST /admin/users/{id}/ban", auth.PermUsersManage, auth.BanUserHandler)
+	permitted("POST /admin/users/{id}/unban", auth.PermUsersManage, auth.UnbanUserHandler)
+
+	// Модерация: скрыть, вернуть или окончательно удалить чужой контент
+	permitted("GET /admin/jobs", auth.PermJobsModerate, job.AdminListHandler)
+	permitted("POST /admin/jobs/{id}/hide", auth.PermJobsModerate, job.HideHandler)
+	permitted("POST /admin/jobs/{id}/restore", auth.PermJobsModerate, job.RestoreHandler)
+	permitted("DELETE /admin/jobs/{id}", auth.PermJobsModerate, job.PurgeHandler)
+	permitted("GET /admin/ankety", auth.PermAnketyModerate, ankety.AdminListHandler)
+	permitted("POST /admin/ankety/{id}/hide", auth.PermAnketyModerate, ankety.HideHandler)
+	permitted("POST /admin/ankety/{id}/restore", auth.PermAnketyModerate, ankety.RestoreHandler)
+	permitted("DELETE /admin/ankety/{id}", auth.PermAnketyModerate, ankety.PurgeHandler)
 
 	// Статические файлы фронтенда
 	fs := http.FileServer(http.Dir("./frontend"))
@@ -49,7 +228,166 @@ func main() {
 	// Оборачиваем роутер в CORS Middleware
 	handler := auth.CORSMiddleware(mux)
 
-	fmt.Println("🚀 Сервер запущен на порту :8080")
-	fmt.Println("🌐 Фронтенд доступен по адресу: http://localhost:8080")
-	http.ListenAndServe(":8080", handler)
+	server := &http.Server{Addr: cfg.Listen, Handler: handler}
+	// Потоки уведомлений сами не заканчиваются - закрываем их, иначе Shutdown ждал бы их до таймаута
+	server.RegisterOnShutdown(notifications.CloseStreams)
+
+	background, stopBackground := context.WithCancel(context.Background())
+	if cfg.EventReminder > 0 {
+		go events.RunReminders(background, cfg.EventReminder)
+	}
+	go webhooks.Run(background)
+	if cfg.SavedSearchInterval > 0 {
+		go searches.Run(background, cfg.SavedSearchInterval)
+	}
+	go func() {
+		fmt.Printf("🚀 Сервер запущен на %s (хранилище: %s)\n", cfg.Listen, cfg.Storage)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Ошибка сервера: %v", err)
+		}
+	}()
+
+	// Ждем сигнал остановки, даем обработчикам завершиться и закрываем хранилища,
+	// чтобы журналы in-memory хранилищ свернулись в JSON-файлы
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	<-stop
+	fmt.Println("Остановка сервера...")
+	stopBackground()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	server.Shutdown(ctx)
+	for _, c := range closers {
+		if err := c.Close(); err != nil {
+			fmt.Printf("Ошибка закрытия хранилища: %v\n", err)
+		}
+	}
+}
+
+// parseLegacy разбирает старые текстовые зарплаты и опыт и сообщает, сколько
+// записей дополнено
+func parseLegacy(what string, parse func() (int, error)) {
+	n, err := parse()
+	if err != nil {
+		log.Fatalf("Ошибка разбора старых %s: %v", what, err)
+	}
+	if n > 0 {
+		fmt.Printf("Дополнено старых %s: %d\n", what, n)
+	}
+}
+
+// newMailer создает отправителя писем по настройкам
+func newMailer(m config.Mail) mailer.Mailer {
+	if m.Driver == "smtp" {
+		return mailer.NewSMTP(m.Host, m.Port, m.Username, m.Password, m.From)
+	}
+	return mailer.NewFile(m.Dir, m.From)
+}
+
+// openStorage подменяет хранилища пакетов. json работает с файлами напрямую,
+// memory держит данные в памяти с журналом изменений, sqlite при первом
+// запуске переносит данные из JSON-файлов в базу.
+func openStorage(cfg config.Config) ([]io.Closer, error) {
+	paths := cfg.Data
+	switch cfg.Storage {
+	case "json":
+		auth.SetStore(auth.NewJSONStore(paths.Users))
+		job.SetStore(job.NewJSONStore(paths.Jobs))
+		ankety.SetStore(ankety.NewJSONStore(paths.Ankety))
+		events.SetStore(events.NewJSONStore(paths.Events))
+		events.SetRegistrationStore(events.NewJSONRegistrationStore(paths.Registrations))
+		applications.SetStore(applications.NewJSONStore(paths.Applications))
+		if err := os.MkdirAll(paths.Messages, 0755); err != nil {
+			return nil, err
+		}
+		messages.SetStore(messages.NewJSONStore(paths.Messages))
+		notifications.SetStore(notifications.NewJSONStore(paths.Notifications))
+		webhooks.SetStore(webhooks.NewJSONStore(paths.Webhooks))
+		searches.SetStore(searches.NewJSONStore(paths.Searches))
+		return nil, auth.SetSessionStore(auth.NewJSONSessionStore(paths.Sessions))
+	case "memory":
+		users, err := auth.NewCachedStore(paths.Users)
+		if err != nil {
+			return nil, err
+		}
+		jobs, err := job.NewCachedStore(paths.Jobs)
+		if err != nil {
+			return nil, err
+		}
+		anketyStore, err := ankety.NewCachedStore(paths.Ankety)
+		if err != nil {
+			return nil, err
+		}
+		sessions, err := auth.NewCachedSessionStore(paths.Sessions)
+		if err != nil {
+			return nil, err
+		}
+		eventStore, err := events.NewCachedStore(paths.Events)
+		if err != nil {
+			return nil, err
+		}
+		registrations, err := events.NewCachedRegistrationStore(paths.Registrations)
+		if err != nil {
+			return nil, err
+		}
+		applicationStore, err := applications.NewCachedStore(paths.Applications)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(paths.Messages, 0755); err != nil {
+			return nil, err
+		}
+		messageStore, err := messages.NewCachedStore(paths.Messages)
+		if err != nil {
+			return nil, err
+		}
+		notificationStore, err := notifications.NewCachedStore(paths.Notifications)
+		if err != nil {
+			return nil, err
+		}
+		webhookStore, err := webhooks.NewCachedStore(paths.Webhooks)
+		if err != nil {
+			return nil, err
+		}
+		searchStore, err := searches.NewCachedStore(paths.Searches)
+		if err != nil {
+			return nil, err
+		}
+		auth.SetStore(users)
+		job.SetStore(jobs)
+		ankety.SetStore(anketyStore)
+		events.SetStore(eventStore)
+		events.SetRegistrationStore(registrations)
+		applications.SetStore(applicationStore)
+		messages.SetStore(messageStore)
+		notifications.SetStore(notificationStore)
+		webhooks.SetStore(webhookStore)
+		searches.SetStore(searchStore)
+		closers := []io.Closer{users, jobs, anketyStore, sessions, eventStore, registrations, applicationStore,
+			messageStore, notificationStore, webhookStore, searchStore}
+		return closers, auth.SetSessionStore(sessions)
+	case "sqlite":
+		db, err := sqlstore.Open(sqlstore.Options{
+			Path:         paths.SQLite,
+			LegacyUsers:  paths.Users,
+			LegacyJobs:   paths.Jobs,
+			LegacyAnkety: paths.Ankety,
+		})
+		if err != nil {
+			return nil, err
+		}
+		auth.SetStore(db.Users())
+		job.SetStore(db.Jobs())
+		ankety.SetStore(db.Ankety())
+		events.SetStore(db.Events())
+		events.SetRegistrationStore(db.Registrations())
+		applications.SetStore(db.Applications())
+		messages.SetStore(db.Messages())
+		notifications.SetStore(db.Notifications())
+		webhooks.SetStore(db.Webhooks())
+		searches.SetStore(db.Searches())
+		return []io.Closer{db}, auth.SetSessionStore(db.Sessions())
+	}
+	return nil, fmt.Errorf("неизвестное хранилище: %s", cfg.Storage)
 }
@@ -0,0 +1,167 @@
+package matching
+
+import (
+	"cmp"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"talant/ankety"
+	"talant/auth"
+	"talant/job"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+// AnketaMatch - анкета, подобранная к вакансии
+type AnketaMatch struct {
+	Anketa ankety.Ankety `json:"anketa"`
+	Result
+}
+
+// JobMatch - вакансия, подобранная к анкете
+type JobMatch struct {
+	Job job.Job `json:"job"`
+	Result
+}
+
+// readPage читает limit (по умолчанию 20, до 100) и min_score (от 0 до 100)
+func readPage(query url.Values) (limit, minScore int, ok bool) {
+	limit = defaultLimit
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return 0, 0, false
+		}
+		limit = min(n, maxLimit)
+	}
+	if v := query.Get("min_score"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > 100 {
+			return 0, 0, false
+		}
+		minScore = n
+	}
+	return limit, minScore, true
+}
+
+// best оставляет до limit лучших с баллом не ниже minScore; при равном балле
+// выше тот, у кого больше совпавших навыков. Порядок равных сохраняется.
+func best[T any](list []T, result func(T) Result, limit, minScore int) []T {
+	list = slices.DeleteFunc(list, func(m T) bool { return result(m).Score < minScore })
+	slices.SortStableFunc(list, func(a, b T) int {
+		ra, rb := result(a), result(b)
+		if c := cmp.Compare(rb.Score, ra.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(len(rb.Breakdown.Skills.Matched), len(ra.Breakdown.Skills.Matched))
+	})
+	return list[:min(limit, len(list))]
+}
+
+func writeMatches[T any](w http.ResponseWriter, results []T) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Count   int `json:"count"`
+		Results []T `json:"results"`
+	}{len(results), results})
+}
+
+// JobMatchesHandler - анкеты, лучше всего подходящие под вакансию
+// (GET /job/{id}/matches, владелец вакансии или модератор). Параметры: limit,
+// min_score и фильтры поиска анкет (city, skills, ...) для сужения выбора.
+func JobMatchesHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	j, err := job.GetJobByID(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Error loading jobs", http.StatusInternalServerError)
+		return
+	}
+	if j == nil {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+	if j.UserID != user.ID && !user.Can(auth.PermJobsModerate) {
+		http.Error(w, "Forbidden: not your job", http.StatusForbidden)
+		return
+	}
+
+	query := r.URL.Query()
+	limit, minScore, ok := readPage(query)
+	if !ok {
+		http.Error(w, "Invalid limit or min_score", http.StatusBadRequest)
+		return
+	}
+	filter, err := ankety.FilterFromQuery(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	found, err := ankety.Search(filter)
+	if err != nil {
+		http.Error(w, "Error loading ankety", http.StatusInternalServerError)
+		return
+	}
+
+	matches := []AnketaMatch{}
+	for _, a := range found {
+		// Свою анкету к своей вакансии не подбираем
+		if a.UserId == j.UserID {
+			continue
+		}
+		matches = append(matches, AnketaMatch{Anketa: a, Result: Score(*j, a)})
+	}
+	writeMatches(w, best(matches, func(m AnketaMatch) Result { return m.Result }, limit, minScore))
+}
+
+// MyAnketaMatchesHandler - вакансии, лучше всего подходящие под анкету текущего
+// пользователя (GET /api/ankety/my/matches). Параметры: limit, min_score и
+// фильтры поиска вакансий (location, remote, ...).
+func MyAnketaMatchesHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	anketa, err := ankety.GetAnketaByUserID(user.ID)
+	if err != nil {
+		http.Error(w, "Error loading ankety", http.StatusInternalServerError)
+		return
+	}
+	if anketa == nil {
+		http.Error(w, "Anketa not found", http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	limit, minScore, ok := readPage(query)
+	if !ok {
+		http.Error(w, "Invalid limit or min_score", http.StatusBadRequest)
+		return
+	}
+	filter, err := job.FilterFromQuery(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	found, err := job.Search(filter)
+	if err != nil {
+		http.Error(w, "Error loading jobs", http.StatusInternalServerError)
+		return
+	}
+
+	matches := []JobMatch{}
+	for _, j := range found {
+		if j.UserID == user.ID {
+			continue
+		}
+		matches = append(matches, JobMatch{Job: j, Result: Score(j, *anketa)})
+	}
+	writeMatches(w, best(matches, func(m JobMatch) Result { return m.Result }, limit, minScore))
+}
@@ -0,0 +1,158 @@
+// Package matching - подбор кандидатов к вакансии и вакансий к анкете. Оценка
+// складывается из навыков, места работы, зарплаты и опыта; каждая часть видна в
+// ответе вместе с весом и пояснением, чтобы было понятно, откуда взялся балл.
+package matching
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"talant/ankety"
+	"talant/job"
+	"talant/salary"
+)
+
+// Веса частей оценки; в сумме 1
+const (
+	weightSkills     = 0.4
+	weightLocation   = 0.2
+	weightSalary     = 0.2
+	weightExperience = 0.2
+)
+
+// neutral - оценка части, которую не с чем сравнить (у вакансии или анкеты
+// нет данных): такая часть не поднимает и не топит общий балл
+const neutral = 0.5
+
+// Component - одна часть оценки
+type Component struct {
+	Score  float64 `json:"score"` // от 0 до 1
+	Weight float64 `json:"weight"`
+	// Known - было ли что сравнивать; иначе Score - нейтральные 0.5
+	Known bool   `json:"known"`
+	Note  string `json:"note"`
+	// Matched и Missing - для навыков: какие требуемые навыки у кандидата есть, каких нет
+	Matched []string `json:"matched,omitempty"`
+	Missing []string `json:"missing,omitempty"`
+}
+
+// Breakdown - оценка по частям
+type Breakdown struct {
+	Skills     Component `json:"skills"`
+	Location   Component `json:"location"`
+	Salary     Component `json:"salary"`
+	Experience Component `json:"experience"`
+}
+
+// Result - итоговый балл от 0 до 100 и из чего он сложился
+type Result struct {
+	Score     int       `json:"score"`
+	Breakdown Breakdown `json:"breakdown"`
+}
+
+func unknown(weight float64, note string) Component {
+	return Component{Score: neutral, Weight: weight, Note: note}
+}
+
+// Score оценивает, насколько анкета подходит под вакансию
+func Score(j job.Job, a ankety.Ankety) Result {
+	b := Breakdown{
+		Skills:     scoreSkills(j, a),
+		Location:   scoreLocation(j, a),
+		Salary:     scoreSalary(j, a),
+		Experience: scoreExperience(j, a),
+	}
+	total := 0.0
+	for _, c := range []Component{b.Skills, b.Location, b.Salary, b.Experience} {
+		total += c.Weight * c.Score
+	}
+	return Result{Score: int(math.Round(total * 100)), Breakdown: b}
+}
+
+// round2 - оценка части с точностью до сотых, для ответа
+func round2(x float64) float64 {
+	return math.Round(x*100) / 100
+}
+
+// scoreSkills - доля требуемых навыков вакансии, которые есть в анкете
+func scoreSkills(j job.Job, a ankety.Ankety) Component {
+	if len(j.Skills) == 0 {
+		return unknown(weightSkills, "в вакансии не указаны навыки")
+	}
+	c := Component{Weight: weightSkills, Known: true, Matched: []string{}, Missing: []string{}}
+	for _, skill := range j.Skills {
+		if a.Skills.Has(skill) {
+			c.Matched = append(c.Matched, skill)
+		} else {
+			c.Missing = append(c.Missing, skill)
+		}
+	}
+	c.Score = round2(float64(len(c.Matched)) / float64(len(j.Skills)))
+	c.Note = fmt.Sprintf("%d из %d требуемых навыков", len(c.Matched), len(j.Skills))
+	return c
+}
+
+// scoreLocation - удаленная работа подходит всем, иначе город кандидата
+// должен совпасть с местом работы
+func scoreLocation(j job.Job, a ankety.Ankety) Component {
+	if j.IsRemote() {
+		return Component{Score: 1, Weight: weightLocation, Known: true, Note: "удаленная работа"}
+	}
+	city, location := strings.TrimSpace(a.City), strings.TrimSpace(j.Location)
+	if city == "" || location == "" {
+		return unknown(weightLocation, "не указан город кандидата или место работы")
+	}
+	lowCity, lowLocation := strings.ToLower(city), strings.ToLower(location)
+	if strings.Contains(lowLocation, lowCity) || strings.Contains(lowCity, lowLocation) {
+		return Component{Score: 1, Weight: weightLocation, Known: true, Note: "тот же город: " + city}
+	}
+	return Component{Weight: weightLocation, Known: true,
+		Note: fmt.Sprintf("вакансия: %s, кандидат: %s", location, city)}
+}
+
+// scoreSalary - ожидания кандидата против вилки вакансии (в месяц, в базовой
+// валюте). Ожидания не выше верхней границы вилки - 1; дальше оценка падает и
+// доходит до 0, когда ожидания выше вилки вдвое.
+func scoreSalary(j job.Job, a ankety.Ankety) Component {
+	if j.SalaryRange == nil || a.SalaryRange == nil {
+		return unknown(weightSalary, "не указана зарплата в вакансии или ожидания кандидата")
+	}
+	_, offer, okJob := j.SalaryRange.Monthly(salary.Base())
+	expected, _, okAnketa := a.SalaryRange.Monthly(salary.Base())
+	if !okJob || !okAnketa {
+		return unknown(weightSalary, "зарплату не пересчитать в базовую валюту")
+	}
+	if expected <= offer {
+		return Component{Score: 1, Weight: weightSalary, Known: true, Note: "ожидания в пределах вилки"}
+	}
+	over := (expected - offer) / expected
+	return Component{
+		Score:  round2(max(0, 1-2*over)),
+		Weight: weightSalary,
+		Known:  true,
+		Note:   fmt.Sprintf("ожидания выше вилки на %.0f%%", (expected/offer-1)*100),
+	}
+}
+
+// scoreExperience - опыт кандидата против требуемого. Каждый недостающий год
+// снижает оценку на треть; опыт больше требуемого - 0.8: кандидату может быть
+// неинтересно.
+func scoreExperience(j job.Job, a ankety.Ankety) Component {
+	if j.ExperienceRange == nil || a.ExperienceRange == nil {
+		return unknown(weightExperience, "не указан опыт в вакансии или анкете")
+	}
+	gap := a.ExperienceRange.Gap(*j.ExperienceRange)
+	c := Component{Weight: weightExperience, Known: true,
+		Note: fmt.Sprintf("кандидат: %s, требуется: %s", a.ExperienceRange, j.ExperienceRange)}
+	switch {
+	case gap == 0:
+		c.Score = 1
+	case gap < 0:
+		c.Score = round2(max(0, 1+float64(gap)/3))
+		c.Note = "не хватает опыта; " + c.Note
+	default:
+		c.Score = 0.8
+		c.Note = "опыт больше требуемого; " + c.Note
+	}
+	return c
+}
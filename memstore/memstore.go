@@ -0,0 +1,389 @@
+// Package memstore держит записи в памяти с индексами и сохраняет их на диск
+// как снапшот (обычный JSON-массив, тот же формат, что у jsonfile) плюс журнал
+// изменений. Каждое изменение дописывается в журнал одной строкой, а журнал
+// периодически сворачивается в новый снапшот.
+package memstore
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"talant/jsonfile"
+	"time"
+)
+
+const (
+	defaultCompactEvery    = 1000
+	defaultCompactInterval = 5 * time.Minute
+)
+
+// Options - параметры хранилища
+type Options[T any] struct {
+	// Snapshot - JSON-файл с массивом записей
+	Snapshot string
+	// Journal - файл журнала; по умолчанию Snapshot + ".journal"
+	Journal string
+	// ID возвращает первичный ключ записи
+	ID func(T) string
+	// Indexes - вторичные индексы: имя -> ключ записи. Пустой ключ не индексируется.
+	Indexes map[string]func(T) string
+	// CompactEvery - сколько записей журнала копится до сворачивания
+	CompactEvery int
+	// CompactInterval - как часто сворачивать журнал по таймеру
+	CompactInterval time.Duration
+}
+
+type entry[T any] struct {
+	seq   uint64
+	value T
+}
+
+// journalEntry - одна строка журнала
+type journalEntry[T any] struct {
+	Op    string `json:"op"`
+	ID    string `json:"id"`
+	Value *T     `json:"value,omitempty"`
+}
+
+const (
+	opPut    = "put"
+	opDelete = "delete"
+)
+
+// Store - записи в памяти. Чтения идут под RLock и не трогают диск,
+// запись - O(1) в памяти плюс одна строка в журнале.
+type Store[T any] struct {
+	opts Options[T]
+
+	mu      sync.RWMutex
+	items   map[string]*entry[T]
+	indexes map[string]map[string]map[string]struct{}
+	seq     uint64
+
+	journal *os.File
+	pending int
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Open читает снапшот, проигрывает поверх него журнал и запускает
+// фоновое сворачивание журнала
+func Open[T any](opts Options[T]) (*Store[T], error) {
+	if opts.Journal == "" {
+		opts.Journal = opts.Snapshot + ".journal"
+	}
+	if opts.CompactEvery <= 0 {
+		opts.CompactEvery = defaultCompactEvery
+	}
+	if opts.CompactInterval <= 0 {
+		opts.CompactInterval = defaultCompactInterval
+	}
+
+	s := &Store[T]{
+		opts:    opts,
+		items:   map[string]*entry[T]{},
+		indexes: map[string]map[string]map[string]struct{}{},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	for name := range opts.Indexes {
+		s.indexes[name] = map[string]map[string]struct{}{}
+	}
+
+	list, err := jsonfile.New[T](opts.Snapshot).Load()
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range list {
+		s.put(v)
+	}
+
+	replayed, err := s.replay()
+	if err != nil {
+		return nil, err
+	}
+
+	s.journal, err = os.OpenFile(opts.Journal, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия журнала %s: %w", opts.Journal, err)
+	}
+	// Сразу сворачиваем то, что осталось с прошлого запуска
+	if replayed > 0 {
+		s.pending = replayed
+		if err := s.compactLocked(); err != nil {
+			s.journal.Close()
+			return nil, err
+		}
+	}
+
+	go s.loop()
+	return s, nil
+}
+
+// replay применяет журнал поверх снапшота. Недописанная последняя строка
+// (падение посреди записи) пропускается.
+func (s *Store[T]) replay() (int, error) {
+	f, err := os.Open(s.opts.Journal)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("ошибка чтения журнала %s: %w", s.opts.Journal, err)
+	}
+	defer f.Close()
+
+	n := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var e journalEntry[T]
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			fmt.Printf("Пропущена поврежденная запись журнала %s: %v\n", s.opts.Journal, err)
+			continue
+		}
+		switch {
+		case e.Op == opPut && e.Value != nil:
+			s.put(*e.Value)
+		case e.Op == opDelete:
+			s.remove(e.ID)
+		}
+		n++
+	}
+	return n, scanner.Err()
+}
+
+func (s *Store[T]) loop() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.opts.CompactInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Compact(); err != nil {
+				fmt.Printf("Ошибка сворачивания журнала %s: %v\n", s.opts.Journal, err)
+			}
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// Close останавливает фоновое сворачивание и сбрасывает журнал в снапшот
+func (s *Store[T]) Close() error {
+	close(s.stop)
+	<-s.done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.compactLocked()
+	if cerr := s.journal.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Compact записывает текущее состояние в снапшот и очищает журнал
+func (s *Store[T]) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.compactLocked()
+}
+
+func (s *Store[T]) compactLocked() error {
+	if s.pending == 0 {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.listLocked(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации JSON: %w", err)
+	}
+	if err := jsonfile.WriteAtomic(s.opts.Snapshot, data, 0644); err != nil {
+		return err
+	}
+	// Снапшот уже содержит все изменения, поэтому журнал можно обнулить.
+	// Если упадем между этими шагами, повторное проигрывание журнала ничего не испортит.
+	if err := s.journal.Truncate(0); err != nil {
+		return err
+	}
+	s.pending = 0
+	return nil
+}
+
+func (s *Store[T]) appendJournal(e journalEntry[T]) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := s.journal.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("ошибка записи журнала %s: %w", s.opts.Journal, err)
+	}
+	if err := s.journal.Sync(); err != nil {
+		return err
+	}
+	s.pending++
+	return nil
+}
+
+// maybeCompact сворачивает журнал, когда он разросся. Вызывается уже после
+// применения изменения в памяти; само изменение к этому моменту надежно
+// записано в журнал, поэтому ошибка сворачивания только логируется.
+func (s *Store[T]) maybeCompact() {
+	if s.pending < s.opts.CompactEvery {
+		return
+	}
+	if err := s.compactLocked(); err != nil {
+		fmt.Printf("Ошибка сворачивания журнала %s: %v\n", s.opts.Journal, err)
+	}
+}
+
+func (s *Store[T]) put(v T) {
+	id := s.opts.ID(v)
+	if old, ok := s.items[id]; ok {
+		s.unindex(id, old.value)
+		old.value = v
+	} else {
+		s.seq++
+		s.items[id] = &entry[T]{seq: s.seq, value: v}
+	}
+	for name, key := range s.opts.Indexes {
+		k := key(v)
+		if k == "" {
+			continue
+		}
+		ids, ok := s.indexes[name][k]
+		if !ok {
+			ids = map[string]struct{}{}
+			s.indexes[name][k] = ids
+		}
+		ids[id] = struct{}{}
+	}
+}
+
+func (s *Store[T]) remove(id string) bool {
+	old, ok := s.items[id]
+	if !ok {
+		return false
+	}
+	s.unindex(id, old.value)
+	delete(s.items, id)
+	return true
+}
+
+func (s *Store[T]) unindex(id string, v T) {
+	for name, key := range s.opts.Indexes {
+		k := key(v)
+		if ids, ok := s.indexes[name][k]; ok {
+			delete(ids, id)
+			if len(ids) == 0 {
+				delete(s.indexes[name], k)
+			}
+		}
+	}
+}
+
+// sorted возвращает записи в порядке добавления, как в исходном JSON-массиве
+func (s *Store[T]) sorted(entries []*entry[T]) []T {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].seq < entries[j].seq })
+	list := make([]T, len(entries))
+	for i, e := range entries {
+		list[i] = e.value
+	}
+	return list
+}
+
+func (s *Store[T]) listLocked() []T {
+	entries := make([]*entry[T], 0, len(s.items))
+	for _, e := range s.items {
+		entries = append(entries, e)
+	}
+	return s.sorted(entries)
+}
+
+func (s *Store[T]) findLocked(index, key string) []T {
+	ids := s.indexes[index][key]
+	entries := make([]*entry[T], 0, len(ids))
+	for id := range ids {
+		entries = append(entries, s.items[id])
+	}
+	return s.sorted(entries)
+}
+
+// List возвращает все записи
+func (s *Store[T]) List() []T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.listLocked()
+}
+
+// Get ищет запись по первичному ключу
+func (s *Store[T]) Get(id string) (T, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, ok := s.items[id]
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return e.value, true
+}
+
+// Find возвращает записи с данным ключом вторичного индекса
+func (s *Store[T]) Find(index, key string) []T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.findLocked(index, key)
+}
+
+// View - доступ на чтение внутри Put/Delete, когда блокировка уже взята
+type View[T any] struct {
+	s *Store[T]
+}
+
+func (v View[T]) Get(id string) (T, bool) {
+	e, ok := v.s.items[id]
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return e.value, true
+}
+
+func (v View[T]) Find(index, key string) []T {
+	return v.s.findLocked(index, key)
+}
+
+// Put добавляет или заменяет запись. check вызывается под той же блокировкой,
+// что и запись, и может отменить ее (проверки уникальности, существования).
+func (s *Store[T]) Put(v T, check func(View[T]) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if check != nil {
+		if err := check(View[T]{s}); err != nil {
+			return err
+		}
+	}
+	if err := s.appendJournal(journalEntry[T]{Op: opPut, ID: s.opts.ID(v), Value: &v}); err != nil {
+		return err
+	}
+	s.put(v)
+	s.maybeCompact()
+	return nil
+}
+
+// Delete удаляет запись; false - записи не было
+func (s *Store[T]) Delete(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.items[id]; !ok {
+		return false, nil
+	}
+	if err := s.appendJournal(journalEntry[T]{Op: opDelete, ID: id}); err != nil {
+		return false, err
+	}
+	s.remove(id)
+	s.maybeCompact()
+	return true, nil
+}
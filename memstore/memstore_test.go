@@ -0,0 +1,162 @@
+package memstore
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"talant/jsonfile"
+	"testing"
+	"time"
+)
+
+type item struct {
+	ID    string `json:"id"`
+	Owner string `json:"owner"`
+	Text  string `json:"text"`
+}
+
+func open(t *testing.T, dir string, compactEvery int) *Store[item] {
+	t.Helper()
+	s, err := Open(Options[item]{
+		Snapshot:        filepath.Join(dir, "items.json"),
+		ID:              func(it item) string { return it.ID },
+		Indexes:         map[string]func(item) string{"owner": func(it item) string { return it.Owner }},
+		CompactEvery:    compactEvery,
+		CompactInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// crash останавливает хранилище без сворачивания журнала, как при падении процесса
+func crash(s *Store[item]) {
+	close(s.stop)
+	<-s.done
+	s.journal.Close()
+}
+
+func fileSize(t *testing.T, path string) int64 {
+	t.Helper()
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return 0
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Size()
+}
+
+// fill делает несколько изменений и возвращает ожидаемое содержимое
+func fill(t *testing.T, s *Store[item]) []item {
+	t.Helper()
+	for _, it := range []item{{"1", "ann", "a"}, {"2", "bob", "b"}, {"3", "ann", "c"}} {
+		if err := s.Put(it, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Put(item{"2", "ann", "b2"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := s.Delete("1"); !ok || err != nil {
+		t.Fatalf("Delete = %v, %v", ok, err)
+	}
+	return []item{{"2", "ann", "b2"}, {"3", "ann", "c"}}
+}
+
+func check(t *testing.T, s *Store[item], want []item) {
+	t.Helper()
+	if got := s.List(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("List = %v, want %v", got, want)
+	}
+	if got := s.Find("owner", "ann"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Find(owner, ann) = %v, want %v", got, want)
+	}
+	if got := s.Find("owner", "bob"); len(got) != 0 {
+		t.Fatalf("Find(owner, bob) = %v, want none", got)
+	}
+}
+
+func TestReopenReplaysJournal(t *testing.T) {
+	dir := t.TempDir()
+	s := open(t, dir, 100)
+	want := fill(t, s)
+	check(t, s, want)
+	crash(s)
+
+	// Все изменения пока только в журнале
+	snapshot, journal := filepath.Join(dir, "items.json"), filepath.Join(dir, "items.json.journal")
+	if fileSize(t, snapshot) != 0 || fileSize(t, journal) == 0 {
+		t.Fatalf("snapshot %d bytes, journal %d bytes; want only journal", fileSize(t, snapshot), fileSize(t, journal))
+	}
+
+	// При открытии журнал проигрывается и сразу сворачивается в снапшот
+	s = open(t, dir, 100)
+	check(t, s, want)
+	if fileSize(t, journal) != 0 {
+		t.Errorf("journal not compacted on open: %d bytes", fileSize(t, journal))
+	}
+	if err := s.Put(item{"4", "bob", "d"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Close сворачивает журнал; снапшот читается обычным jsonfile
+	want = append(want, item{"4", "bob", "d"})
+	list, err := jsonfile.New[item](snapshot).Load()
+	if err != nil || !reflect.DeepEqual(list, want) {
+		t.Fatalf("snapshot = %v, %v; want %v", list, err, want)
+	}
+	s = open(t, dir, 100)
+	defer s.Close()
+	if got := s.List(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("after reopen List = %v, want %v", got, want)
+	}
+}
+
+func TestCompactEvery(t *testing.T) {
+	dir := t.TempDir()
+	s := open(t, dir, 2)
+	want := fill(t, s)
+	crash(s)
+
+	// 5 изменений при CompactEvery = 2: в журнале осталось только последнее
+	list, err := jsonfile.New[item](filepath.Join(dir, "items.json")).Load()
+	if err != nil || len(list) != 3 {
+		t.Fatalf("snapshot = %v, %v; want state after 4 changes", list, err)
+	}
+	s = open(t, dir, 2)
+	defer s.Close()
+	check(t, s, want)
+}
+
+func TestTruncatedJournalTail(t *testing.T) {
+	dir := t.TempDir()
+	s := open(t, dir, 100)
+	want := fill(t, s)
+	crash(s)
+
+	// Падение посреди записи: последняя строка журнала недописана
+	f, err := os.OpenFile(filepath.Join(dir, "items.json.journal"), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"op":"put","id":"5","value":{"id":"5","ow`)
+	f.Close()
+
+	s = open(t, dir, 100)
+	check(t, s, want)
+	if _, ok := s.Get("5"); ok {
+		t.Error("truncated entry was applied")
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	s = open(t, dir, 100)
+	defer s.Close()
+	check(t, s, want)
+}
@@ -0,0 +1,592 @@
+// Package messages - личная переписка пользователей: беседы двоих (можно по
+// конкретной вакансии или анкете), история с постраничной загрузкой,
+// непрочитанные и блокировка собеседника.
+package messages
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"talant/ankety"
+	"talant/auth"
+	"talant/job"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxMessageLength = 4000 // символов в одном сообщении
+	previewLength    = 100  // символов в превью последнего сообщения
+	defaultPageSize  = 50
+	maxPageSize      = 100
+)
+
+var (
+	ErrBlocked     = errors.New("messaging is blocked")
+	errEmptyText   = errors.New("message text is required")
+	errTextTooLong = errors.New("message is too long")
+)
+
+// Member - участник беседы и его непрочитанные сообщения
+type Member struct {
+	UserID     string     `json:"user_id"`
+	Username   string     `json:"username"`
+	Unread     int        `json:"unread"`
+	LastReadAt *time.Time `json:"last_read_at,omitempty"`
+}
+
+// Conversation - беседа двух пользователей. JobID или AnketaID задают тему:
+// по одной вакансии или анкете у пары пользователей одна беседа.
+type Conversation struct {
+	Id string `json:"id"`
+	// Key - участники по порядку и тема; по нему находится существующая беседа
+	Key           string    `json:"key"`
+	JobID         string    `json:"job_id,omitempty"`
+	AnketaID      string    `json:"anketa_id,omitempty"`
+	Members       []Member  `json:"members"`
+	LastMessage   string    `json:"last_message,omitempty"`
+	LastMessageAt time.Time `json:"last_message_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Message - сообщение в беседе
+type Message struct {
+	Id             string    `json:"id"`
+	ConversationID string    `json:"conversation_id"`
+	SenderID       string    `json:"sender_id"`
+	Text           string    `json:"text"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Block - пользователь UserID не принимает сообщения от BlockedID
+type Block struct {
+	UserID    string    `json:"user_id"`
+	BlockedID string    `json:"blocked_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (c *Conversation) member(userID string) *Member {
+	for i := range c.Members {
+		if c.Members[i].UserID == userID {
+			return &c.Members[i]
+		}
+	}
+	return nil
+}
+
+// memberID - ID i-го участника или пустая строка
+func (c Conversation) memberID(i int) string {
+	if i < len(c.Members) {
+		return c.Members[i].UserID
+	}
+	return ""
+}
+
+// other - собеседник пользователя userID
+func (c *Conversation) other(userID string) *Member {
+	for i := range c.Members {
+		if c.Members[i].UserID != userID {
+			return &c.Members[i]
+		}
+	}
+	return nil
+}
+
+func conversationKey(a, b, jobID, anketaID string) string {
+	if a > b {
+		a, b = b, a
+	}
+	return strings.Join([]string{a, b, jobID, anketaID}, "|")
+}
+
+// isBlocked - заблокировал ли кто-то из двоих другого
+func isBlocked(a, b string) (bool, error) {
+	for _, pair := range [][2]string{{a, b}, {b, a}} {
+		blocks, err := store.ListBlocks(pair[0])
+		if err != nil {
+			return false, err
+		}
+		if slices.ContainsFunc(blocks, func(bl Block) bool { return bl.BlockedID == pair[1] }) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func checkText(text string) error {
+	if text == "" {
+		return errEmptyText
+	}
+	if utf8.RuneCountInString(text) > maxMessageLength {
+		return errTextTooLong
+	}
+	return nil
+}
+
+func preview(text string) string {
+	if utf8.RuneCountInString(text) <= previewLength {
+		return text
+	}
+	return string([]rune(text)[:previewLength]) + "…"
+}
+
+// messageHooks вызываются после каждого отправленного сообщения (уведомления)
+var messageHooks []func(c Conversation, m Message) error
+
+// OnMessage подключает обработчик нового сообщения
+func OnMessage(hook func(c Conversation, m Message) error) {
+	messageHooks = append(messageHooks, hook)
+}
+
+// send добавляет сообщение в беседу и увеличивает непрочитанное у собеседника
+func send(c Conversation, senderID, text string) (Message, error) {
+	blocked, err := isBlocked(c.memberID(0), c.memberID(1))
+	if err != nil {
+		return Message{}, err
+	}
+	if blocked {
+		return Message{}, ErrBlocked
+	}
+
+	now := time.Now()
+	m := Message{
+		Id:             uuid.New().String(),
+		ConversationID: c.Id,
+		SenderID:       senderID,
+		Text:           text,
+		CreatedAt:      now,
+	}
+	if err := store.AddMessage(m); err != nil {
+		return Message{}, err
+	}
+	err = store.ChangeConversation(c.Id, func(c *Conversation) error {
+		c.LastMessage = preview(text)
+		c.LastMessageAt = now
+		if other := c.other(senderID); other != nil {
+			other.Unread++
+		}
+		// Отвечая, отправитель прочитал беседу
+		if me := c.member(senderID); me != nil {
+			me.Unread = 0
+			me.LastReadAt = &now
+		}
+		return nil
+	})
+	if err != nil {
+		return Message{}, err
+	}
+	// Ошибки хуков только в лог: сообщение уже отправлено
+	for _, hook := range messageHooks {
+		if err := hook(c, m); err != nil {
+			fmt.Printf("Ошибка обработчика сообщения %s: %v\n", m.Id, err)
+		}
+	}
+	return m, nil
+}
+
+// writeSendError отвечает на ошибку отправки сообщения
+func writeSendError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrBlocked):
+		http.Error(w, "Messaging is blocked", http.StatusForbidden)
+	case errors.Is(err, errEmptyText):
+		http.Error(w, "Message text is required", http.StatusBadRequest)
+	case errors.Is(err, errTextTooLong):
+		http.Error(w, "Message is too long", http.StatusBadRequest)
+	default:
+		http.Error(w, "Error saving messages", http.StatusInternalServerError)
+	}
+}
+
+// conversationView - беседа глазами участника: собеседник и свое непрочитанное
+type conversationView struct {
+	Conversation
+	With   Member `json:"with"`
+	Unread int    `json:"unread"`
+}
+
+func newView(c Conversation, userID string) conversationView {
+	view := conversationView{Conversation: c}
+	if other := c.other(userID); other != nil {
+		view.With = *other
+	}
+	if me := c.member(userID); me != nil {
+		view.Unread = me.Unread
+	}
+	return view
+}
+
+// resolveTopic находит владельца вакансии или анкеты, по которой начинают беседу
+func resolveTopic(jobID, anketaID string) (string, bool, error) {
+	switch {
+	case jobID != "":
+		j, err := job.GetJobByID(jobID)
+		if err != nil || j == nil {
+			return "", false, err
+		}
+		return j.UserID, true, nil
+	case anketaID != "":
+		a, err := ankety.GetAnketaByID(anketaID)
+		if err != nil || a == nil || a.Moderation != nil {
+			return "", false, err
+		}
+		return a.UserId, true, nil
+	}
+	return "", true, nil
+}
+
+// StartHandler начинает беседу первым сообщением (POST /api/conversations).
+// Поля: text, to (ID собеседника), job_id или anketa_id (тема). Без to
+// собеседник - владелец вакансии или анкеты. Если беседа уже есть, сообщение
+// добавляется в нее.
+func StartHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	text := strings.TrimSpace(r.FormValue("text"))
+	to := r.FormValue("to")
+	jobID := r.FormValue("job_id")
+	anketaID := r.FormValue("anketa_id")
+	if jobID != "" && anketaID != "" {
+		http.Error(w, "Use either job_id or anketa_id", http.StatusBadRequest)
+		return
+	}
+	if err := checkText(text); err != nil {
+		writeSendError(w, err)
+		return
+	}
+
+	owner, found, err := resolveTopic(jobID, anketaID)
+	if err != nil {
+		http.Error(w, "Error loading topic", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "Job or anketa not found", http.StatusNotFound)
+		return
+	}
+	if to == "" {
+		to = owner
+	}
+	// Тема должна касаться одного из участников
+	if owner != "" && owner != to && owner != user.ID {
+		http.Error(w, "Job or anketa belongs to neither participant", http.StatusBadRequest)
+		return
+	}
+	if to == "" {
+		http.Error(w, "Missing recipient", http.StatusBadRequest)
+		return
+	}
+	if to == user.ID {
+		http.Error(w, "Cannot message yourself", http.StatusBadRequest)
+		return
+	}
+	recipient, err := auth.GetUser(to)
+	if errors.Is(err, auth.ErrNotFound) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error loading users", http.StatusInternalServerError)
+		return
+	}
+
+	// Проверяем до создания беседы, чтобы не оставлять пустых
+	blocked, err := isBlocked(user.ID, recipient.Id)
+	if err != nil {
+		http.Error(w, "Error loading blocks", http.StatusInternalServerError)
+		return
+	}
+	if blocked {
+		writeSendError(w, ErrBlocked)
+		return
+	}
+
+	key := conversationKey(user.ID, recipient.Id, jobID, anketaID)
+	c, err := store.GetConversationByKey(key)
+	status := http.StatusOK
+	if errors.Is(err, ErrNotFound) {
+		members := []Member{{UserID: user.ID, Username: user.Username}, {UserID: recipient.Id, Username: recipient.Username}}
+		slices.SortFunc(members, func(a, b Member) int { return strings.Compare(a.UserID, b.UserID) })
+		c = Conversation{
+			Id:        uuid.New().String(),
+			Key:       key,
+			JobID:     jobID,
+			AnketaID:  anketaID,
+			Members:   members,
+			CreatedAt: time.Now(),
+		}
+		err = store.CreateConversation(c)
+		status = http.StatusCreated
+		if errors.Is(err, ErrConversationExists) {
+			c, err = store.GetConversationByKey(key)
+			status = http.StatusOK
+		}
+	}
+	if err != nil {
+		http.Error(w, "Error saving messages", http.StatusInternalServerError)
+		return
+	}
+
+	m, err := send(c, user.ID, text)
+	if err != nil {
+		writeSendError(w, err)
+		return
+	}
+	if c, err = store.GetConversation(c.Id); err != nil {
+		http.Error(w, "Error loading messages", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct {
+		Conversation conversationView `json:"conversation"`
+		Message      Message          `json:"message"`
+	}{newView(c, user.ID), m})
+}
+
+// ListHandler - беседы текущего пользователя, свежие первыми (GET /api/conversations)
+func ListHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	list, err := store.ListConversations(user.ID)
+	if err != nil {
+		http.Error(w, "Error loading messages", http.StatusInternalServerError)
+		return
+	}
+	slices.SortStableFunc(list, func(a, b Conversation) int {
+		return b.LastMessageAt.Compare(a.LastMessageAt)
+	})
+	views := make([]conversationView, 0, len(list))
+	for _, c := range list {
+		views = append(views, newView(c, user.ID))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(views)
+}
+
+// UnreadHandler - сколько всего непрочитанных сообщений и в скольких беседах
+// (GET /api/conversations/unread)
+func UnreadHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	list, err := store.ListConversations(user.ID)
+	if err != nil {
+		http.Error(w, "Error loading messages", http.StatusInternalServerError)
+		return
+	}
+	var total, conversations int
+	for _, c := range list {
+		if me := c.member(user.ID); me != nil && me.Unread > 0 {
+			total += me.Unread
+			conversations++
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"unread": total, "conversations": conversations})
+}
+
+// loadConversation загружает беседу текущего пользователя; чужие беседы - 404
+func loadConversation(w http.ResponseWriter, r *http.Request, userID string) (Conversation, bool) {
+	c, err := store.GetConversation(r.PathValue("id"))
+	if err == nil && c.member(userID) == nil {
+		err = ErrNotFound
+	}
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, "Conversation not found", http.StatusNotFound)
+		return Conversation{}, false
+	}
+	if err != nil {
+		http.Error(w, "Error loading messages", http.StatusInternalServerError)
+		return Conversation{}, false
+	}
+	return c, true
+}
+
+// markRead обнуляет непрочитанное пользователя в беседе
+func markRead(conversationID, userID string) error {
+	return store.ChangeConversation(conversationID, func(c *Conversation) error {
+		if me := c.member(userID); me != nil {
+			now := time.Now()
+			me.Unread = 0
+			me.LastReadAt = &now
+		}
+		return nil
+	})
+}
+
+// MessagesHandler - страница истории (GET /api/conversations/{id}/messages).
+// Без before - последние limit сообщений, и беседа отмечается прочитанной;
+// следующую (более раннюю) страницу дает before=next_before из ответа.
+func MessagesHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	c, ok := loadConversation(w, r, user.ID)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	limit := defaultPageSize
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxPageSize)
+	}
+	before := query.Get("before")
+
+	// Лишнее сообщение показывает, есть ли страница раньше
+	list, err := store.ListMessages(c.Id, before, limit+1)
+	if err != nil {
+		http.Error(w, "Error loading messages", http.StatusInternalServerError)
+		return
+	}
+	nextBefore := ""
+	if len(list) > limit {
+		list = list[1:]
+		nextBefore = list[0].Id
+	}
+	if before == "" {
+		if err := markRead(c.Id, user.ID); err != nil {
+			http.Error(w, "Error saving messages", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Messages   []Message `json:"messages"`
+		NextBefore string    `json:"next_before,omitempty"`
+	}{list, nextBefore})
+}
+
+// SendHandler отправляет сообщение в беседу (POST /api/conversations/{id}/messages, поле text)
+func SendHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	c, ok := loadConversation(w, r, user.ID)
+	if !ok {
+		return
+	}
+	text := strings.TrimSpace(r.FormValue("text"))
+	if err := checkText(text); err != nil {
+		writeSendError(w, err)
+		return
+	}
+	m, err := send(c, user.ID, text)
+	if err != nil {
+		writeSendError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(m)
+}
+
+// ReadHandler отмечает беседу прочитанной (POST /api/conversations/{id}/read)
+func ReadHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	c, ok := loadConversation(w, r, user.ID)
+	if !ok {
+		return
+	}
+	if err := markRead(c.Id, user.ID); err != nil {
+		http.Error(w, "Error saving messages", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// BlocksHandler - кого заблокировал текущий пользователь (GET /api/blocks)
+func BlocksHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	blocks, err := store.ListBlocks(user.ID)
+	if err != nil {
+		http.Error(w, "Error loading blocks", http.StatusInternalServerError)
+		return
+	}
+	type blockView struct {
+		Block
+		Username string `json:"username,omitempty"`
+	}
+	views := make([]blockView, 0, len(blocks))
+	for _, b := range blocks {
+		view := blockView{Block: b}
+		if u, err := auth.GetUser(b.BlockedID); err == nil {
+			view.Username = u.Username
+		}
+		views = append(views, view)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(views)
+}
+
+// BlockHandler блокирует пользователя (POST /api/blocks/{user_id}): он больше
+// не может писать текущему пользователю, и наоборот
+func BlockHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	blockedID := r.PathValue("user_id")
+	if blockedID == user.ID {
+		http.Error(w, "Cannot block yourself", http.StatusBadRequest)
+		return
+	}
+	_, err := auth.GetUser(blockedID)
+	if errors.Is(err, auth.ErrNotFound) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error loading users", http.StatusInternalServerError)
+		return
+	}
+	if err := store.AddBlock(Block{UserID: user.ID, BlockedID: blockedID, CreatedAt: time.Now()}); err != nil {
+		http.Error(w, "Error saving blocks", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnblockHandler снимает блокировку (DELETE /api/blocks/{user_id})
+func UnblockHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	err := store.RemoveBlock(user.ID, r.PathValue("user_id"))
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, "User is not blocked", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error saving blocks", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,319 @@
+package messages
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"sync"
+	"talant/jsonfile"
+	"talant/memstore"
+)
+
+var (
+	// ErrNotFound возвращается хранилищем, если беседа или блокировка не найдены
+	ErrNotFound = errors.New("conversation not found")
+	// ErrConversationExists - беседа с тем же ключом уже есть (ее создал параллельный запрос)
+	ErrConversationExists = errors.New("conversation already exists")
+)
+
+// Store - хранилище бесед, сообщений и блокировок
+type Store interface {
+	GetConversation(id string) (Conversation, error)
+	GetConversationByKey(key string) (Conversation, error)
+	ListConversations(userID string) ([]Conversation, error)
+	CreateConversation(c Conversation) error
+	// ChangeConversation меняет беседу под блокировкой: счетчики непрочитанного
+	// обновляют и отправитель, и читатель, и ни одно изменение не должно потеряться
+	ChangeConversation(id string, fn func(*Conversation) error) error
+
+	AddMessage(m Message) error
+	// ListMessages - до limit сообщений беседы перед сообщением beforeID (пустой -
+	// самые новые) в порядке отправки
+	ListMessages(conversationID, beforeID string, limit int) ([]Message, error)
+
+	ListBlocks(userID string) ([]Block, error)
+	AddBlock(b Block) error
+	RemoveBlock(userID, blockedID string) error
+}
+
+var store Store = NewJSONStore("chats")
+
+// SetStore подменяет хранилище, с которым работают обработчики
+func SetStore(s Store) {
+	store = s
+}
+
+// pageBefore - общая для хранилищ нарезка страницы из сообщений беседы по порядку отправки
+func pageBefore(list []Message, beforeID string, limit int) []Message {
+	if beforeID != "" {
+		i := slices.IndexFunc(list, func(m Message) bool { return m.Id == beforeID })
+		if i < 0 {
+			return []Message{}
+		}
+		list = list[:i]
+	}
+	if len(list) > limit {
+		list = list[len(list)-limit:]
+	}
+	return list
+}
+
+// JSONStore хранит беседы, сообщения и блокировки тремя JSON-файлами в каталоге
+type JSONStore struct {
+	conversations *jsonfile.File[Conversation]
+	messages      *jsonfile.File[Message]
+	blocks        *jsonfile.File[Block]
+}
+
+func NewJSONStore(dir string) *JSONStore {
+	return &JSONStore{
+		conversations: jsonfile.New[Conversation](filepath.Join(dir, "conversations.json")),
+		messages:      jsonfile.New[Message](filepath.Join(dir, "messages.json")),
+		blocks:        jsonfile.New[Block](filepath.Join(dir, "blocks.json")),
+	}
+}
+
+func (s *JSONStore) findConversation(match func(Conversation) bool) (Conversation, error) {
+	list, err := s.conversations.Load()
+	if err != nil {
+		return Conversation{}, err
+	}
+	for _, c := range list {
+		if match(c) {
+			return c, nil
+		}
+	}
+	return Conversation{}, ErrNotFound
+}
+
+func (s *JSONStore) GetConversation(id string) (Conversation, error) {
+	return s.findConversation(func(c Conversation) bool { return c.Id == id })
+}
+
+func (s *JSONStore) GetConversationByKey(key string) (Conversation, error) {
+	return s.findConversation(func(c Conversation) bool { return c.Key == key })
+}
+
+func (s *JSONStore) ListConversations(userID string) ([]Conversation, error) {
+	list, err := s.conversations.Load()
+	if err != nil {
+		return nil, err
+	}
+	found := []Conversation{}
+	for _, c := range list {
+		if c.member(userID) != nil {
+			found = append(found, c)
+		}
+	}
+	return found, nil
+}
+
+func (s *JSONStore) CreateConversation(c Conversation) error {
+	return s.conversations.Update(func(list []Conversation) ([]Conversation, error) {
+		for _, other := range list {
+			if other.Key == c.Key {
+				return nil, ErrConversationExists
+			}
+		}
+		return append(list, c), nil
+	})
+}
+
+func (s *JSONStore) ChangeConversation(id string, fn func(*Conversation) error) error {
+	return s.conversations.Update(func(list []Conversation) ([]Conversation, error) {
+		for i := range list {
+			if list[i].Id == id {
+				if err := fn(&list[i]); err != nil {
+					return nil, err
+				}
+				return list, nil
+			}
+		}
+		return nil, ErrNotFound
+	})
+}
+
+func (s *JSONStore) AddMessage(m Message) error {
+	return s.messages.Update(func(list []Message) ([]Message, error) {
+		return append(list, m), nil
+	})
+}
+
+func (s *JSONStore) ListMessages(conversationID, beforeID string, limit int) ([]Message, error) {
+	list, err := s.messages.Load()
+	if err != nil {
+		return nil, err
+	}
+	own := []Message{}
+	for _, m := range list {
+		if m.ConversationID == conversationID {
+			own = append(own, m)
+		}
+	}
+	return pageBefore(own, beforeID, limit), nil
+}
+
+func (s *JSONStore) ListBlocks(userID string) ([]Block, error) {
+	list, err := s.blocks.Load()
+	if err != nil {
+		return nil, err
+	}
+	found := []Block{}
+	for _, b := range list {
+		if b.UserID == userID {
+			found = append(found, b)
+		}
+	}
+	return found, nil
+}
+
+func (s *JSONStore) AddBlock(b Block) error {
+	return s.blocks.Update(func(list []Block) ([]Block, error) {
+		for _, other := range list {
+			if other.UserID == b.UserID && other.BlockedID == b.BlockedID {
+				return list, nil
+			}
+		}
+		return append(list, b), nil
+	})
+}
+
+func (s *JSONStore) RemoveBlock(userID, blockedID string) error {
+	return s.blocks.Update(func(list []Block) ([]Block, error) {
+		for i, b := range list {
+			if b.UserID == userID && b.BlockedID == blockedID {
+				return append(list[:i], list[i+1:]...), nil
+			}
+		}
+		return nil, ErrNotFound
+	})
+}
+
+// CachedStore держит беседы, сообщения и блокировки в памяти, каждую сущность
+// со своим журналом в каталоге
+type CachedStore struct {
+	conversations *memstore.Store[Conversation]
+	messages      *memstore.Store[Message]
+	blocks        *memstore.Store[Block]
+	// mu делает ChangeConversation атомарным: memstore защищает только отдельные записи
+	mu sync.Mutex
+}
+
+// NewCachedStore загружает данные из JSON-файлов каталога dir (и их журналов)
+func NewCachedStore(dir string) (*CachedStore, error) {
+	conversations, err := memstore.Open(memstore.Options[Conversation]{
+		Snapshot: filepath.Join(dir, "conversations.json"),
+		ID:       func(c Conversation) string { return c.Id },
+		Indexes: map[string]func(Conversation) string{
+			"key": func(c Conversation) string { return c.Key },
+			// Участников двое, у каждого свой индекс
+			"first":  func(c Conversation) string { return c.memberID(0) },
+			"second": func(c Conversation) string { return c.memberID(1) },
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	messages, err := memstore.Open(memstore.Options[Message]{
+		Snapshot: filepath.Join(dir, "messages.json"),
+		ID:       func(m Message) string { return m.Id },
+		Indexes: map[string]func(Message) string{
+			"conversation": func(m Message) string { return m.ConversationID },
+		},
+	})
+	if err != nil {
+		conversations.Close()
+		return nil, err
+	}
+	blocks, err := memstore.Open(memstore.Options[Block]{
+		Snapshot: filepath.Join(dir, "blocks.json"),
+		ID:       func(b Block) string { return b.UserID + ":" + b.BlockedID },
+		Indexes: map[string]func(Block) string{
+			"user": func(b Block) string { return b.UserID },
+		},
+	})
+	if err != nil {
+		conversations.Close()
+		messages.Close()
+		return nil, err
+	}
+	return &CachedStore{conversations: conversations, messages: messages, blocks: blocks}, nil
+}
+
+func (s *CachedStore) Close() error {
+	return errors.Join(s.conversations.Close(), s.messages.Close(), s.blocks.Close())
+}
+
+func (s *CachedStore) GetConversation(id string) (Conversation, error) {
+	c, ok := s.conversations.Get(id)
+	if !ok {
+		return Conversation{}, ErrNotFound
+	}
+	return c, nil
+}
+
+func (s *CachedStore) GetConversationByKey(key string) (Conversation, error) {
+	found := s.conversations.Find("key", key)
+	if len(found) == 0 {
+		return Conversation{}, ErrNotFound
+	}
+	return found[0], nil
+}
+
+func (s *CachedStore) ListConversations(userID string) ([]Conversation, error) {
+	return append(s.conversations.Find("first", userID), s.conversations.Find("second", userID)...), nil
+}
+
+func (s *CachedStore) CreateConversation(c Conversation) error {
+	return s.conversations.Put(c, func(v memstore.View[Conversation]) error {
+		if len(v.Find("key", c.Key)) > 0 {
+			return ErrConversationExists
+		}
+		return nil
+	})
+}
+
+func (s *CachedStore) ChangeConversation(id string, fn func(*Conversation) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.conversations.Get(id)
+	if !ok {
+		return ErrNotFound
+	}
+	// Копия участников, чтобы fn не поменял запись в памяти до сохранения
+	c.Members = slices.Clone(c.Members)
+	if err := fn(&c); err != nil {
+		return err
+	}
+	return s.conversations.Put(c, nil)
+}
+
+func (s *CachedStore) AddMessage(m Message) error {
+	return s.messages.Put(m, nil)
+}
+
+func (s *CachedStore) ListMessages(conversationID, beforeID string, limit int) ([]Message, error) {
+	return pageBefore(s.messages.Find("conversation", conversationID), beforeID, limit), nil
+}
+
+func (s *CachedStore) ListBlocks(userID string) ([]Block, error) {
+	return s.blocks.Find("user", userID), nil
+}
+
+func (s *CachedStore) AddBlock(b Block) error {
+	if _, ok := s.blocks.Get(b.UserID + ":" + b.BlockedID); ok {
+		return nil
+	}
+	return s.blocks.Put(b, nil)
+}
+
+func (s *CachedStore) RemoveBlock(userID, blockedID string) error {
+	ok, err := s.blocks.Delete(userID + ":" + blockedID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+// Package moderation - общее для модерации объявлений, анкет и пользователей.
+package moderation
+
+import (
+	"net/http"
+	"time"
+)
+
+// State - решение модератора. Запись с State скрыта от всех, кроме владельца
+// и модераторов; у видимой записи State нет (nil). Reason показывается владельцу.
+type State struct {
+	Reason string    `json:"reason"`
+	By     string    `json:"by"` // ID модератора
+	At     time.Time `json:"at"`
+	// Ban - запись скрыта из-за бана автора и вернется, когда бан снимут
+	Ban bool `json:"ban,omitempty"`
+}
+
+// New создает решение модератора с текущим временем
+func New(reason, moderatorID string, ban bool) *State {
+	return &State{Reason: reason, By: moderatorID, At: time.Now(), Ban: ban}
+}
+
+// Filter - какие записи показывать в админских списках (?status=hidden|visible)
+type Filter string
+
+const (
+	FilterAll     Filter = ""
+	FilterHidden  Filter = "hidden"
+	FilterVisible Filter = "visible"
+)
+
+// FilterFromRequest читает ?status=; неизвестное значение - ошибка
+func FilterFromRequest(r *http.Request) (Filter, bool) {
+	switch f := Filter(r.URL.Query().Get("status")); f {
+	case FilterAll, FilterHidden, FilterVisible:
+		return f, true
+	default:
+		return f, false
+	}
+}
+
+// Match - подходит ли запись с состоянием s под фильтр
+func (f Filter) Match(s *State) bool {
+	switch f {
+	case FilterHidden:
+		return s != nil
+	case FilterVisible:
+		return s == nil
+	}
+	return true
+}
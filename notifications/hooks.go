@@ -0,0 +1,109 @@
+package notifications
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"talant/applications"
+	"talant/auth"
+	"talant/events"
+	"talant/job"
+	"talant/messages"
+	"talant/searches"
+)
+
+// Обработчики хуков других пакетов; подключаются в main:
+// applications.OnApply(notifications.Applied) и т.д.
+
+// statusText - статус отклика так, как его видит кандидат
+var statusText = map[applications.Status]string{
+	applications.StatusViewed:    "отклик просмотрен",
+	applications.StatusInterview: "приглашение на собеседование",
+	applications.StatusOffer:     "предложение о работе",
+	applications.StatusRejected:  "отказ",
+}
+
+// Applied уведомляет владельца вакансии о новом отклике
+func Applied(a applications.Application, j job.Job) error {
+	from := "кандидата"
+	user, err := auth.GetUser(a.UserID)
+	if err != nil && !errors.Is(err, auth.ErrNotFound) {
+		return err
+	}
+	if err == nil {
+		from = user.Username
+	}
+	return Notify(Notification{
+		UserID: j.UserID,
+		Type:   TypeApplication,
+		Text:   fmt.Sprintf("Новый отклик на вакансию «%s» от %s", j.Title, from),
+		Link:   "/api/applications/" + a.Id,
+		Data:   map[string]string{"application_id": a.Id, "job_id": j.Id},
+	})
+}
+
+// StatusChanged уведомляет кандидата о новом статусе его отклика
+func StatusChanged(a applications.Application, j job.Job) error {
+	return Notify(Notification{
+		UserID: a.UserID,
+		Type:   TypeApplicationStatus,
+		Text:   fmt.Sprintf("Вакансия «%s»: %s", j.Title, statusText[a.Status]),
+		Link:   "/api/applications/" + a.Id,
+		Data:   map[string]string{"application_id": a.Id, "job_id": j.Id, "status": string(a.Status)},
+	})
+}
+
+// MessageSent уведомляет собеседника о новом сообщении. Текст сообщения в
+// уведомление не попадает - его видно в самой беседе.
+func MessageSent(c messages.Conversation, m messages.Message) error {
+	var sender string
+	for _, member := range c.Members {
+		if member.UserID == m.SenderID {
+			sender = member.Username
+		}
+	}
+	for _, member := range c.Members {
+		if member.UserID == m.SenderID {
+			continue
+		}
+		err := Notify(Notification{
+			UserID: member.UserID,
+			Type:   TypeMessage,
+			Text:   fmt.Sprintf("Новое сообщение от %s", sender),
+			Link:   "/api/conversations/" + c.Id + "/messages",
+			Data:   map[string]string{"conversation_id": c.Id, "message_id": m.Id},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EventReminder напоминает участнику о скором начале мероприятия, один раз
+// на каждое время начала
+func EventReminder(e events.Event, reg events.Registration) error {
+	return Notify(Notification{
+		UserID: reg.UserID,
+		Type:   TypeEventReminder,
+		Text:   fmt.Sprintf("«%s» начнется %s в %s", e.Title, e.Date, e.Time),
+		Link:   "/api/events/" + e.Id,
+		Data:   map[string]string{"event_id": e.Id, "registration_id": reg.Id},
+		Key:    "event.reminder:" + events.ReminderKey(e, reg),
+	})
+}
+
+// SearchMatched сообщает владельцу сохраненного поиска о новых совпадениях,
+// если он не отключил уведомления на сайте
+func SearchMatched(s searches.SavedSearch, ids []string) error {
+	if !s.Notify {
+		return nil
+	}
+	return Notify(Notification{
+		UserID: s.UserID,
+		Type:   TypeSearchMatch,
+		Text:   searches.MatchText(s, len(ids)),
+		Link:   searches.Link(s),
+		Data:   map[string]string{"search_id": s.Id, "count": strconv.Itoa(len(ids))},
+	})
+}
@@ -0,0 +1,177 @@
+// Package notifications - уведомления пользователей: об откликах, сменах статуса,
+// сообщениях, скором начале мероприятий и новых результатах сохраненных поисков. Уведомления хранятся для истории и сразу
+// уходят в открытые потоки Server-Sent Events (GET /api/notifications/stream).
+package notifications
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"talant/auth"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Type - о чем уведомление
+type Type string
+
+const (
+	TypeApplication       Type = "application.new"    // отклик на вакансию пользователя
+	TypeApplicationStatus Type = "application.status" // владелец вакансии сменил статус отклика
+	TypeMessage           Type = "message.new"        // новое личное сообщение
+	TypeEventReminder     Type = "event.reminder"     // мероприятие, на которое записан пользователь, скоро начнется
+	TypeSearchMatch       Type = "search.match"       // новые результаты сохраненного поиска
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// Notification - уведомление пользователю
+type Notification struct {
+	Id     string `json:"id"`
+	UserID string `json:"user_id"`
+	Type   Type   `json:"type"`
+	Text   string `json:"text"`
+	// Link - путь API к тому, о чем уведомление
+	Link string `json:"link,omitempty"`
+	// Data - ID связанных записей (application_id, conversation_id, ...) для фронтенда
+	Data map[string]string `json:"data,omitempty"`
+	// Key - ключ для уведомлений, которые нельзя отправлять дважды (напоминания)
+	Key       string     `json:"key,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+}
+
+// Notify сохраняет уведомление и отправляет его в открытые потоки пользователя.
+// Повтор по Key не ошибка: уведомление просто не отправляется второй раз.
+func Notify(n Notification) error {
+	n.Id = uuid.New().String()
+	n.CreatedAt = time.Now()
+	err := store.Add(n)
+	if errors.Is(err, ErrDuplicate) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	streams.publish(n.UserID, notificationEvent(n))
+	return nil
+}
+
+// publishUnread рассылает открытым потокам пользователя новое число непрочитанных,
+// чтобы счетчик обновился во всех вкладках
+func publishUnread(userID string) {
+	count, err := store.CountUnread(userID)
+	if err != nil {
+		fmt.Printf("Ошибка подсчета уведомлений %s: %v\n", userID, err)
+		return
+	}
+	streams.publish(userID, unreadEvent(count))
+}
+
+func writeUnread(w http.ResponseWriter, count int) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Unread int `json:"unread"`
+	}{count})
+}
+
+// ListHandler - история уведомлений, новые первыми (GET /api/notifications;
+// unread=1 - только непрочитанные; limit, before - ID из next_before предыдущей страницы)
+func ListHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	limit := defaultPageSize
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxPageSize)
+	}
+
+	list, err := store.List(user.ID, query.Get("before"), limit+1, query.Get("unread") == "1")
+	if err != nil {
+		http.Error(w, "Error loading notifications", http.StatusInternalServerError)
+		return
+	}
+	unread, err := store.CountUnread(user.ID)
+	if err != nil {
+		http.Error(w, "Error loading notifications", http.StatusInternalServerError)
+		return
+	}
+	var next string
+	if len(list) > limit {
+		list = list[:limit]
+		next = list[limit-1].Id
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Notifications []Notification `json:"notifications"`
+		NextBefore    string         `json:"next_before,omitempty"`
+		Unread        int            `json:"unread"`
+	}{list, next, unread})
+}
+
+// UnreadHandler - число непрочитанных уведомлений (GET /api/notifications/unread)
+func UnreadHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	count, err := store.CountUnread(user.ID)
+	if err != nil {
+		http.Error(w, "Error loading notifications", http.StatusInternalServerError)
+		return
+	}
+	writeUnread(w, count)
+}
+
+// ReadHandler отмечает уведомление прочитанным (POST /api/notifications/{id}/read)
+func ReadHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	err := store.MarkRead(user.ID, r.PathValue("id"), time.Now())
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, "Notification not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error saving notifications", http.StatusInternalServerError)
+		return
+	}
+	publishUnread(user.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReadAllHandler отмечает прочитанными все уведомления (POST /api/notifications/read)
+func ReadAllHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	count, err := store.MarkAllRead(user.ID, time.Now())
+	if err != nil {
+		http.Error(w, "Error saving notifications", http.StatusInternalServerError)
+		return
+	}
+	if count > 0 {
+		publishUnread(user.ID)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Marked int `json:"marked"`
+	}{count})
+}
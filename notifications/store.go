@@ -0,0 +1,224 @@
+package notifications
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"talant/jsonfile"
+	"talant/memstore"
+	"time"
+)
+
+var (
+	// ErrNotFound возвращается хранилищем, если уведомление не найдено
+	ErrNotFound = errors.New("notification not found")
+	// ErrDuplicate - у пользователя уже есть уведомление с тем же ключом
+	ErrDuplicate = errors.New("notification already sent")
+)
+
+// Store - хранилище уведомлений
+type Store interface {
+	// Add возвращает ErrDuplicate, если у пользователя уже есть уведомление с тем же
+	// непустым Key: так напоминание не придет дважды и после перезапуска
+	Add(n Notification) error
+	// List - до limit уведомлений пользователя, новые первыми, старше уведомления
+	// beforeID (пустой - с самого нового)
+	List(userID, beforeID string, limit int, unreadOnly bool) ([]Notification, error)
+	CountUnread(userID string) (int, error)
+	MarkRead(userID, id string, at time.Time) error
+	// MarkAllRead отмечает прочитанными все уведомления и возвращает, сколько их было
+	MarkAllRead(userID string, at time.Time) (int, error)
+}
+
+var store Store = NewJSONStore("notifications.json")
+
+// SetStore подменяет хранилище, с которым работают обработчики
+func SetStore(s Store) {
+	store = s
+}
+
+// pageBefore - общая для хранилищ нарезка страницы из уведомлений пользователя
+// в порядке создания
+func pageBefore(list []Notification, beforeID string, limit int, unreadOnly bool) []Notification {
+	if beforeID != "" {
+		i := slices.IndexFunc(list, func(n Notification) bool { return n.Id == beforeID })
+		if i < 0 {
+			return []Notification{}
+		}
+		list = list[:i]
+	}
+	page := []Notification{}
+	for i := len(list) - 1; i >= 0 && len(page) < limit; i-- {
+		if !unreadOnly || list[i].ReadAt == nil {
+			page = append(page, list[i])
+		}
+	}
+	return page
+}
+
+func countUnread(list []Notification) int {
+	count := 0
+	for _, n := range list {
+		if n.ReadAt == nil {
+			count++
+		}
+	}
+	return count
+}
+
+// JSONStore хранит все уведомления одним массивом в JSON-файле
+type JSONStore struct {
+	file *jsonfile.File[Notification]
+}
+
+func NewJSONStore(path string) *JSONStore {
+	return &JSONStore{file: jsonfile.New[Notification](path)}
+}
+
+func (s *JSONStore) own(userID string) ([]Notification, error) {
+	list, err := s.file.Load()
+	if err != nil {
+		return nil, err
+	}
+	found := []Notification{}
+	for _, n := range list {
+		if n.UserID == userID {
+			found = append(found, n)
+		}
+	}
+	return found, nil
+}
+
+func (s *JSONStore) Add(n Notification) error {
+	return s.file.Update(func(list []Notification) ([]Notification, error) {
+		if n.Key != "" && slices.ContainsFunc(list, func(other Notification) bool {
+			return other.UserID == n.UserID && other.Key == n.Key
+		}) {
+			return nil, ErrDuplicate
+		}
+		return append(list, n), nil
+	})
+}
+
+func (s *JSONStore) List(userID, beforeID string, limit int, unreadOnly bool) ([]Notification, error) {
+	list, err := s.own(userID)
+	if err != nil {
+		return nil, err
+	}
+	return pageBefore(list, beforeID, limit, unreadOnly), nil
+}
+
+func (s *JSONStore) CountUnread(userID string) (int, error) {
+	list, err := s.own(userID)
+	if err != nil {
+		return 0, err
+	}
+	return countUnread(list), nil
+}
+
+func (s *JSONStore) MarkRead(userID, id string, at time.Time) error {
+	return s.file.Update(func(list []Notification) ([]Notification, error) {
+		for i := range list {
+			if list[i].Id == id && list[i].UserID == userID {
+				if list[i].ReadAt == nil {
+					list[i].ReadAt = &at
+				}
+				return list, nil
+			}
+		}
+		return nil, ErrNotFound
+	})
+}
+
+func (s *JSONStore) MarkAllRead(userID string, at time.Time) (int, error) {
+	count := 0
+	err := s.file.Update(func(list []Notification) ([]Notification, error) {
+		for i := range list {
+			if list[i].UserID == userID && list[i].ReadAt == nil {
+				list[i].ReadAt = &at
+				count++
+			}
+		}
+		return list, nil
+	})
+	return count, err
+}
+
+// CachedStore держит уведомления в памяти с индексом по пользователю
+type CachedStore struct {
+	m *memstore.Store[Notification]
+	// mu делает MarkAllRead атомарным: memstore защищает только отдельные записи
+	mu sync.Mutex
+}
+
+// NewCachedStore загружает уведомления из path (и журнала path + ".journal")
+func NewCachedStore(path string) (*CachedStore, error) {
+	m, err := memstore.Open(memstore.Options[Notification]{
+		Snapshot: path,
+		ID:       func(n Notification) string { return n.Id },
+		Indexes: map[string]func(Notification) string{
+			"user": func(n Notification) string { return n.UserID },
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &CachedStore{m: m}, nil
+}
+
+func (s *CachedStore) Close() error {
+	return s.m.Close()
+}
+
+func (s *CachedStore) Add(n Notification) error {
+	return s.m.Put(n, func(v memstore.View[Notification]) error {
+		if n.Key == "" {
+			return nil
+		}
+		for _, other := range v.Find("user", n.UserID) {
+			if other.Key == n.Key {
+				return ErrDuplicate
+			}
+		}
+		return nil
+	})
+}
+
+func (s *CachedStore) List(userID, beforeID string, limit int, unreadOnly bool) ([]Notification, error) {
+	return pageBefore(s.m.Find("user", userID), beforeID, limit, unreadOnly), nil
+}
+
+func (s *CachedStore) CountUnread(userID string) (int, error) {
+	return countUnread(s.m.Find("user", userID)), nil
+}
+
+func (s *CachedStore) MarkRead(userID, id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n, ok := s.m.Get(id)
+	if !ok || n.UserID != userID {
+		return ErrNotFound
+	}
+	if n.ReadAt != nil {
+		return nil
+	}
+	n.ReadAt = &at
+	return s.m.Put(n, nil)
+}
+
+func (s *CachedStore) MarkAllRead(userID string, at time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := 0
+	for _, n := range s.m.Find("user", userID) {
+		if n.ReadAt != nil {
+			continue
+		}
+		n.ReadAt = &at
+		if err := s.m.Put(n, nil); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
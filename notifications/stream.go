@@ -0,0 +1,204 @@
+package notifications
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"talant/auth"
+	"time"
+)
+
+// Поток уведомлений - Server-Sent Events. События:
+//
+//	event: notification - новое уведомление (id: - его ID, data: - JSON уведомления)
+//	event: unread       - число непрочитанных: при подключении и после отметки прочитанными
+//
+// Переподключившийся браузер присылает Last-Event-ID, и поток сначала досылает
+// пропущенные уведомления (не больше одной страницы истории).
+
+// heartbeat - как часто поток шлет комментарий-пинг, чтобы прокси не закрыли
+// соединение, и заодно проверяет, что сессия пользователя еще активна
+const heartbeat = 25 * time.Second
+
+// streamBuffer - сколько событий ждет медленного клиента; лишние отбрасываются,
+// они все равно есть в истории
+const streamBuffer = 16
+
+// event - одно событие потока
+type event struct {
+	id   string
+	name string
+	data []byte
+}
+
+func notificationEvent(n Notification) event {
+	data, _ := json.Marshal(n)
+	return event{id: n.Id, name: "notification", data: data}
+}
+
+func unreadEvent(count int) event {
+	data, _ := json.Marshal(struct {
+		Unread int `json:"unread"`
+	}{count})
+	return event{name: "unread", data: data}
+}
+
+// hub - открытые потоки по пользователям
+type hub struct {
+	mu     sync.Mutex
+	users  map[string]map[chan event]struct{}
+	closed bool
+}
+
+var streams = &hub{users: map[string]map[chan event]struct{}{}}
+
+// subscribe открывает канал событий пользователя; cancel его закрывает.
+// После CloseStreams возвращает уже закрытый канал.
+func (h *hub) subscribe(userID string) (ch chan event, cancel func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ch = make(chan event, streamBuffer)
+	if h.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	if h.users[userID] == nil {
+		h.users[userID] = map[chan event]struct{}{}
+	}
+	h.users[userID][ch] = struct{}{}
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.users[userID][ch]; ok {
+			delete(h.users[userID], ch)
+			if len(h.users[userID]) == 0 {
+				delete(h.users, userID)
+			}
+			close(ch)
+		}
+	}
+}
+
+func (h *hub) publish(userID string, e event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.users[userID] {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// CloseStreams закрывает все открытые потоки. Вызывается при остановке сервера:
+// иначе Shutdown ждал бы потоки, которые сами не заканчиваются.
+func CloseStreams() {
+	streams.mu.Lock()
+	defer streams.mu.Unlock()
+	streams.closed = true
+	for userID, chans := range streams.users {
+		for ch := range chans {
+			close(ch)
+		}
+		delete(streams.users, userID)
+	}
+}
+
+// missed - уведомления новее lastID в порядке создания. Если lastID не нашелся
+// на первой странице истории, досылать нечего: клиент давно отключался и
+// загрузит историю сам.
+func missed(userID, lastID string) ([]Notification, error) {
+	list, err := store.List(userID, "", maxPageSize, false)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(list, func(n Notification) bool { return n.Id == lastID })
+	if i < 0 {
+		return nil, nil
+	}
+	list = list[:i]
+	slices.Reverse(list)
+	return list, nil
+}
+
+// StreamHandler - поток уведомлений текущего пользователя (GET /api/notifications/stream)
+func StreamHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	// Подписываемся до чтения истории, чтобы не потерять уведомление между ними
+	ch, cancel := streams.subscribe(user.ID)
+	defer cancel()
+
+	var replay []Notification
+	if lastID := r.Header.Get("Last-Event-ID"); lastID != "" {
+		var err error
+		if replay, err = missed(user.ID, lastID); err != nil {
+			http.Error(w, "Error loading notifications", http.StatusInternalServerError)
+			return
+		}
+	}
+	unread, err := store.CountUnread(user.ID)
+	if err != nil {
+		http.Error(w, "Error loading notifications", http.StatusInternalServerError)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Чтобы nginx не копил события в буфере
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	write := func(e event) error {
+		if e.id != "" {
+			fmt.Fprintf(w, "id: %s\n", e.id)
+		}
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.name, e.data)
+		return rc.Flush()
+	}
+
+	fmt.Fprint(w, "retry: 5000\n\n")
+	sent := map[string]bool{}
+	for _, n := range replay {
+		sent[n.Id] = true
+		if write(notificationEvent(n)) != nil {
+			return
+		}
+	}
+	if write(unreadEvent(unread)) != nil {
+		return
+	}
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-ch:
+			if !ok {
+				return
+			}
+			if e.id != "" && sent[e.id] {
+				continue
+			}
+			if write(e) != nil {
+				return
+			}
+		case <-ticker.C:
+			// Сессию завершили (выход, бан) - закрываем поток
+			if !auth.SessionActive(user.SessionID) {
+				return
+			}
+			fmt.Fprint(w, ": ping\n\n")
+			if rc.Flush() != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package salary
+
+import (
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// number - сумма в тексте: разряды могут быть разделены пробелами ("150 000"),
+// за числом может идти множитель ("150к", "150 тыс")
+var number = regexp.MustCompile(`\d+(?:[ \x{a0}]\d{3})*(?:\s*(к|k|тыс))?`)
+
+// Признаки валюты, периода и налогов в тексте (текст уже в нижнем регистре)
+var (
+	currencyWords = []struct {
+		code  string
+		words []string
+	}{
+		{"USD", []string{"$", "usd", "долл"}},
+		{"EUR", []string{"€", "eur", "евро"}},
+		{"RUB", []string{"₽", "rub", "руб", "р."}},
+	}
+	periodWords = []struct {
+		period Period
+		words  []string
+	}{
+		{PeriodHour, []string{"час", "hour", "/h"}},
+		{PeriodDay, []string{"день", "смен", "day"}},
+		{PeriodYear, []string{"год", "year"}},
+		{PeriodMonth, []string{"мес", "month"}},
+	}
+	// netWords ищутся целыми словами: "net" не должно найтись в "internet"
+	netWords   = []string{"на руки", "чистыми", "net"}
+	grossWords = []string{"до вычета", "до налог", "gross", "гросс"}
+)
+
+func containsAny(s string, words []string) bool {
+	for _, w := range words {
+		if strings.Contains(s, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// wordIndex - позиция первого вхождения w в s целым словом (или фразой), -1 если нет
+func wordIndex(s, w string) int {
+	isLetter := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+	for from := 0; ; {
+		i := strings.Index(s[from:], w)
+		if i < 0 {
+			return -1
+		}
+		i += from
+		before, _ := utf8.DecodeLastRuneInString(s[:i])
+		after, _ := utf8.DecodeRuneInString(s[i+len(w):])
+		if !isLetter(before) && !isLetter(after) {
+			return i
+		}
+		from = i + len(w)
+	}
+}
+
+func hasWord(s string, words []string) bool {
+	for _, w := range words {
+		if wordIndex(s, w) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// currency - валюта, которая упомянута в тексте раньше других: по словам из
+// currencyWords или по коду из таблицы курсов. "" - валюты в тексте нет.
+func currency(text string) string {
+	code, first := "", len(text)
+	found := func(c string, i int) {
+		if i >= 0 && i < first {
+			code, first = c, i
+		}
+	}
+	for _, c := range currencyWords {
+		for _, w := range c.words {
+			found(c.code, strings.Index(text, w))
+		}
+	}
+	// Коды по алфавиту, чтобы разбор не зависел от порядка обхода map
+	for _, c := range slices.Sorted(maps.Keys(rates)) {
+		found(c, wordIndex(text, strings.ToLower(c)))
+	}
+	return code
+}
+
+// Parse разбирает зарплату из свободного текста: "100000-150000", "от 220000$",
+// "до 200к ₽ на руки", "1500 руб/час". Валюта без указания - базовая, период -
+// месяц. ok == false, если сумм в тексте нет.
+func Parse(text string) (*Salary, bool) {
+	text = strings.ToLower(text)
+	matches := number.FindAllStringSubmatchIndex(text, 2)
+	if len(matches) == 0 {
+		return nil, false
+	}
+	var nums []int
+	thousands := false
+	for _, m := range matches {
+		digits := strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, text[m[0]:m[1]])
+		n, err := strconv.Atoi(digits)
+		if err != nil {
+			return nil, false
+		}
+		nums = append(nums, n)
+		if m[2] >= 0 {
+			thousands = true
+		}
+	}
+	// "100-150к": множитель относится к обоим числам
+	if thousands {
+		for i, n := range nums {
+			if n < 1000 {
+				nums[i] = n * 1000
+			}
+		}
+	}
+
+	s := &Salary{Currency: base, Period: PeriodMonth}
+	prefix := text[:matches[0][0]]
+	switch {
+	case len(nums) == 2:
+		s.Min, s.Max = nums[0], nums[1]
+	case hasWord(prefix, []string{"до"}):
+		s.Max = nums[0]
+	case hasWord(prefix, []string{"от"}):
+		s.Min = nums[0]
+	default:
+		s.Min, s.Max = nums[0], nums[0]
+	}
+	if s.Min == 0 && s.Max == 0 {
+		return nil, false
+	}
+	if s.Max > 0 && s.Min > s.Max {
+		s.Min, s.Max = s.Max, s.Min
+	}
+
+	// Слова вокруг чисел: "руб" из "рублей в час" не должно найтись в самих числах
+	words := number.ReplaceAllString(text, " ")
+	if code := currency(words); code != "" {
+		s.Currency = code
+	}
+	for _, p := range periodWords {
+		if containsAny(words, p.words) {
+			s.Period = p.period
+			break
+		}
+	}
+	switch {
+	case hasWord(words, netWords):
+		s.Taxes = TaxesNet
+	case containsAny(words, grossWords):
+		s.Taxes = TaxesGross
+	}
+	return s, true
+}
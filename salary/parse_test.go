@@ -0,0 +1,35 @@
+package salary
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text string
+		want Salary
+	}{
+		{"100000-150000", Salary{Min: 100000, Max: 150000, Currency: "RUB", Period: PeriodMonth}},
+		{"от 220000$", Salary{Min: 220000, Currency: "USD", Period: PeriodMonth}},
+		{"до 200к ₽ на руки", Salary{Max: 200000, Currency: "RUB", Period: PeriodMonth, Taxes: TaxesNet}},
+		{"1500 руб/час", Salary{Min: 1500, Max: 1500, Currency: "RUB", Period: PeriodHour}},
+		{"3000 евро gross", Salary{Min: 3000, Max: 3000, Currency: "EUR", Period: PeriodMonth, Taxes: TaxesGross}},
+		// Из нескольких валют - та, что упомянута первой
+		{"5000 usd, можно в eur", Salary{Min: 5000, Max: 5000, Currency: "USD", Period: PeriodMonth}},
+		{"3000 € или $", Salary{Min: 3000, Max: 3000, Currency: "EUR", Period: PeriodMonth}},
+		// "от", "до" и "net" - только целые слова
+		{"Доход от 100000", Salary{Min: 100000, Currency: "RUB", Period: PeriodMonth}},
+		{"120000 rub, internet compensation", Salary{Min: 120000, Max: 120000, Currency: "RUB", Period: PeriodMonth}},
+		{"120000 rub net", Salary{Min: 120000, Max: 120000, Currency: "RUB", Period: PeriodMonth, Taxes: TaxesNet}},
+	}
+	for _, tt := range tests {
+		// Несколько прогонов: результат не должен зависеть от порядка обхода map
+		for range 20 {
+			got, ok := Parse(tt.text)
+			if !ok || *got != tt.want {
+				t.Fatalf("Parse(%q) = %+v, %v; want %+v", tt.text, got, ok, tt.want)
+			}
+		}
+	}
+	if got, ok := Parse("по договоренности"); ok {
+		t.Errorf("Parse without numbers = %+v, want not ok", got)
+	}
+}
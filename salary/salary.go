@@ -0,0 +1,249 @@
+// Package salary - зарплата в вакансиях и анкетах: диапазон, валюта, период и
+// налоги. Здесь же разбор свободного текста ("от 220000$") и пересчет сумм между
+// валютами по локальной таблице курсов, чтобы зарплаты можно было сравнивать.
+package salary
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Period - за какой срок указана сумма
+type Period string
+
+const (
+	PeriodHour  Period = "hour"
+	PeriodDay   Period = "day"
+	PeriodMonth Period = "month"
+	PeriodYear  Period = "year"
+)
+
+// Taxes - до или после вычета налогов; пустое значение - не указано
+type Taxes string
+
+const (
+	TaxesGross Taxes = "gross"
+	TaxesNet   Taxes = "net"
+)
+
+// Для сравнения все суммы приводятся к месяцу
+const (
+	hoursPerMonth = 160
+	daysPerMonth  = 21
+)
+
+var monthly = map[Period]float64{
+	PeriodHour:  hoursPerMonth,
+	PeriodDay:   daysPerMonth,
+	PeriodMonth: 1,
+	PeriodYear:  1.0 / 12,
+}
+
+// Salary - зарплата. Нулевая граница - открытая: {Min: 120000} - "от 120 000".
+type Salary struct {
+	Min      int    `json:"min,omitempty"`
+	Max      int    `json:"max,omitempty"`
+	Currency string `json:"currency"` // код ISO 4217: RUB, USD, EUR
+	Period   Period `json:"period"`
+	Taxes    Taxes  `json:"taxes,omitempty"`
+}
+
+var (
+	// base - валюта, к которой приводятся суммы при сравнении
+	base = "RUB"
+	// rates - сколько единиц base стоит единица валюты
+	rates = map[string]float64{"RUB": 1, "USD": 90, "EUR": 100}
+)
+
+// Configure задает базовую валюту и курсы остальных к ней
+func Configure(baseCurrency string, table map[string]float64) {
+	base = strings.ToUpper(baseCurrency)
+	rates = map[string]float64{base: 1}
+	for code, rate := range table {
+		if code = strings.ToUpper(code); code != base {
+			rates[code] = rate
+		}
+	}
+}
+
+// Base - базовая валюта
+func Base() string {
+	return base
+}
+
+// Known - есть ли валюта в таблице курсов
+func Known(currency string) bool {
+	_, ok := rates[currency]
+	return ok
+}
+
+// Convert пересчитывает сумму из одной валюты в другую
+func Convert(amount float64, from, to string) (float64, bool) {
+	rateFrom, okFrom := rates[from]
+	rateTo, okTo := rates[to]
+	if !okFrom || !okTo {
+		return 0, false
+	}
+	return amount * rateFrom / rateTo, true
+}
+
+// Monthly - границы зарплаты в месяц в валюте currency. Открытая граница
+// считается равной другой: "от 120 000" - это 120 000 и для верхней границы.
+// ok == false, если сумм нет или валюту не пересчитать.
+func (s Salary) Monthly(currency string) (min, max float64, ok bool) {
+	lo, hi := s.Min, s.Max
+	if lo == 0 {
+		lo = hi
+	}
+	if hi == 0 {
+		hi = lo
+	}
+	if hi == 0 {
+		return 0, 0, false
+	}
+	factor, ok := monthly[s.Period]
+	if !ok {
+		factor = 1
+	}
+	if min, ok = Convert(float64(lo)*factor, s.Currency, currency); !ok {
+		return 0, 0, false
+	}
+	max, _ = Convert(float64(hi)*factor, s.Currency, currency)
+	return min, max, true
+}
+
+// Overlaps - пересекается ли месячная зарплата с диапазоном [from, to] в валюте
+// currency; нулевая граница диапазона не ограничивает
+func (s Salary) Overlaps(from, to float64, currency string) bool {
+	min, max, ok := s.Monthly(currency)
+	if !ok {
+		return false
+	}
+	// Копейки от пересчета не должны отсекать границу
+	min, max = math.Round(min), math.Round(max)
+	return (from <= 0 || max >= from) && (to <= 0 || min <= to)
+}
+
+var symbols = map[string]string{"RUB": "₽", "USD": "$", "EUR": "€"}
+
+var periodText = map[Period]string{
+	PeriodHour:  "в час",
+	PeriodDay:   "в день",
+	PeriodMonth: "в месяц",
+	PeriodYear:  "в год",
+}
+
+// formatAmount пишет сумму с пробелами между разрядами: 120 000
+func formatAmount(n int) string {
+	digits := strconv.Itoa(n)
+	var b strings.Builder
+	for i, r := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// String - зарплата текстом, как ее показывают пользователю
+func (s Salary) String() string {
+	var text string
+	switch {
+	case s.Min > 0 && s.Max > 0 && s.Min != s.Max:
+		text = formatAmount(s.Min) + " – " + formatAmount(s.Max)
+	case s.Min > 0 && s.Max > 0:
+		text = formatAmount(s.Min)
+	case s.Min > 0:
+		text = "от " + formatAmount(s.Min)
+	default:
+		text = "до " + formatAmount(s.Max)
+	}
+	currency := s.Currency
+	if symbol, ok := symbols[currency]; ok {
+		currency = symbol
+	}
+	text += " " + currency
+	if p, ok := periodText[s.Period]; ok {
+		text += " " + p
+	}
+	switch s.Taxes {
+	case TaxesNet:
+		text += " на руки"
+	case TaxesGross:
+		text += " до вычета налогов"
+	}
+	return text
+}
+
+// FormFields - поля формы с типизированной зарплатой
+var FormFields = []string{"salary_min", "salary_max", "salary_currency", "salary_period", "salary_taxes"}
+
+// Validate проверяет зарплату и подставляет значения по умолчанию: базовую
+// валюту и месяц
+func (s *Salary) Validate() error {
+	if s.Min < 0 || s.Max < 0 {
+		return errors.New("Salary must not be negative")
+	}
+	if s.Min == 0 && s.Max == 0 {
+		return errors.New("Salary needs salary_min or salary_max")
+	}
+	if s.Max > 0 && s.Min > s.Max {
+		return errors.New("salary_min is greater than salary_max")
+	}
+	if s.Currency == "" {
+		s.Currency = base
+	}
+	s.Currency = strings.ToUpper(s.Currency)
+	if !Known(s.Currency) {
+		return fmt.Errorf("Unknown currency: %s", s.Currency)
+	}
+	if s.Period == "" {
+		s.Period = PeriodMonth
+	}
+	if _, ok := monthly[s.Period]; !ok {
+		return errors.New("Invalid salary_period: must be hour, day, month or year")
+	}
+	if s.Taxes != "" && s.Taxes != TaxesGross && s.Taxes != TaxesNet {
+		return errors.New("Invalid salary_taxes: must be gross or net")
+	}
+	return nil
+}
+
+// Read читает зарплату из формы: типизированные поля FormFields, а без них -
+// разобранный текст поля salary. Возвращает и текст для показа: если поле
+// salary пустое, он собирается из типизированных полей. Зарплаты нет - nil.
+func Read(form url.Values) (string, *Salary, error) {
+	text := strings.TrimSpace(form.Get("salary"))
+	if form.Get("salary_min") == "" && form.Get("salary_max") == "" {
+		if s, ok := Parse(text); ok {
+			return text, s, nil
+		}
+		return text, nil, nil
+	}
+	s := &Salary{
+		Currency: strings.TrimSpace(form.Get("salary_currency")),
+		Period:   Period(form.Get("salary_period")),
+		Taxes:    Taxes(form.Get("salary_taxes")),
+	}
+	for field, dst := range map[string]*int{"salary_min": &s.Min, "salary_max": &s.Max} {
+		if v := form.Get(field); v != "" {
+			n, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil {
+				return "", nil, fmt.Errorf("Invalid %s", field)
+			}
+			*dst = n
+		}
+	}
+	if err := s.Validate(); err != nil {
+		return "", nil, err
+	}
+	if text == "" {
+		text = s.String()
+	}
+	return text, s, nil
+}
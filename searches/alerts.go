@@ -0,0 +1,121 @@
+package searches
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"talant/auth"
+	"time"
+)
+
+// matchHooks получают поиск и ID новых совпадений. searches не знает про
+// уведомления, они подключаются через OnMatch; письма отправляет сам пакет.
+var matchHooks []func(s SavedSearch, ids []string) error
+
+// OnMatch подключает обработчик новых совпадений
+func OnMatch(hook func(s SavedSearch, ids []string) error) {
+	matchHooks = append(matchHooks, hook)
+}
+
+// plural выбирает форму слова для числа n: одна, две-четыре, пять
+func plural(n int, one, few, many string) string {
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return one
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20):
+		return few
+	}
+	return many
+}
+
+// MatchText - текст оповещения о n новых совпадениях
+func MatchText(s SavedSearch, n int) string {
+	var what string
+	switch s.Kind {
+	case KindJobs:
+		what = plural(n, "новая вакансия", "новые вакансии", "новых вакансий")
+	default:
+		what = plural(n, "новая анкета", "новые анкеты", "новых анкет")
+	}
+	return fmt.Sprintf("По поиску «%s» %d %s", s.Name, n, what)
+}
+
+// Link - путь API к результатам поиска
+func Link(s SavedSearch) string {
+	return "/api/searches/" + s.Id + "/results"
+}
+
+// alert оповещает владельца поиска. Ошибки только пишутся в лог: оповещение
+// не повторяется, иначе одна сломанная почта заваливала бы дублями уведомлений.
+func alert(s SavedSearch, ids []string) {
+	for _, hook := range matchHooks {
+		if err := hook(s, ids); err != nil {
+			fmt.Printf("Ошибка оповещения по поиску %s: %v\n", s.Id, err)
+		}
+	}
+	if !s.Email {
+		return
+	}
+	err := auth.MailUser(s.UserID, "Новые результаты поиска", MatchText(s, len(ids)), Link(s))
+	switch {
+	case errors.Is(err, auth.ErrEmailNotVerified):
+		// Почту успели сменить, а новую еще не подтвердили
+	case err != nil:
+		fmt.Printf("Ошибка письма по поиску %s: %v\n", s.Id, err)
+	}
+}
+
+// check повторяет все поиски и оповещает о записях, которых не было в прошлый раз
+func check(now time.Time) error {
+	list, err := store.List()
+	if err != nil {
+		return err
+	}
+	for _, s := range list {
+		_, ids, err := find(s)
+		if err != nil {
+			// Сломанный поиск не должен останавливать оповещения остальных
+			fmt.Printf("Ошибка сохраненного поиска %s: %v\n", s.Id, err)
+			continue
+		}
+		matched := make(map[string]bool, len(s.Matched))
+		for _, id := range s.Matched {
+			matched[id] = true
+		}
+		var fresh []string
+		for _, id := range ids {
+			if !matched[id] {
+				fresh = append(fresh, id)
+			}
+		}
+		// Пропавшие записи тоже меняют список: вернувшаяся запись снова новая
+		if len(fresh) == 0 && len(ids) == len(s.Matched) {
+			continue
+		}
+		var alertedAt *time.Time
+		if len(fresh) > 0 && (s.Notify || s.Email) {
+			alert(s, fresh)
+			alertedAt = &now
+		}
+		if err := store.SetMatched(s.Id, s.Query, ids, alertedAt); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run раз в interval проверяет сохраненные поиски, пока не отменен ctx
+func Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := check(time.Now()); err != nil {
+			fmt.Printf("Ошибка проверки сохраненных поисков: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,427 @@
+// Package searches - сохраненные поиски анкет и вакансий. Фоновая проверка
+// периодически повторяет каждый поиск и оповещает владельца о новых совпадениях:
+// уведомлением на сайте и, если он попросил, письмом.
+package searches
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"talant/ankety"
+	"talant/auth"
+	"talant/job"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Kind - что ищет поиск
+type Kind string
+
+const (
+	KindAnkety Kind = "ankety"
+	KindJobs   Kind = "jobs"
+)
+
+const (
+	maxSearches    = 20  // поисков у одного пользователя
+	maxNameLength  = 100 // символов в названии
+	maxValueLength = 200 // символов в значении фильтра
+)
+
+// SavedSearch - сохраненный поиск
+type SavedSearch struct {
+	Id     string `json:"id"`
+	UserID string `json:"user_id"`
+	Name   string `json:"name"`
+	Kind   Kind   `json:"kind"`
+	// Query - параметры фильтра в виде строки запроса, как у поиска
+	// (/api/ankety/search?<Query>)
+	Query string `json:"query"`
+	// Notify - оповещать уведомлением на сайте, Email - письмом
+	Notify bool `json:"notify"`
+	Email  bool `json:"email"`
+	// Matched - ID записей, найденных при последней проверке; новыми считаются
+	// записи не из этого списка - созданные или измененные так, что стали подходить
+	Matched   []string   `json:"matched"`
+	AlertedAt *time.Time `json:"alerted_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// searchView - поиск в ответе: вместо списка найденных только их число
+type searchView struct {
+	SavedSearch
+	Matched    []string `json:"matched,omitempty"`
+	MatchCount int      `json:"match_count"`
+}
+
+func newView(s SavedSearch) searchView {
+	return searchView{SavedSearch: s, MatchCount: len(s.Matched)}
+}
+
+// filterParams - параметры фильтра для вида поиска
+func filterParams(kind Kind) []string {
+	switch kind {
+	case KindAnkety:
+		return ankety.FilterParams
+	case KindJobs:
+		return job.FilterParams
+	}
+	return nil
+}
+
+// readFilter собирает Query из непустых параметров фильтра в форме
+func readFilter(kind Kind, form url.Values) (string, error) {
+	query := url.Values{}
+	for _, param := range filterParams(kind) {
+		v := strings.TrimSpace(form.Get(param))
+		if v == "" {
+			continue
+		}
+		if utf8.RuneCountInString(v) > maxValueLength {
+			return "", fmt.Errorf("Filter %s is too long (max %d characters)", param, maxValueLength)
+		}
+		query.Set(param, v)
+	}
+	if len(query) == 0 {
+		return "", fmt.Errorf("At least one filter is required: %s", strings.Join(filterParams(kind), ", "))
+	}
+	var err error
+	switch kind {
+	case KindAnkety:
+		_, err = ankety.FilterFromQuery(query)
+	case KindJobs:
+		_, err = job.FilterFromQuery(query)
+	}
+	if err != nil {
+		return "", err
+	}
+	return query.Encode(), nil
+}
+
+// hasFilter - передан ли в форме хоть один параметр фильтра
+func hasFilter(kind Kind, form url.Values) bool {
+	return slices.ContainsFunc(filterParams(kind), form.Has)
+}
+
+// defaultName - название поиска по значениям фильтра
+func defaultName(kind Kind, rawQuery string) string {
+	query, _ := url.ParseQuery(rawQuery)
+	var values []string
+	for _, param := range filterParams(kind) {
+		if v := query.Get(param); v != "" {
+			values = append(values, v)
+		}
+	}
+	name := strings.Join(values, ", ")
+	if utf8.RuneCountInString(name) > maxNameLength {
+		name = string([]rune(name)[:maxNameLength])
+	}
+	return name
+}
+
+func readName(r *http.Request) (string, error) {
+	name := strings.TrimSpace(r.FormValue("name"))
+	if utf8.RuneCountInString(name) > maxNameLength {
+		return "", fmt.Errorf("Name is too long (max %d characters)", maxNameLength)
+	}
+	return name, nil
+}
+
+// readBool читает флаг field, если он передан
+func readBool(r *http.Request, field string, dst *bool) error {
+	if !r.Form.Has(field) {
+		return nil
+	}
+	v, err := strconv.ParseBool(r.FormValue(field))
+	if err != nil {
+		return fmt.Errorf("Invalid %s", field)
+	}
+	*dst = v
+	return nil
+}
+
+// readAlerts читает переданные флаги notify и email. Письма уходят только на
+// подтвержденную почту, поэтому без нее включить их нельзя. Ошибка уже
+// отправлена клиентом.
+func readAlerts(w http.ResponseWriter, r *http.Request, s *SavedSearch) error {
+	wasEmail := s.Email
+	for field, dst := range map[string]*bool{"notify": &s.Notify, "email": &s.Email} {
+		if err := readBool(r, field, dst); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return err
+		}
+	}
+	if !s.Email || wasEmail {
+		return nil
+	}
+	user, err := auth.GetUser(s.UserID)
+	if err != nil {
+		http.Error(w, "Error loading user", http.StatusInternalServerError)
+		return err
+	}
+	if !user.EmailVerified {
+		http.Error(w, "Confirm your email to receive alerts by mail", http.StatusBadRequest)
+		return auth.ErrEmailNotVerified
+	}
+	return nil
+}
+
+// find выполняет поиск и возвращает найденные записи с их ID. Записи самого
+// владельца поиска в результаты не попадают.
+func find(s SavedSearch) (any, []string, error) {
+	query, err := url.ParseQuery(s.Query)
+	if err != nil {
+		return nil, nil, err
+	}
+	ids := []string{}
+	switch s.Kind {
+	case KindAnkety:
+		f, err := ankety.FilterFromQuery(query)
+		if err != nil {
+			return nil, nil, err
+		}
+		list, err := ankety.Search(f)
+		if err != nil {
+			return nil, nil, err
+		}
+		found := []ankety.Ankety{}
+		for _, a := range list {
+			if a.UserId != s.UserID {
+				found = append(found, a)
+				ids = append(ids, a.Id)
+			}
+		}
+		return found, ids, nil
+	case KindJobs:
+		f, err := job.FilterFromQuery(query)
+		if err != nil {
+			return nil, nil, err
+		}
+		list, err := job.Search(f)
+		if err != nil {
+			return nil, nil, err
+		}
+		found := []job.Job{}
+		for _, j := range list {
+			if j.UserID != s.UserID {
+				found = append(found, j)
+				ids = append(ids, j.Id)
+			}
+		}
+		return found, ids, nil
+	}
+	return nil, nil, fmt.Errorf("unknown search kind %q", s.Kind)
+}
+
+// loadOwned загружает поиск из пути запроса; чужой поиск - 404, как несуществующий
+func loadOwned(w http.ResponseWriter, r *http.Request) (SavedSearch, bool) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return SavedSearch{}, false
+	}
+	s, err := store.Get(r.PathValue("id"))
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		http.Error(w, "Error loading searches", http.StatusInternalServerError)
+		return SavedSearch{}, false
+	}
+	if err != nil || s.UserID != user.ID {
+		http.Error(w, "Search not found", http.StatusNotFound)
+		return SavedSearch{}, false
+	}
+	return s, true
+}
+
+func writeSearch(w http.ResponseWriter, status int, s SavedSearch) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(newView(s))
+}
+
+// CreateHandler сохраняет поиск (POST /api/searches, поля kind - ankety или jobs,
+// name, notify, email и параметры фильтра, как у поиска этого вида). Найденное
+// сейчас запоминается: оповещения будут только о новых совпадениях.
+func CreateHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad form", http.StatusBadRequest)
+		return
+	}
+	s := SavedSearch{
+		Id:     uuid.New().String(),
+		UserID: user.ID,
+		Kind:   Kind(r.FormValue("kind")),
+		Notify: true,
+	}
+	if filterParams(s.Kind) == nil {
+		http.Error(w, "Kind must be ankety or jobs", http.StatusBadRequest)
+		return
+	}
+	var err error
+	if s.Query, err = readFilter(s.Kind, r.Form); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if s.Name, err = readName(r); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if s.Name == "" {
+		s.Name = defaultName(s.Kind, s.Query)
+	}
+	if err := readAlerts(w, r, &s); err != nil {
+		return
+	}
+
+	list, err := store.ListByUser(user.ID)
+	if err != nil {
+		http.Error(w, "Error loading searches", http.StatusInternalServerError)
+		return
+	}
+	if len(list) >= maxSearches {
+		http.Error(w, fmt.Sprintf("Too many saved searches (max %d)", maxSearches), http.StatusConflict)
+		return
+	}
+
+	if _, s.Matched, err = find(s); err != nil {
+		http.Error(w, "Error running search", http.StatusInternalServerError)
+		return
+	}
+	s.CreatedAt = time.Now()
+	s.UpdatedAt = s.CreatedAt
+	if err := store.Create(s); err != nil {
+		http.Error(w, "Error saving searches", http.StatusInternalServerError)
+		return
+	}
+	writeSearch(w, http.StatusCreated, s)
+}
+
+// ListHandler - поиски текущего пользователя (GET /api/searches)
+func ListHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	list, err := store.ListByUser(user.ID)
+	if err != nil {
+		http.Error(w, "Error loading searches", http.StatusInternalServerError)
+		return
+	}
+	result := make([]searchView, 0, len(list))
+	for _, s := range list {
+		result = append(result, newView(s))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetHandler - один поиск (GET /api/searches/{id})
+func GetHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := loadOwned(w, r)
+	if !ok {
+		return
+	}
+	writeSearch(w, http.StatusOK, s)
+}
+
+// UpdateHandler меняет поиск (PUT /api/searches/{id}). Меняются только переданные
+// поля: name, notify, email; если передан хоть один параметр фильтра, фильтр
+// заменяется целиком, и найденное по новому фильтру оповещений не вызывает.
+func UpdateHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := loadOwned(w, r)
+	if !ok {
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad form", http.StatusBadRequest)
+		return
+	}
+
+	if r.Form.Has("name") {
+		name, err := readName(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.Name = name
+	}
+	filterChanged := hasFilter(s.Kind, r.Form)
+	if filterChanged {
+		query, err := readFilter(s.Kind, r.Form)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.Query = query
+	}
+	if s.Name == "" {
+		s.Name = defaultName(s.Kind, s.Query)
+	}
+	if err := readAlerts(w, r, &s); err != nil {
+		return
+	}
+
+	var matched []string
+	if filterChanged {
+		var err error
+		if _, matched, err = find(s); err != nil {
+			http.Error(w, "Error running search", http.StatusInternalServerError)
+			return
+		}
+	}
+	s.UpdatedAt = time.Now()
+	if err := store.Update(s); err != nil {
+		http.Error(w, "Error saving searches", http.StatusInternalServerError)
+		return
+	}
+	if filterChanged {
+		if err := store.SetMatched(s.Id, s.Query, matched, nil); err != nil {
+			http.Error(w, "Error saving searches", http.StatusInternalServerError)
+			return
+		}
+		s.Matched = matched
+	}
+	writeSearch(w, http.StatusOK, s)
+}
+
+// DeleteHandler удаляет поиск (DELETE /api/searches/{id})
+func DeleteHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := loadOwned(w, r)
+	if !ok {
+		return
+	}
+	if err := store.Delete(s.Id); err != nil {
+		http.Error(w, "Error saving searches", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ResultsHandler выполняет поиск заново и возвращает все найденное
+// (GET /api/searches/{id}/results) в том же виде, что и поиск анкет
+func ResultsHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := loadOwned(w, r)
+	if !ok {
+		return
+	}
+	results, ids, err := find(s)
+	if err != nil {
+		http.Error(w, "Error running search", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Count   int `json:"count"`
+		Results any `json:"results"`
+	}{len(ids), results})
+}
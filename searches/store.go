@@ -0,0 +1,221 @@
+package searches
+
+import (
+	"errors"
+	"sync"
+	"talant/jsonfile"
+	"talant/memstore"
+	"time"
+)
+
+// ErrNotFound возвращается хранилищем, если поиск не найден
+var ErrNotFound = errors.New("saved search not found")
+
+// Store - хранилище сохраненных поисков
+type Store interface {
+	Get(id string) (SavedSearch, error)
+	// ListByUser - поиски пользователя в порядке создания
+	ListByUser(userID string) ([]SavedSearch, error)
+	List() ([]SavedSearch, error)
+	Create(s SavedSearch) error
+	// Update меняет поиск, кроме Matched и AlertedAt - их меняет только SetMatched
+	Update(s SavedSearch) error
+	Delete(id string) error
+	// SetMatched запоминает записи, найденные по фильтру query; если фильтр
+	// поиска уже сменили, ничего не меняет. alertedAt == nil - время оповещения
+	// остается прежним.
+	SetMatched(id, query string, matched []string, alertedAt *time.Time) error
+}
+
+var store Store = NewJSONStore("searches.json")
+
+// SetStore подменяет хранилище, с которым работают обработчики и проверка
+func SetStore(s Store) {
+	store = s
+}
+
+// keepMatched переносит в обновленный поиск то, что Update не меняет
+func keepMatched(saved *SavedSearch, old SavedSearch) {
+	saved.Matched, saved.AlertedAt = old.Matched, old.AlertedAt
+}
+
+func setMatched(s *SavedSearch, query string, matched []string, alertedAt *time.Time) {
+	if s.Query != query {
+		return
+	}
+	s.Matched = matched
+	if alertedAt != nil {
+		s.AlertedAt = alertedAt
+	}
+}
+
+// JSONStore хранит все поиски одним массивом в JSON-файле
+type JSONStore struct {
+	file *jsonfile.File[SavedSearch]
+}
+
+func NewJSONStore(path string) *JSONStore {
+	return &JSONStore{file: jsonfile.New[SavedSearch](path)}
+}
+
+func (s *JSONStore) Get(id string) (SavedSearch, error) {
+	list, err := s.file.Load()
+	if err != nil {
+		return SavedSearch{}, err
+	}
+	for _, saved := range list {
+		if saved.Id == id {
+			return saved, nil
+		}
+	}
+	return SavedSearch{}, ErrNotFound
+}
+
+func (s *JSONStore) ListByUser(userID string) ([]SavedSearch, error) {
+	list, err := s.file.Load()
+	if err != nil {
+		return nil, err
+	}
+	found := []SavedSearch{}
+	for _, saved := range list {
+		if saved.UserID == userID {
+			found = append(found, saved)
+		}
+	}
+	return found, nil
+}
+
+func (s *JSONStore) List() ([]SavedSearch, error) {
+	return s.file.Load()
+}
+
+func (s *JSONStore) Create(saved SavedSearch) error {
+	return s.file.Update(func(list []SavedSearch) ([]SavedSearch, error) {
+		return append(list, saved), nil
+	})
+}
+
+// change применяет fn к поиску с данным id
+func (s *JSONStore) change(id string, fn func(*SavedSearch)) error {
+	return s.file.Update(func(list []SavedSearch) ([]SavedSearch, error) {
+		for i := range list {
+			if list[i].Id == id {
+				fn(&list[i])
+				return list, nil
+			}
+		}
+		return nil, ErrNotFound
+	})
+}
+
+func (s *JSONStore) Update(saved SavedSearch) error {
+	return s.change(saved.Id, func(old *SavedSearch) {
+		keepMatched(&saved, *old)
+		*old = saved
+	})
+}
+
+func (s *JSONStore) Delete(id string) error {
+	return s.file.Update(func(list []SavedSearch) ([]SavedSearch, error) {
+		for i := range list {
+			if list[i].Id == id {
+				return append(list[:i], list[i+1:]...), nil
+			}
+		}
+		return nil, ErrNotFound
+	})
+}
+
+func (s *JSONStore) SetMatched(id, query string, matched []string, alertedAt *time.Time) error {
+	return s.change(id, func(saved *SavedSearch) {
+		setMatched(saved, query, matched, alertedAt)
+	})
+}
+
+// CachedStore держит поиски в памяти
+type CachedStore struct {
+	// mu делает чтение и запись в Update и SetMatched атомарными
+	mu  sync.Mutex
+	mem *memstore.Store[SavedSearch]
+}
+
+func NewCachedStore(path string) (*CachedStore, error) {
+	mem, err := memstore.Open(memstore.Options[SavedSearch]{
+		Snapshot: path,
+		ID:       func(s SavedSearch) string { return s.Id },
+		Indexes: map[string]func(SavedSearch) string{
+			"user": func(s SavedSearch) string { return s.UserID },
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &CachedStore{mem: mem}, nil
+}
+
+func (s *CachedStore) Close() error {
+	return s.mem.Close()
+}
+
+func (s *CachedStore) Get(id string) (SavedSearch, error) {
+	saved, ok := s.mem.Get(id)
+	if !ok {
+		return SavedSearch{}, ErrNotFound
+	}
+	return saved, nil
+}
+
+func (s *CachedStore) ListByUser(userID string) ([]SavedSearch, error) {
+	return s.mem.Find("user", userID), nil
+}
+
+func (s *CachedStore) List() ([]SavedSearch, error) {
+	return s.mem.List(), nil
+}
+
+func (s *CachedStore) Create(saved SavedSearch) error {
+	return s.mem.Put(saved, nil)
+}
+
+// replace сохраняет измененную копию поиска id; вызывается под mu
+func (s *CachedStore) replace(id string, fn func(*SavedSearch)) error {
+	saved, ok := s.mem.Get(id)
+	if !ok {
+		return ErrNotFound
+	}
+	fn(&saved)
+	return s.mem.Put(saved, func(v memstore.View[SavedSearch]) error {
+		if _, ok := v.Get(id); !ok {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (s *CachedStore) Update(saved SavedSearch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.replace(saved.Id, func(old *SavedSearch) {
+		keepMatched(&saved, *old)
+		*old = saved
+	})
+}
+
+func (s *CachedStore) Delete(id string) error {
+	ok, err := s.mem.Delete(id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *CachedStore) SetMatched(id, query string, matched []string, alertedAt *time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.replace(id, func(saved *SavedSearch) {
+		setMatched(saved, query, matched, alertedAt)
+	})
+}
@@ -0,0 +1,105 @@
+package skills
+
+// dictionary - известные навыки. ID - короткое имя в нижнем регистре, как у
+// тегов; в синонимах - другие написания, сокращения и русские названия.
+var dictionary = []Skill{
+	// Языки
+	{ID: "go", Name: "Go", Aliases: []string{"golang", "го"}},
+	{ID: "python", Name: "Python", Aliases: []string{"py", "питон", "python3"}},
+	{ID: "java", Name: "Java", Aliases: []string{"джава", "java se", "java ee"}},
+	{ID: "javascript", Name: "JavaScript", Aliases: []string{"js", "ecmascript", "es6", "джаваскрипт"}},
+	{ID: "typescript", Name: "TypeScript", Aliases: []string{"ts"}},
+	{ID: "php", Name: "PHP", Aliases: []string{"пхп"}},
+	{ID: "c", Name: "C", Aliases: []string{"си"}},
+	{ID: "c++", Name: "C++", Aliases: []string{"cpp", "cplusplus", "си++", "плюсы"}},
+	{ID: "c#", Name: "C#", Aliases: []string{"csharp", "c sharp", "си шарп"}},
+	{ID: "kotlin", Name: "Kotlin", Aliases: []string{"котлин"}},
+	{ID: "swift", Name: "Swift", Aliases: []string{"свифт"}},
+	{ID: "objective-c", Name: "Objective-C", Aliases: []string{"objc", "obj-c"}},
+	{ID: "ruby", Name: "Ruby", Aliases: []string{"руби"}},
+	{ID: "rust", Name: "Rust", Aliases: []string{"раст"}},
+	{ID: "scala", Name: "Scala"},
+	{ID: "dart", Name: "Dart"},
+	{ID: "r", Name: "R", Aliases: []string{"r language"}},
+	{ID: "1c", Name: "1С", Aliases: []string{"1с", "1c:предприятие", "1с:предприятие", "1c enterprise"}},
+	{ID: "bash", Name: "Bash", Aliases: []string{"shell", "sh", "shell scripting"}},
+	{ID: "sql", Name: "SQL"},
+	{ID: "html", Name: "HTML", Aliases: []string{"html5"}},
+	{ID: "css", Name: "CSS", Aliases: []string{"css3"}},
+
+	// Фреймворки и библиотеки
+	{ID: "react", Name: "React", Aliases: []string{"reactjs", "react.js", "реакт"}},
+	{ID: "vue", Name: "Vue.js", Aliases: []string{"vuejs", "vue.js", "vue3"}},
+	{ID: "angular", Name: "Angular", Aliases: []string{"angularjs"}},
+	{ID: "redux", Name: "Redux"},
+	{ID: "next.js", Name: "Next.js", Aliases: []string{"nextjs", "next"}},
+	{ID: "node.js", Name: "Node.js", Aliases: []string{"nodejs", "node"}},
+	{ID: "express", Name: "Express", Aliases: []string{"express.js", "expressjs"}},
+	{ID: "django", Name: "Django", Aliases: []string{"джанго"}},
+	{ID: "flask", Name: "Flask"},
+	{ID: "fastapi", Name: "FastAPI"},
+	{ID: "spring", Name: "Spring", Aliases: []string{"spring boot", "spring framework"}},
+	{ID: "hibernate", Name: "Hibernate"},
+	{ID: "laravel", Name: "Laravel", Aliases: []string{"ларавель"}},
+	{ID: "symfony", Name: "Symfony"},
+	{ID: ".net", Name: ".NET", Aliases: []string{"dotnet", "asp.net", ".net core"}},
+	{ID: "ruby-on-rails", Name: "Ruby on Rails", Aliases: []string{"rails", "ror"}},
+	{ID: "swiftui", Name: "SwiftUI"},
+	{ID: "uikit", Name: "UIKit"},
+	{ID: "flutter", Name: "Flutter"},
+	{ID: "react-native", Name: "React Native"},
+	{ID: "android", Name: "Android", Aliases: []string{"android sdk"}},
+	{ID: "ios", Name: "iOS"},
+	{ID: "pandas", Name: "pandas"},
+	{ID: "numpy", Name: "NumPy"},
+	{ID: "scikit-learn", Name: "scikit-learn", Aliases: []string{"sklearn"}},
+	{ID: "pytorch", Name: "PyTorch", Aliases: []string{"torch"}},
+	{ID: "tensorflow", Name: "TensorFlow", Aliases: []string{"tf"}},
+	{ID: "machine-learning", Name: "Machine Learning", Aliases: []string{"ml", "машинное обучение"}},
+
+	// Базы данных и очереди
+	{ID: "postgresql", Name: "PostgreSQL", Aliases: []string{"postgres", "pg", "постгрес"}},
+	{ID: "mysql", Name: "MySQL"},
+	{ID: "sqlite", Name: "SQLite"},
+	{ID: "mongodb", Name: "MongoDB", Aliases: []string{"mongo"}},
+	{ID: "redis", Name: "Redis"},
+	{ID: "clickhouse", Name: "ClickHouse"},
+	{ID: "elasticsearch", Name: "Elasticsearch", Aliases: []string{"elastic", "es"}},
+	{ID: "kafka", Name: "Kafka", Aliases: []string{"apache kafka"}},
+	{ID: "rabbitmq", Name: "RabbitMQ", Aliases: []string{"rabbit"}},
+
+	// Инфраструктура
+	{ID: "git", Name: "Git", Aliases: []string{"гит", "github", "gitlab"}},
+	{ID: "docker", Name: "Docker", Aliases: []string{"докер"}},
+	{ID: "kubernetes", Name: "Kubernetes", Aliases: []string{"k8s", "кубернетес"}},
+	{ID: "linux", Name: "Linux", Aliases: []string{"линукс"}},
+	{ID: "windows-server", Name: "Windows Server"},
+	{ID: "nginx", Name: "Nginx"},
+	{ID: "ansible", Name: "Ansible"},
+	{ID: "terraform", Name: "Terraform"},
+	{ID: "ci-cd", Name: "CI/CD", Aliases: []string{"ci", "cicd", "continuous integration"}},
+	{ID: "jenkins", Name: "Jenkins"},
+	{ID: "aws", Name: "AWS", Aliases: []string{"amazon web services"}},
+	{ID: "prometheus", Name: "Prometheus"},
+	{ID: "grafana", Name: "Grafana"},
+	{ID: "networking", Name: "Сети", Aliases: []string{"сети", "tcp/ip", "networks"}},
+
+	// Тестирование
+	{ID: "selenium", Name: "Selenium"},
+	{ID: "pytest", Name: "pytest"},
+	{ID: "junit", Name: "JUnit"},
+	{ID: "postman", Name: "Postman"},
+	{ID: "test-automation", Name: "Автотесты", Aliases: []string{"автотесты", "автоматизация тестирования", "test automation"}},
+
+	// Прочее
+	{ID: "rest-api", Name: "REST API", Aliases: []string{"rest", "restful"}},
+	{ID: "graphql", Name: "GraphQL"},
+	{ID: "grpc", Name: "gRPC"},
+	{ID: "microservices", Name: "Микросервисы", Aliases: []string{"микросервисы", "microservice"}},
+	{ID: "figma", Name: "Figma", Aliases: []string{"фигма"}},
+	{ID: "photoshop", Name: "Photoshop", Aliases: []string{"adobe photoshop", "фотошоп"}},
+	{ID: "excel", Name: "Excel", Aliases: []string{"ms excel", "эксель"}},
+	{ID: "jira", Name: "Jira", Aliases: []string{"джира"}},
+	{ID: "agile", Name: "Agile", Aliases: []string{"scrum", "kanban"}},
+	{ID: "english", Name: "Английский язык", Aliases: []string{"английский"}},
+}
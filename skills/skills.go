@@ -0,0 +1,233 @@
+// Package skills - словарь навыков: канонические ID и названия, синонимы
+// (golang - Go, JS - JavaScript) и подсказки при вводе. Навыки вакансий и анкет
+// хранятся списками ID (навыки не из словаря - как их написали), поэтому поиск
+// сравнивает навыки целиком: "Go" не находит "Google".
+package skills
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxSkills      = 50 // навыков в одной вакансии или анкете
+	maxSkillLength = 50 // символов в названии навыка
+)
+
+// Skill - навык из словаря
+type Skill struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+var (
+	// index - ID навыка по ключу (key) его ID, названия и синонимов
+	index = map[string]string{}
+	// byID - навыки словаря по ID
+	byID = map[string]Skill{}
+)
+
+func init() {
+	for _, s := range dictionary {
+		byID[s.ID] = s
+		for _, name := range append([]string{s.ID, s.Name}, s.Aliases...) {
+			k := key(name)
+			if id, ok := index[k]; ok && id != s.ID {
+				panic(fmt.Sprintf("skills: %q is both %s and %s", name, id, s.ID))
+			}
+			index[k] = s.ID
+		}
+	}
+}
+
+// key приводит написание навыка к виду для сравнения: нижний регистр, ё - е,
+// дефисы и подчеркивания - пробелы, одиночные пробелы
+func key(s string) string {
+	s = strings.NewReplacer("ё", "е", "-", " ", "_", " ").Replace(strings.ToLower(s))
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// Normalize - ID навыка по его написанию. Навык не из словаря остается в
+// написании пользователя, только без лишних пробелов ("Vue Router"): его ID и
+// есть название. Пустая строка - пустой ID.
+func Normalize(s string) string {
+	if id, ok := index[key(s)]; ok {
+		return id
+	}
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// canonical - навык для сравнения: ID словаря, а навык не из словаря - в виде
+// "machine-vision", так что "Machine Vision", "machine vision" и сохраненный
+// раньше ID "machine-vision" совпадают
+func canonical(id string) string {
+	k := key(id)
+	if id, ok := index[k]; ok {
+		return id
+	}
+	return strings.ReplaceAll(k, " ", "-")
+}
+
+// Same - один ли это навык при разном написании
+func Same(a, b string) bool {
+	return canonical(a) == canonical(b)
+}
+
+// Name - название навыка для показа; навык не из словаря показывается как его написали
+func Name(id string) string {
+	if s, ok := byID[id]; ok {
+		return s.Name
+	}
+	return id
+}
+
+// List - навыки списком ID без повторов, в порядке ввода
+type List []string
+
+// Parse читает навыки через запятую (или точку с запятой, или с новой строки)
+// и приводит их к ID
+func Parse(text string) List {
+	list := List{}
+	for _, s := range strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ';' || r == '\n'
+	}) {
+		if id := Normalize(s); id != "" && !list.Has(id) {
+			list = append(list, id)
+		}
+	}
+	return list
+}
+
+// Read читает навыки из поля skills формы: через запятую или несколькими значениями
+func Read(form url.Values) (List, error) {
+	list := Parse(strings.Join(form["skills"], ","))
+	if len(list) > maxSkills {
+		return nil, fmt.Errorf("Too many skills (max %d)", maxSkills)
+	}
+	for _, id := range list {
+		if utf8.RuneCountInString(id) > maxSkillLength {
+			return nil, fmt.Errorf("Skill is too long (max %d characters): %s", maxSkillLength, id)
+		}
+	}
+	return list, nil
+}
+
+// Names - названия навыков для показа
+func (l List) Names() []string {
+	names := make([]string, len(l))
+	for i, id := range l {
+		names[i] = Name(id)
+	}
+	return names
+}
+
+// String - названия навыков через запятую
+func (l List) String() string {
+	return strings.Join(l.Names(), ", ")
+}
+
+// Has - есть ли навык в списке, в любом написании (Same)
+func (l List) Has(id string) bool {
+	return slices.ContainsFunc(l, func(s string) bool { return Same(s, id) })
+}
+
+// MarshalJSON пишет пустой список как [], а не null
+func (l List) MarshalJSON() ([]byte, error) {
+	if l == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]string(l))
+}
+
+// UnmarshalJSON читает список ID, а также строку через запятую - так навыки
+// хранились раньше ("Git,Go,Docker")
+func (l *List) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*l = Parse(text)
+		return nil
+	}
+	var ids []string
+	if err := json.Unmarshal(data, &ids); err != nil {
+		return err
+	}
+	*l = Parse(strings.Join(ids, ","))
+	return nil
+}
+
+// matchRank - насколько навык подходит под начало ввода: 0 - точное совпадение
+// с ID, названием или синонимом, 1 - с ввода начинается название, 2 - синоним;
+// -1 - не подходит
+func matchRank(s Skill, prefix string) int {
+	if prefix == "" || key(s.ID) == prefix || key(s.Name) == prefix {
+		return 0
+	}
+	rank := -1
+	if strings.HasPrefix(key(s.Name), prefix) || strings.HasPrefix(key(s.ID), prefix) {
+		rank = 1
+	}
+	for _, alias := range s.Aliases {
+		k := key(alias)
+		if k == prefix {
+			return 0
+		}
+		if rank < 0 && strings.HasPrefix(k, prefix) {
+			rank = 2
+		}
+	}
+	return rank
+}
+
+// Autocomplete - до limit навыков словаря для подсказки по началу ввода,
+// лучшие совпадения первыми (matchRank), внутри - по алфавиту
+func Autocomplete(prefix string, limit int) []Skill {
+	prefix = key(prefix)
+	type hit struct {
+		skill Skill
+		rank  int
+	}
+	var hits []hit
+	for _, s := range dictionary {
+		if rank := matchRank(s, prefix); rank >= 0 {
+			hits = append(hits, hit{s, rank})
+		}
+	}
+	slices.SortFunc(hits, func(a, b hit) int {
+		if a.rank != b.rank {
+			return a.rank - b.rank
+		}
+		return strings.Compare(key(a.skill.Name), key(b.skill.Name))
+	})
+	result := []Skill{}
+	for _, h := range hits[:min(limit, len(hits))] {
+		result = append(result, h.skill)
+	}
+	return result
+}
+
+// AutocompleteHandler - подсказка навыков при вводе
+// (GET /api/skills?q=gol&limit=10, limit до 50; без q - весь словарь по алфавиту)
+func AutocompleteHandler(w http.ResponseWriter, r *http.Request) {
+	limit := 10
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		limit = len(dictionary)
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, 50)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Autocomplete(q, limit))
+}
@@ -0,0 +1,106 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"talant/ankety"
+)
+
+const anketaColumns = `id, user_id, name, gender, age, job, school, skills, photo,
+	position, salary, experience, city, jobtype, description, telegram, moderation, salary_range,
+	experience_range, seniority`
+
+const insertAnketaSQL = `INSERT INTO ankety (` + anketaColumns + `) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+func anketaArgs(a ankety.Ankety) []any {
+	return []any{a.Id, a.UserId, a.Name, a.Gender, a.Age, a.Job, a.School, formatSkills(a.Skills), a.Photo,
+		a.Position, a.Salary, a.Experience, a.City, a.Jobtype, a.Description, a.Telegram,
+		formatJSON(a.Moderation), formatJSON(a.SalaryRange), formatJSON(a.ExperienceRange), a.Seniority}
+}
+
+func scanAnketa(row interface{ Scan(...any) error }) (ankety.Ankety, error) {
+	var a ankety.Ankety
+	var anketaSkills string
+	var mod, salaryRange, experienceRange sql.NullString
+	err := row.Scan(&a.Id, &a.UserId, &a.Name, &a.Gender, &a.Age, &a.Job, &a.School, &anketaSkills, &a.Photo,
+		&a.Position, &a.Salary, &a.Experience, &a.City, &a.Jobtype, &a.Description, &a.Telegram, &mod,
+		&salaryRange, &experienceRange, &a.Seniority)
+	if err != nil {
+		return a, err
+	}
+	if err := json.Unmarshal([]byte(anketaSkills), &a.Skills); err != nil {
+		return a, err
+	}
+	if err := parseJSON(mod, &a.Moderation); err != nil {
+		return a, err
+	}
+	if err := parseJSON(salaryRange, &a.SalaryRange); err != nil {
+		return a, err
+	}
+	err = parseJSON(experienceRange, &a.ExperienceRange)
+	return a, err
+}
+
+// AnketyStore реализует ankety.Store поверх SQLite
+type AnketyStore struct {
+	d *DB
+}
+
+func (d *DB) Ankety() *AnketyStore {
+	return &AnketyStore{d: d}
+}
+
+func (s *AnketyStore) List() ([]ankety.Ankety, error) {
+	rows, err := s.d.db.Query(`SELECT ` + anketaColumns + ` FROM ankety ORDER BY rowid`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []ankety.Ankety{}
+	for rows.Next() {
+		a, err := scanAnketa(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+func (s *AnketyStore) getBy(column, value string) (ankety.Ankety, error) {
+	a, err := scanAnketa(s.d.db.QueryRow(`SELECT `+anketaColumns+` FROM ankety WHERE `+column+` = ?`, value))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ankety.Ankety{}, ankety.ErrNotFound
+	}
+	return a, err
+}
+
+func (s *AnketyStore) Get(id string) (ankety.Ankety, error) {
+	return s.getBy("id", id)
+}
+
+func (s *AnketyStore) GetByUser(userID string) (ankety.Ankety, error) {
+	return s.getBy("user_id", userID)
+}
+
+func (s *AnketyStore) Create(a ankety.Ankety) error {
+	_, err := s.d.db.Exec(insertAnketaSQL, anketaArgs(a)...)
+	if isUniqueViolation(err) {
+		return ankety.ErrAlreadyExists
+	}
+	return err
+}
+
+func (s *AnketyStore) Update(a ankety.Ankety) error {
+	return s.d.execOne(ankety.ErrNotFound, `UPDATE ankety SET
+		user_id = ?, name = ?, gender = ?, age = ?, job = ?, school = ?, skills = ?, photo = ?,
+		position = ?, salary = ?, experience = ?, city = ?, jobtype = ?, description = ?, telegram = ?,
+		moderation = ?, salary_range = ?, experience_range = ?, seniority = ?
+		WHERE id = ?`, append(anketaArgs(a)[1:], a.Id)...)
+}
+
+func (s *AnketyStore) Delete(id string) error {
+	return s.d.execOne(ankety.ErrNotFound, `DELETE FROM ankety WHERE id = ?`, id)
+}
@@ -0,0 +1,97 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"talant/applications"
+)
+
+const applicationColumns = `id, job_id, user_id, anketa_id, cover_letter, status, history, created_at, updated_at`
+
+func applicationArgs(a applications.Application) []any {
+	// История статусов хранится JSON-массивом
+	history, _ := json.Marshal(a.History)
+	return []any{a.Id, a.JobID, a.UserID, a.AnketaID, a.CoverLetter, a.Status, string(history),
+		formatTime(a.CreatedAt), formatTime(a.UpdatedAt)}
+}
+
+func scanApplication(row interface{ Scan(...any) error }) (applications.Application, error) {
+	var a applications.Application
+	var history, created, updated string
+	err := row.Scan(&a.Id, &a.JobID, &a.UserID, &a.AnketaID, &a.CoverLetter, &a.Status, &history,
+		&created, &updated)
+	if err != nil {
+		return a, err
+	}
+	if err := json.Unmarshal([]byte(history), &a.History); err != nil {
+		return a, err
+	}
+	if a.CreatedAt, err = parseTime(created); err != nil {
+		return a, err
+	}
+	a.UpdatedAt, err = parseTime(updated)
+	return a, err
+}
+
+// ApplicationStore реализует applications.Store поверх SQLite
+type ApplicationStore struct {
+	d *DB
+}
+
+func (d *DB) Applications() *ApplicationStore {
+	return &ApplicationStore{d: d}
+}
+
+func (s *ApplicationStore) query(where string, args ...any) ([]applications.Application, error) {
+	rows, err := s.d.db.Query(`SELECT `+applicationColumns+` FROM applications `+where+` ORDER BY rowid`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []applications.Application{}
+	for rows.Next() {
+		a, err := scanApplication(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+func (s *ApplicationStore) Get(id string) (applications.Application, error) {
+	a, err := scanApplication(s.d.db.QueryRow(`SELECT `+applicationColumns+` FROM applications WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return applications.Application{}, applications.ErrNotFound
+	}
+	return a, err
+}
+
+func (s *ApplicationStore) ListByJob(jobID string) ([]applications.Application, error) {
+	return s.query("WHERE job_id = ?", jobID)
+}
+
+func (s *ApplicationStore) ListByUser(userID string) ([]applications.Application, error) {
+	return s.query("WHERE user_id = ?", userID)
+}
+
+func (s *ApplicationStore) Create(a applications.Application) error {
+	_, err := s.d.db.Exec(`INSERT INTO applications (`+applicationColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		applicationArgs(a)...)
+	if isUniqueViolation(err) {
+		return applications.ErrAlreadyApplied
+	}
+	return err
+}
+
+func (s *ApplicationStore) Update(a applications.Application) error {
+	return s.d.execOne(applications.ErrNotFound, `UPDATE applications SET
+		job_id = ?, user_id = ?, anketa_id = ?, cover_letter = ?, status = ?, history = ?,
+		created_at = ?, updated_at = ? WHERE id = ?`, append(applicationArgs(a)[1:], a.Id)...)
+}
+
+func (s *ApplicationStore) Delete(id string) error {
+	return s.d.execOne(applications.ErrNotFound, `DELETE FROM applications WHERE id = ?`, id)
+}
@@ -0,0 +1,173 @@
+// Package sqlstore - хранилище пользователей, объявлений и анкет во встроенной SQLite.
+// Используется драйвер modernc.org/sqlite на чистом Go, поэтому cgo не нужен.
+package sqlstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"talant/ankety"
+	"talant/applications"
+	"talant/auth"
+	"talant/events"
+	"talant/job"
+	"talant/messages"
+	"talant/notifications"
+	"talant/searches"
+	"talant/skills"
+	"talant/webhooks"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Options - параметры открытия базы
+type Options struct {
+	// Path - путь к файлу базы
+	Path string
+	// Пути к старым JSON-файлам; при первом запуске их содержимое переносится в базу.
+	// Пустой путь или отсутствующий файл пропускаются.
+	LegacyUsers  string
+	LegacyJobs   string
+	LegacyAnkety string
+}
+
+// DB - открытая база с примененными миграциями
+type DB struct {
+	db   *sql.DB
+	opts Options
+}
+
+// Open открывает (или создает) файл базы и накатывает недостающие миграции
+func Open(opts Options) (*DB, error) {
+	dsn := "file:" + opts.Path +
+		"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)"
+	sqlDB, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия базы %s: %w", opts.Path, err)
+	}
+	// SQLite допускает только одного писателя, так что одно соединение избавляет от SQLITE_BUSY
+	sqlDB.SetMaxOpenConns(1)
+
+	d := &DB{db: sqlDB, opts: opts}
+	if err := d.migrate(); err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// isUniqueViolation сообщает, что запись нарушила UNIQUE-ограничение
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE ||
+			sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+	}
+	return false
+}
+
+// execOne выполняет UPDATE/DELETE и возвращает notFound, если ни одна строка не затронута
+func (d *DB) execOne(notFound error, query string, args ...any) error {
+	res, err := d.db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
+}
+
+// Время хранится строкой RFC3339 с наносекундами, чтобы сравнение строк
+// совпадало со сравнением моментов времени. Нулевое время - пустая строка.
+const timeLayout = time.RFC3339Nano
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(timeLayout)
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(timeLayout, s)
+}
+
+// formatTimePtr и parseTimePtr - то же для необязательных полей (NULL)
+func formatTimePtr(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return formatTime(*t)
+}
+
+func parseTimePtr(s sql.NullString) (*time.Time, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+	t, err := parseTime(s.String)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// formatJSON и parseJSON хранят вложенные структуры (решения модератора)
+// JSON-строкой; nil - NULL
+func formatJSON[T any](v *T) any {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return string(data)
+}
+
+func parseJSON[T any](s sql.NullString, dst **T) error {
+	*dst = nil
+	if !s.Valid {
+		return nil
+	}
+	v := new(T)
+	if err := json.Unmarshal([]byte(s.String), v); err != nil {
+		return err
+	}
+	*dst = v
+	return nil
+}
+
+// formatSkills хранит навыки JSON-массивом ID
+func formatSkills(l skills.List) string {
+	data, _ := json.Marshal(l)
+	return string(data)
+}
+
+var (
+	_ auth.UserStore           = (*UserStore)(nil)
+	_ job.Store                = (*JobStore)(nil)
+	_ ankety.Store             = (*AnketyStore)(nil)
+	_ auth.SessionStore        = (*SessionStore)(nil)
+	_ events.Store             = (*EventStore)(nil)
+	_ events.RegistrationStore = (*RegistrationStore)(nil)
+	_ applications.Store       = (*ApplicationStore)(nil)
+	_ messages.Store           = (*MessageStore)(nil)
+	_ notifications.Store      = (*NotificationStore)(nil)
+	_ webhooks.Store           = (*WebhookStore)(nil)
+	_ searches.Store           = (*SearchStore)(nil)
+)
@@ -0,0 +1,122 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"talant/events"
+)
+
+const eventColumns = `id, user_id, title, type, format, description, date, time, duration, starts_at,
+	location, city, map_link, price_type, price, max_participants, registration_link, topics, custom_tags,
+	organizer, organizer_email, contact_phone, website, telegram, vk, instagram, twitter,
+	level, language, prerequisites, materials, created_at, updated_at`
+
+const insertEventSQL = `INSERT INTO events (` + eventColumns + `) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+	?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// Списки (темы, теги) хранятся JSON-массивом
+func marshalList(list []string) string {
+	if list == nil {
+		list = []string{}
+	}
+	data, _ := json.Marshal(list)
+	return string(data)
+}
+
+func eventArgs(e events.Event) []any {
+	return []any{e.Id, e.UserID, e.Title, e.Type, e.Format, e.Description, e.Date, e.Time, e.Duration,
+		formatTime(e.StartsAt), e.Location, e.City, e.MapLink, e.PriceType, e.Price, e.MaxParticipants,
+		e.RegistrationLink, marshalList(e.Topics), marshalList(e.CustomTags),
+		e.Organizer, e.OrganizerEmail, e.ContactPhone, e.Website, e.Telegram, e.VK, e.Instagram, e.Twitter,
+		e.Level, e.Language, e.Prerequisites, e.Materials, formatTime(e.CreatedAt), formatTime(e.UpdatedAt)}
+}
+
+func scanEvent(row interface{ Scan(...any) error }) (events.Event, error) {
+	var e events.Event
+	var startsAt, topics, tags, created, updated string
+	err := row.Scan(&e.Id, &e.UserID, &e.Title, &e.Type, &e.Format, &e.Description, &e.Date, &e.Time,
+		&e.Duration, &startsAt, &e.Location, &e.City, &e.MapLink, &e.PriceType, &e.Price, &e.MaxParticipants,
+		&e.RegistrationLink, &topics, &tags,
+		&e.Organizer, &e.OrganizerEmail, &e.ContactPhone, &e.Website, &e.Telegram, &e.VK, &e.Instagram,
+		&e.Twitter, &e.Level, &e.Language, &e.Prerequisites, &e.Materials, &created, &updated)
+	if err != nil {
+		return e, err
+	}
+	if err := json.Unmarshal([]byte(topics), &e.Topics); err != nil {
+		return e, err
+	}
+	if err := json.Unmarshal([]byte(tags), &e.CustomTags); err != nil {
+		return e, err
+	}
+	if e.StartsAt, err = parseTime(startsAt); err != nil {
+		return e, err
+	}
+	if e.CreatedAt, err = parseTime(created); err != nil {
+		return e, err
+	}
+	e.UpdatedAt, err = parseTime(updated)
+	return e, err
+}
+
+// EventStore реализует events.Store поверх SQLite
+type EventStore struct {
+	d *DB
+}
+
+func (d *DB) Events() *EventStore {
+	return &EventStore{d: d}
+}
+
+func (s *EventStore) query(where string, args ...any) ([]events.Event, error) {
+	rows, err := s.d.db.Query(`SELECT `+eventColumns+` FROM events `+where+` ORDER BY rowid`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []events.Event{}
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
+
+func (s *EventStore) List() ([]events.Event, error) {
+	return s.query("")
+}
+
+func (s *EventStore) Get(id string) (events.Event, error) {
+	e, err := scanEvent(s.d.db.QueryRow(`SELECT `+eventColumns+` FROM events WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return events.Event{}, events.ErrNotFound
+	}
+	return e, err
+}
+
+func (s *EventStore) ListByUser(userID string) ([]events.Event, error) {
+	return s.query("WHERE user_id = ?", userID)
+}
+
+func (s *EventStore) Create(e events.Event) error {
+	_, err := s.d.db.Exec(insertEventSQL, eventArgs(e)...)
+	return err
+}
+
+func (s *EventStore) Update(e events.Event) error {
+	return s.d.execOne(events.ErrNotFound, `UPDATE events SET
+		user_id = ?, title = ?, type = ?, format = ?, description = ?, date = ?, time = ?, duration = ?,
+		starts_at = ?, location = ?, city = ?, map_link = ?, price_type = ?, price = ?, max_participants = ?,
+		registration_link = ?, topics = ?, custom_tags = ?, organizer = ?, organizer_email = ?,
+		contact_phone = ?, website = ?, telegram = ?, vk = ?, instagram = ?, twitter = ?,
+		level = ?, language = ?, prerequisites = ?, materials = ?, created_at = ?, updated_at = ?
+		WHERE id = ?`, append(eventArgs(e)[1:], e.Id)...)
+}
+
+func (s *EventStore) Delete(id string) error {
+	return s.d.execOne(events.ErrNotFound, `DELETE FROM events WHERE id = ?`, id)
+}
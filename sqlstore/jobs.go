@@ -0,0 +1,101 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"talant/job"
+)
+
+const jobColumns = `id, user_id, title, company, school, description, salary, skills,
+	location, experience, job_type, telegram, moderation, salary_range, experience_range, seniority`
+
+const insertJobSQL = `INSERT INTO jobs (` + jobColumns + `) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+func jobArgs(j job.Job) []any {
+	return []any{j.Id, j.UserID, j.Title, j.Company, j.School, j.Description, j.Salary, formatSkills(j.Skills),
+		j.Location, j.Experience, j.JobType, j.Telegram, formatJSON(j.Moderation), formatJSON(j.SalaryRange),
+		formatJSON(j.ExperienceRange), j.Seniority}
+}
+
+func scanJob(row interface{ Scan(...any) error }) (job.Job, error) {
+	var j job.Job
+	var jobSkills string
+	var mod, salaryRange, experienceRange sql.NullString
+	err := row.Scan(&j.Id, &j.UserID, &j.Title, &j.Company, &j.School, &j.Description, &j.Salary, &jobSkills,
+		&j.Location, &j.Experience, &j.JobType, &j.Telegram, &mod, &salaryRange, &experienceRange, &j.Seniority)
+	if err != nil {
+		return j, err
+	}
+	if err := json.Unmarshal([]byte(jobSkills), &j.Skills); err != nil {
+		return j, err
+	}
+	if err := parseJSON(mod, &j.Moderation); err != nil {
+		return j, err
+	}
+	if err := parseJSON(salaryRange, &j.SalaryRange); err != nil {
+		return j, err
+	}
+	err = parseJSON(experienceRange, &j.ExperienceRange)
+	return j, err
+}
+
+// JobStore реализует job.Store поверх SQLite
+type JobStore struct {
+	d *DB
+}
+
+func (d *DB) Jobs() *JobStore {
+	return &JobStore{d: d}
+}
+
+func (s *JobStore) query(where string, args ...any) ([]job.Job, error) {
+	rows, err := s.d.db.Query(`SELECT `+jobColumns+` FROM jobs `+where+` ORDER BY rowid`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []job.Job{}
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, j)
+	}
+	return list, rows.Err()
+}
+
+func (s *JobStore) List() ([]job.Job, error) {
+	return s.query("")
+}
+
+func (s *JobStore) ListByUser(userID string) ([]job.Job, error) {
+	return s.query("WHERE user_id = ?", userID)
+}
+
+func (s *JobStore) Get(id string) (job.Job, error) {
+	j, err := scanJob(s.d.db.QueryRow(`SELECT `+jobColumns+` FROM jobs WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return job.Job{}, job.ErrNotFound
+	}
+	return j, err
+}
+
+func (s *JobStore) Create(j job.Job) error {
+	_, err := s.d.db.Exec(insertJobSQL, jobArgs(j)...)
+	return err
+}
+
+func (s *JobStore) Update(j job.Job) error {
+	return s.d.execOne(job.ErrNotFound, `UPDATE jobs SET
+		user_id = ?, title = ?, company = ?, school = ?, description = ?, salary = ?, skills = ?,
+		location = ?, experience = ?, job_type = ?, telegram = ?, moderation = ?, salary_range = ?,
+		experience_range = ?, seniority = ?
+		WHERE id = ?`, append(jobArgs(j)[1:], j.Id)...)
+}
+
+func (s *JobStore) Delete(id string) error {
+	return s.d.execOne(job.ErrNotFound, `DELETE FROM jobs WHERE id = ?`, id)
+}
@@ -0,0 +1,200 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"slices"
+	"talant/messages"
+)
+
+// Участники беседы лежат JSON-массивом (со счетчиками непрочитанного), а их ID
+// продублированы в user_a и user_b для поиска бесед пользователя
+const conversationColumns = `id, key, job_id, anketa_id, user_a, user_b, members, last_message,
+	last_message_at, created_at`
+
+func conversationArgs(c messages.Conversation) []any {
+	members, _ := json.Marshal(c.Members)
+	var userA, userB string
+	if len(c.Members) > 0 {
+		userA = c.Members[0].UserID
+	}
+	if len(c.Members) > 1 {
+		userB = c.Members[1].UserID
+	}
+	return []any{c.Id, c.Key, c.JobID, c.AnketaID, userA, userB, string(members), c.LastMessage,
+		formatTime(c.LastMessageAt), formatTime(c.CreatedAt)}
+}
+
+func scanConversation(row interface{ Scan(...any) error }) (messages.Conversation, error) {
+	var c messages.Conversation
+	var userA, userB, members, lastAt, created string
+	err := row.Scan(&c.Id, &c.Key, &c.JobID, &c.AnketaID, &userA, &userB, &members, &c.LastMessage,
+		&lastAt, &created)
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal([]byte(members), &c.Members); err != nil {
+		return c, err
+	}
+	if c.LastMessageAt, err = parseTime(lastAt); err != nil {
+		return c, err
+	}
+	c.CreatedAt, err = parseTime(created)
+	return c, err
+}
+
+func scanMessage(row interface{ Scan(...any) error }) (messages.Message, error) {
+	var m messages.Message
+	var created string
+	err := row.Scan(&m.Id, &m.ConversationID, &m.SenderID, &m.Text, &created)
+	if err != nil {
+		return m, err
+	}
+	m.CreatedAt, err = parseTime(created)
+	return m, err
+}
+
+// MessageStore реализует messages.Store поверх SQLite
+type MessageStore struct {
+	d *DB
+}
+
+func (d *DB) Messages() *MessageStore {
+	return &MessageStore{d: d}
+}
+
+func (s *MessageStore) getConversation(q interface {
+	QueryRow(query string, args ...any) *sql.Row
+}, column, value string) (messages.Conversation, error) {
+	c, err := scanConversation(q.QueryRow(`SELECT `+conversationColumns+` FROM conversations WHERE `+column+` = ?`, value))
+	if errors.Is(err, sql.ErrNoRows) {
+		return messages.Conversation{}, messages.ErrNotFound
+	}
+	return c, err
+}
+
+func (s *MessageStore) GetConversation(id string) (messages.Conversation, error) {
+	return s.getConversation(s.d.db, "id", id)
+}
+
+func (s *MessageStore) GetConversationByKey(key string) (messages.Conversation, error) {
+	return s.getConversation(s.d.db, "key", key)
+}
+
+func (s *MessageStore) ListConversations(userID string) ([]messages.Conversation, error) {
+	rows, err := s.d.db.Query(`SELECT `+conversationColumns+` FROM conversations
+		WHERE user_a = ? OR user_b = ? ORDER BY rowid`, userID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []messages.Conversation{}
+	for rows.Next() {
+		c, err := scanConversation(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+func (s *MessageStore) CreateConversation(c messages.Conversation) error {
+	_, err := s.d.db.Exec(`INSERT INTO conversations (`+conversationColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		conversationArgs(c)...)
+	if isUniqueViolation(err) {
+		return messages.ErrConversationExists
+	}
+	return err
+}
+
+func (s *MessageStore) ChangeConversation(id string, fn func(*messages.Conversation) error) error {
+	tx, err := s.d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	c, err := s.getConversation(tx, "id", id)
+	if err != nil {
+		return err
+	}
+	if err := fn(&c); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`UPDATE conversations SET key = ?, job_id = ?, anketa_id = ?, user_a = ?, user_b = ?,
+		members = ?, last_message = ?, last_message_at = ?, created_at = ? WHERE id = ?`,
+		append(conversationArgs(c)[1:], c.Id)...)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *MessageStore) AddMessage(m messages.Message) error {
+	_, err := s.d.db.Exec(`INSERT INTO messages (id, conversation_id, sender_id, text, created_at)
+		VALUES (?, ?, ?, ?, ?)`, m.Id, m.ConversationID, m.SenderID, m.Text, formatTime(m.CreatedAt))
+	return err
+}
+
+func (s *MessageStore) ListMessages(conversationID, beforeID string, limit int) ([]messages.Message, error) {
+	query := `SELECT id, conversation_id, sender_id, text, created_at FROM messages WHERE conversation_id = ?`
+	args := []any{conversationID}
+	if beforeID != "" {
+		query += ` AND rowid < (SELECT rowid FROM messages WHERE id = ? AND conversation_id = ?)`
+		args = append(args, beforeID, conversationID)
+	}
+	rows, err := s.d.db.Query(query+` ORDER BY rowid DESC LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []messages.Message{}
+	for rows.Next() {
+		m, err := scanMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, m)
+	}
+	// Выбирали с конца, отдаем в порядке отправки
+	slices.Reverse(list)
+	return list, rows.Err()
+}
+
+func (s *MessageStore) ListBlocks(userID string) ([]messages.Block, error) {
+	rows, err := s.d.db.Query(`SELECT user_id, blocked_id, created_at FROM blocks
+		WHERE user_id = ? ORDER BY rowid`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []messages.Block{}
+	for rows.Next() {
+		var b messages.Block
+		var created string
+		if err := rows.Scan(&b.UserID, &b.BlockedID, &created); err != nil {
+			return nil, err
+		}
+		if b.CreatedAt, err = parseTime(created); err != nil {
+			return nil, err
+		}
+		list = append(list, b)
+	}
+	return list, rows.Err()
+}
+
+func (s *MessageStore) AddBlock(b messages.Block) error {
+	_, err := s.d.db.Exec(`INSERT OR IGNORE INTO blocks (user_id, blocked_id, created_at) VALUES (?, ?, ?)`,
+		b.UserID, b.BlockedID, formatTime(b.CreatedAt))
+	return err
+}
+
+func (s *MessageStore) RemoveBlock(userID, blockedID string) error {
+	return s.d.execOne(messages.ErrNotFound, `DELETE FROM blocks WHERE user_id = ? AND blocked_id = ?`,
+		userID, blockedID)
+}
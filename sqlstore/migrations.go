@@ -0,0 +1,487 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"talant/jsonfile"
+	"talant/skills"
+	"time"
+)
+
+// migration - один шаг схемы. Версии только растут; примененные шаги
+// записываются в schema_migrations и повторно не выполняются.
+type migration struct {
+	version int
+	name    string
+	up      func(d *DB, tx *sql.Tx) error
+}
+
+var migrations = []migration{
+	{1, "create users, jobs and ankety", execSQL(`
+		CREATE TABLE users (
+			id       TEXT PRIMARY KEY,
+			username TEXT NOT NULL UNIQUE,
+			usermail TEXT NOT NULL UNIQUE,
+			password TEXT NOT NULL
+		);
+		CREATE TABLE jobs (
+			id          TEXT PRIMARY KEY,
+			user_id     TEXT NOT NULL,
+			title       TEXT NOT NULL,
+			company     TEXT NOT NULL DEFAULT '',
+			school      TEXT NOT NULL DEFAULT '',
+			description TEXT NOT NULL DEFAULT '',
+			salary      TEXT NOT NULL DEFAULT '',
+			skills      TEXT NOT NULL DEFAULT '',
+			location    TEXT NOT NULL DEFAULT '',
+			experience  TEXT NOT NULL DEFAULT '',
+			job_type    TEXT NOT NULL DEFAULT '',
+			telegram    TEXT NOT NULL DEFAULT ''
+		);
+		CREATE INDEX jobs_user_id ON jobs(user_id);
+		CREATE TABLE ankety (
+			id          TEXT PRIMARY KEY,
+			user_id     TEXT NOT NULL UNIQUE,
+			name        TEXT NOT NULL,
+			gender      TEXT NOT NULL DEFAULT '',
+			age         TEXT NOT NULL DEFAULT '',
+			job         TEXT NOT NULL DEFAULT '',
+			school      TEXT NOT NULL DEFAULT '',
+			skills      TEXT NOT NULL DEFAULT '',
+			photo       TEXT NOT NULL DEFAULT '',
+			position    TEXT NOT NULL DEFAULT '',
+			salary      TEXT NOT NULL DEFAULT '',
+			experience  TEXT NOT NULL DEFAULT '',
+			city        TEXT NOT NULL DEFAULT '',
+			jobtype     TEXT NOT NULL DEFAULT '',
+			description TEXT NOT NULL DEFAULT '',
+			telegram    TEXT NOT NULL DEFAULT ''
+		);
+	`)},
+	{2, "import legacy JSON files", importLegacyJSON},
+	{3, "create sessions", execSQL(`
+		CREATE TABLE sessions (
+			id                TEXT PRIMARY KEY,
+			user_id           TEXT NOT NULL,
+			refresh_hash      TEXT NOT NULL,
+			prev_refresh_hash TEXT NOT NULL DEFAULT '',
+			rotated_at        TEXT NOT NULL,
+			user_agent        TEXT NOT NULL DEFAULT '',
+			ip                TEXT NOT NULL DEFAULT '',
+			created_at        TEXT NOT NULL,
+			last_used_at      TEXT NOT NULL,
+			expires_at        TEXT NOT NULL,
+			revoked_at        TEXT
+		);
+		CREATE INDEX sessions_user_id ON sessions(user_id);
+	`)},
+	{4, "add email verification to users", execSQL(`
+		ALTER TABLE users ADD COLUMN email_verified INTEGER NOT NULL DEFAULT 0;
+		ALTER TABLE users ADD COLUMN email_verified_at TEXT;
+		ALTER TABLE users ADD COLUMN created_at TEXT NOT NULL DEFAULT '';
+		ALTER TABLE users ADD COLUMN updated_at TEXT NOT NULL DEFAULT '';
+	`)},
+	{5, "add roles to users", execSQL(`
+		ALTER TABLE users ADD COLUMN roles TEXT NOT NULL DEFAULT '';
+	`)},
+	{6, "add moderation", execSQL(`
+		ALTER TABLE jobs ADD COLUMN moderation TEXT;
+		ALTER TABLE ankety ADD COLUMN moderation TEXT;
+		ALTER TABLE users ADD COLUMN ban TEXT;
+	`)},
+	{7, "create events", execSQL(`
+		CREATE TABLE events (
+			id                TEXT PRIMARY KEY,
+			user_id           TEXT NOT NULL,
+			title             TEXT NOT NULL,
+			type              TEXT NOT NULL,
+			format            TEXT NOT NULL,
+			description       TEXT NOT NULL DEFAULT '',
+			date              TEXT NOT NULL,
+			time              TEXT NOT NULL,
+			duration          TEXT NOT NULL,
+			starts_at         TEXT NOT NULL,
+			location          TEXT NOT NULL DEFAULT '',
+			city              TEXT NOT NULL DEFAULT '',
+			map_link          TEXT NOT NULL DEFAULT '',
+			price_type        TEXT NOT NULL,
+			price             INTEGER NOT NULL DEFAULT 0,
+			max_participants  INTEGER NOT NULL DEFAULT 0,
+			registration_link TEXT NOT NULL DEFAULT '',
+			topics            TEXT NOT NULL DEFAULT '[]',
+			custom_tags       TEXT NOT NULL DEFAULT '[]',
+			organizer         TEXT NOT NULL DEFAULT '',
+			organizer_email   TEXT NOT NULL DEFAULT '',
+			contact_phone     TEXT NOT NULL DEFAULT '',
+			website           TEXT NOT NULL DEFAULT '',
+			telegram          TEXT NOT NULL DEFAULT '',
+			vk                TEXT NOT NULL DEFAULT '',
+			instagram         TEXT NOT NULL DEFAULT '',
+			twitter           TEXT NOT NULL DEFAULT '',
+			level             TEXT NOT NULL DEFAULT '',
+			language          TEXT NOT NULL DEFAULT '',
+			prerequisites     TEXT NOT NULL DEFAULT '',
+			materials         TEXT NOT NULL DEFAULT '',
+			created_at        TEXT NOT NULL,
+			updated_at        TEXT NOT NULL
+		);
+		CREATE INDEX events_user_id ON events(user_id);
+		CREATE INDEX events_starts_at ON events(starts_at);
+	`)},
+	{8, "create registrations", execSQL(`
+		CREATE TABLE registrations (
+			id          TEXT PRIMARY KEY,
+			event_id    TEXT NOT NULL,
+			user_id     TEXT NOT NULL,
+			username    TEXT NOT NULL,
+			status      TEXT NOT NULL,
+			created_at  TEXT NOT NULL,
+			promoted_at TEXT,
+			UNIQUE (event_id, user_id)
+		);
+		CREATE INDEX registrations_user_id ON registrations(user_id);
+	`)},
+	{9, "add users.calendar_key", execSQL(`
+		ALTER TABLE users ADD COLUMN calendar_key TEXT NOT NULL DEFAULT '';
+	`)},
+	{10, "add registrations.checked_in_at", execSQL(`
+		ALTER TABLE registrations ADD COLUMN checked_in_at TEXT;
+	`)},
+	{11, "create applications", execSQL(`
+		CREATE TABLE applications (
+			id           TEXT PRIMARY KEY,
+			job_id       TEXT NOT NULL,
+			user_id      TEXT NOT NULL,
+			anketa_id    TEXT NOT NULL,
+			cover_letter TEXT NOT NULL DEFAULT '',
+			status       TEXT NOT NULL,
+			history      TEXT NOT NULL DEFAULT '[]',
+			created_at   TEXT NOT NULL,
+			updated_at   TEXT NOT NULL,
+			UNIQUE (job_id, user_id)
+		);
+		CREATE INDEX applications_user_id ON applications(user_id);
+	`)},
+	{12, "create conversations, messages and blocks", execSQL(`
+		CREATE TABLE conversations (
+			id              TEXT PRIMARY KEY,
+			key             TEXT NOT NULL UNIQUE,
+			job_id          TEXT NOT NULL DEFAULT '',
+			anketa_id       TEXT NOT NULL DEFAULT '',
+			user_a          TEXT NOT NULL,
+			user_b          TEXT NOT NULL,
+			members         TEXT NOT NULL,
+			last_message    TEXT NOT NULL DEFAULT '',
+			last_message_at TEXT NOT NULL DEFAULT '',
+			created_at      TEXT NOT NULL
+		);
+		CREATE INDEX conversations_user_a ON conversations(user_a);
+		CREATE INDEX conversations_user_b ON conversations(user_b);
+		CREATE TABLE messages (
+			id              TEXT PRIMARY KEY,
+			conversation_id TEXT NOT NULL,
+			sender_id       TEXT NOT NULL,
+			text            TEXT NOT NULL,
+			created_at      TEXT NOT NULL
+		);
+		CREATE INDEX messages_conversation_id ON messages(conversation_id);
+		CREATE TABLE blocks (
+			user_id    TEXT NOT NULL,
+			blocked_id TEXT NOT NULL,
+			created_at TEXT NOT NULL,
+			PRIMARY KEY (user_id, blocked_id)
+		);
+	`)},
+	{13, "create notifications", execSQL(`
+		CREATE TABLE notifications (
+			id         TEXT PRIMARY KEY,
+			user_id    TEXT NOT NULL,
+			type       TEXT NOT NULL,
+			text       TEXT NOT NULL,
+			link       TEXT NOT NULL DEFAULT '',
+			data       TEXT NOT NULL DEFAULT 'null',
+			key        TEXT NOT NULL DEFAULT '',
+			created_at TEXT NOT NULL,
+			read_at    TEXT
+		);
+		CREATE INDEX notifications_user_id ON notifications(user_id);
+		CREATE UNIQUE INDEX notifications_user_key ON notifications(user_id, key) WHERE key != '';
+	`)},
+	{14, "create webhooks and webhook_deliveries", execSQL(`
+		CREATE TABLE webhooks (
+			id         TEXT PRIMARY KEY,
+			user_id    TEXT NOT NULL,
+			url        TEXT NOT NULL,
+			secret     TEXT NOT NULL,
+			events     TEXT NOT NULL,
+			active     INTEGER NOT NULL,
+			created_at TEXT NOT NULL,
+			updated_at TEXT NOT NULL
+		);
+		CREATE INDEX webhooks_user_id ON webhooks(user_id);
+		CREATE TABLE webhook_deliveries (
+			id              TEXT PRIMARY KEY,
+			webhook_id      TEXT NOT NULL,
+			event           TEXT NOT NULL,
+			payload         TEXT NOT NULL,
+			status          TEXT NOT NULL,
+			attempts        INTEGER NOT NULL DEFAULT 0,
+			response_code   INTEGER NOT NULL DEFAULT 0,
+			error           TEXT NOT NULL DEFAULT '',
+			created_at      TEXT NOT NULL,
+			next_attempt_at TEXT,
+			delivered_at    TEXT
+		);
+		CREATE INDEX webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id);
+		CREATE INDEX webhook_deliveries_due ON webhook_deliveries(status, next_attempt_at);
+	`)},
+	{15, "create saved_searches", execSQL(`
+		CREATE TABLE saved_searches (
+			id         TEXT PRIMARY KEY,
+			user_id    TEXT NOT NULL,
+			name       TEXT NOT NULL,
+			kind       TEXT NOT NULL,
+			query      TEXT NOT NULL,
+			notify     INTEGER NOT NULL,
+			email      INTEGER NOT NULL,
+			matched    TEXT NOT NULL DEFAULT '[]',
+			alerted_at TEXT,
+			created_at TEXT NOT NULL,
+			updated_at TEXT NOT NULL
+		);
+		CREATE INDEX saved_searches_user_id ON saved_searches(user_id);
+	`)},
+	// Разбор старых текстовых зарплат и опыта делают job.ParseLegacy и
+	// ankety.ParseLegacy при старте
+	{16, "add salary_range", execSQL(`
+		ALTER TABLE jobs ADD COLUMN salary_range TEXT;
+		ALTER TABLE ankety ADD COLUMN salary_range TEXT;
+	`)},
+	{17, "add experience_range and seniority", execSQL(`
+		ALTER TABLE jobs ADD COLUMN experience_range TEXT;
+		ALTER TABLE jobs ADD COLUMN seniority TEXT NOT NULL DEFAULT '';
+		ALTER TABLE ankety ADD COLUMN experience_range TEXT;
+		ALTER TABLE ankety ADD COLUMN seniority TEXT NOT NULL DEFAULT '';
+	`)},
+	{18, "store skills as lists", convertSkills},
+}
+
+func execSQL(query string) func(*DB, *sql.Tx) error {
+	return func(_ *DB, tx *sql.Tx) error {
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+// migrate накатывает все миграции, которых еще нет в schema_migrations.
+// Каждая миграция выполняется в своей транзакции вместе с записью о версии.
+func (d *DB) migrate() error {
+	_, err := d.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TEXT NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("ошибка создания schema_migrations: %w", err)
+	}
+
+	var current int
+	err = d.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения версии схемы: %w", err)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		tx, err := d.db.Begin()
+		if err != nil {
+			return err
+		}
+		if err := m.up(d, tx); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("миграция %d (%s): %w", m.version, m.name, err)
+		}
+		_, err = tx.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+			m.version, m.name, time.Now().UTC().Format(time.RFC3339))
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		fmt.Printf("Применена миграция %d: %s\n", m.version, m.name)
+	}
+	return nil
+}
+
+// Записи старых JSON-файлов в том виде, в каком их знала миграция 2. Типы
+// заморожены вместе с миграцией: поля job.Job, ankety.Ankety и auth.User
+// меняются, а уже выпущенная миграция на старой базе должна читать файлы
+// так же, как в первый раз.
+type (
+	legacyUser struct {
+		Id       string `json:"id"`
+		Username string `json:"username"`
+		Usermail string `json:"usermail"`
+		Password string `json:"password"`
+	}
+	legacyJob struct {
+		Id          string     `json:"id"`
+		UserID      string     `json:"user_id"`
+		Title       string     `json:"title"`
+		Company     string     `json:"company"`
+		School      string     `json:"school"`
+		Description string     `json:"description"`
+		Salary      string     `json:"salary"`
+		Skills      legacyText `json:"skills"`
+		Location    string     `json:"location"`
+		Experience  string     `json:"experience"`
+		JobType     string     `json:"job_type"`
+		Telegram    string     `json:"telegram"`
+	}
+	legacyAnketa struct {
+		Id          string     `json:"id"`
+		UserId      string     `json:"user_id"`
+		Name        string     `json:"name"`
+		Gender      string     `json:"gender"`
+		Age         string     `json:"age"`
+		Job         string     `json:"job"`
+		School      string     `json:"school"`
+		Skills      legacyText `json:"skills"`
+		Photo       string     `json:"photo"`
+		Position    string     `json:"position"`
+		Salary      string     `json:"salary"`
+		Experience  string     `json:"experience"`
+		City        string     `json:"city"`
+		Jobtype     string     `json:"jobtype"`
+		Description string     `json:"description"`
+		Telegram    string     `json:"telegram"`
+	}
+)
+
+// legacyText - навыки текстом через запятую. Файлы, записанные уже новой
+// версией, хранят их массивом - он склеивается в тот же текст, а в JSON-массив
+// его переводит миграция 18 (convertSkills).
+type legacyText string
+
+func (t *legacyText) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*t = legacyText(strings.Join(list, ","))
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*t = legacyText(s)
+	return nil
+}
+
+// importLegacyJSON переносит data.json, job.json и ankety.json в новые таблицы.
+// Колонки и поля перечислены явно: на момент миграции 2 в таблицах были только они.
+func importLegacyJSON(d *DB, tx *sql.Tx) error {
+	if exists(d.opts.LegacyUsers) {
+		list, err := jsonfile.New[legacyUser](d.opts.LegacyUsers).Load()
+		if err != nil {
+			return err
+		}
+		for _, u := range list {
+			err := importRow(tx, `INSERT INTO users (id, username, usermail, password) VALUES (?, ?, ?, ?)`,
+				u.Id, u.Username, u.Usermail, u.Password)
+			if err != nil {
+				return fmt.Errorf("пользователь %s: %w", u.Id, err)
+			}
+		}
+		fmt.Printf("Импортировано пользователей: %d\n", len(list))
+	}
+	if exists(d.opts.LegacyJobs) {
+		list, err := jsonfile.New[legacyJob](d.opts.LegacyJobs).Load()
+		if err != nil {
+			return err
+		}
+		for _, j := range list {
+			err := importRow(tx, `INSERT INTO jobs (id, user_id, title, company, school, description,
+				salary, skills, location, experience, job_type, telegram) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				j.Id, j.UserID, j.Title, j.Company, j.School, j.Description,
+				j.Salary, string(j.Skills), j.Location, j.Experience, j.JobType, j.Telegram)
+			if err != nil {
+				return fmt.Errorf("объявление %s: %w", j.Id, err)
+			}
+		}
+		fmt.Printf("Импортировано объявлений: %d\n", len(list))
+	}
+	if exists(d.opts.LegacyAnkety) {
+		list, err := jsonfile.New[legacyAnketa](d.opts.LegacyAnkety).Load()
+		if err != nil {
+			return err
+		}
+		for _, a := range list {
+			err := importRow(tx, `INSERT INTO ankety (id, user_id, name, gender, age, job, school, skills,
+				photo, position, salary, experience, city, jobtype, description, telegram)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				a.Id, a.UserId, a.Name, a.Gender, a.Age, a.Job, a.School, string(a.Skills),
+				a.Photo, a.Position, a.Salary, a.Experience, a.City, a.Jobtype, a.Description, a.Telegram)
+			if err != nil {
+				return fmt.Errorf("анкета %s: %w", a.Id, err)
+			}
+		}
+		fmt.Printf("Импортировано анкет: %d\n", len(list))
+	}
+	return nil
+}
+
+// convertSkills переводит навыки из текста через запятую ("Git,Go,Docker") в
+// JSON-массив ID словаря; навыки не из словаря остаются в написании автора.
+func convertSkills(_ *DB, tx *sql.Tx) error {
+	for _, table := range []string{"jobs", "ankety"} {
+		rows, err := tx.Query(`SELECT id, skills FROM ` + table + ` WHERE skills NOT LIKE '[%'`)
+		if err != nil {
+			return err
+		}
+		converted := map[string]string{}
+		for rows.Next() {
+			var id, text string
+			if err := rows.Scan(&id, &text); err != nil {
+				rows.Close()
+				return err
+			}
+			converted[id] = formatSkills(skills.Parse(text))
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for id, list := range converted {
+			if _, err := tx.Exec(`UPDATE `+table+` SET skills = ? WHERE id = ?`, list, id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// importRow вставляет одну запись. Дубликаты (в старых файлах встречаются
+// анкеты с одинаковым id) пропускаются: остается первая, как и в JSONStore.
+func importRow(tx *sql.Tx, query string, args ...any) error {
+	_, err := tx.Exec(query, args...)
+	if isUniqueViolation(err) {
+		fmt.Printf("Пропущен дубликат при импорте: %v\n", args[0])
+		return nil
+	}
+	return err
+}
+
+func exists(path string) bool {
+	if path == "" {
+		return false
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,97 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"talant/notifications"
+	"time"
+)
+
+const notificationColumns = `id, user_id, type, text, link, data, key, created_at, read_at`
+
+func scanNotification(row interface{ Scan(...any) error }) (notifications.Notification, error) {
+	var n notifications.Notification
+	var data, created string
+	var readAt sql.NullString
+	err := row.Scan(&n.Id, &n.UserID, &n.Type, &n.Text, &n.Link, &data, &n.Key, &created, &readAt)
+	if err != nil {
+		return n, err
+	}
+	if err := json.Unmarshal([]byte(data), &n.Data); err != nil {
+		return n, err
+	}
+	if n.CreatedAt, err = parseTime(created); err != nil {
+		return n, err
+	}
+	n.ReadAt, err = parseTimePtr(readAt)
+	return n, err
+}
+
+// NotificationStore реализует notifications.Store поверх SQLite
+type NotificationStore struct {
+	d *DB
+}
+
+func (d *DB) Notifications() *NotificationStore {
+	return &NotificationStore{d: d}
+}
+
+func (s *NotificationStore) Add(n notifications.Notification) error {
+	// Связанные ID хранятся JSON-объектом
+	data, _ := json.Marshal(n.Data)
+	_, err := s.d.db.Exec(`INSERT INTO notifications (`+notificationColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		n.Id, n.UserID, n.Type, n.Text, n.Link, string(data), n.Key, formatTime(n.CreatedAt), formatTimePtr(n.ReadAt))
+	if isUniqueViolation(err) {
+		return notifications.ErrDuplicate
+	}
+	return err
+}
+
+func (s *NotificationStore) List(userID, beforeID string, limit int, unreadOnly bool) ([]notifications.Notification, error) {
+	query := `SELECT ` + notificationColumns + ` FROM notifications WHERE user_id = ?`
+	args := []any{userID}
+	if beforeID != "" {
+		query += ` AND rowid < (SELECT rowid FROM notifications WHERE id = ? AND user_id = ?)`
+		args = append(args, beforeID, userID)
+	}
+	if unreadOnly {
+		query += ` AND read_at IS NULL`
+	}
+	rows, err := s.d.db.Query(query+` ORDER BY rowid DESC LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []notifications.Notification{}
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, n)
+	}
+	return list, rows.Err()
+}
+
+func (s *NotificationStore) CountUnread(userID string) (int, error) {
+	var count int
+	err := s.d.db.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL`,
+		userID).Scan(&count)
+	return count, err
+}
+
+func (s *NotificationStore) MarkRead(userID, id string, at time.Time) error {
+	return s.d.execOne(notifications.ErrNotFound, `UPDATE notifications SET read_at = COALESCE(read_at, ?)
+		WHERE id = ? AND user_id = ?`, formatTime(at), id, userID)
+}
+
+func (s *NotificationStore) MarkAllRead(userID string, at time.Time) (int, error) {
+	res, err := s.d.db.Exec(`UPDATE notifications SET read_at = ? WHERE user_id = ? AND read_at IS NULL`,
+		formatTime(at), userID)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
@@ -0,0 +1,100 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"talant/events"
+)
+
+const registrationColumns = `id, event_id, user_id, username, status, created_at, promoted_at, checked_in_at`
+
+func scanRegistration(row interface{ Scan(...any) error }) (events.Registration, error) {
+	var reg events.Registration
+	var created string
+	var promoted, checkedIn sql.NullString
+	err := row.Scan(&reg.Id, &reg.EventID, &reg.UserID, &reg.Username, &reg.Status, &created, &promoted,
+		&checkedIn)
+	if err != nil {
+		return reg, err
+	}
+	if reg.CreatedAt, err = parseTime(created); err != nil {
+		return reg, err
+	}
+	if reg.PromotedAt, err = parseTimePtr(promoted); err != nil {
+		return reg, err
+	}
+	reg.CheckedInAt, err = parseTimePtr(checkedIn)
+	return reg, err
+}
+
+// RegistrationStore реализует events.RegistrationStore поверх SQLite
+type RegistrationStore struct {
+	d *DB
+}
+
+func (d *DB) Registrations() *RegistrationStore {
+	return &RegistrationStore{d: d}
+}
+
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+func queryRegistrations(q queryer, where string, args ...any) ([]events.Registration, error) {
+	rows, err := q.Query(`SELECT `+registrationColumns+` FROM registrations `+where+` ORDER BY rowid`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []events.Registration{}
+	for rows.Next() {
+		reg, err := scanRegistration(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, reg)
+	}
+	return list, rows.Err()
+}
+
+func (s *RegistrationStore) ListByEvent(eventID string) ([]events.Registration, error) {
+	return queryRegistrations(s.d.db, "WHERE event_id = ?", eventID)
+}
+
+func (s *RegistrationStore) ListByUser(userID string) ([]events.Registration, error) {
+	return queryRegistrations(s.d.db, "WHERE user_id = ?", userID)
+}
+
+// Change читает и перезаписывает записи мероприятия в одной транзакции
+func (s *RegistrationStore) Change(eventID string, fn func([]events.Registration) ([]events.Registration, error)) error {
+	tx, err := s.d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	list, err := queryRegistrations(tx, "WHERE event_id = ?", eventID)
+	if err != nil {
+		return err
+	}
+	if list, err = fn(list); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM registrations WHERE event_id = ?`, eventID); err != nil {
+		return err
+	}
+	for _, reg := range list {
+		_, err := tx.Exec(`INSERT INTO registrations (`+registrationColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			reg.Id, reg.EventID, reg.UserID, reg.Username, reg.Status,
+			formatTime(reg.CreatedAt), formatTimePtr(reg.PromotedAt), formatTimePtr(reg.CheckedInAt))
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *RegistrationStore) DeleteByEvent(eventID string) error {
+	_, err := s.d.db.Exec(`DELETE FROM registrations WHERE event_id = ?`, eventID)
+	return err
+}
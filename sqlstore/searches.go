@@ -0,0 +1,124 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"talant/searches"
+	"time"
+)
+
+const searchColumns = `id, user_id, name, kind, query, notify, email, matched, alerted_at, created_at, updated_at`
+
+// formatMatched хранит ID найденных записей JSON-массивом
+func formatMatched(ids []string) string {
+	if ids == nil {
+		ids = []string{}
+	}
+	data, _ := json.Marshal(ids)
+	return string(data)
+}
+
+func searchArgs(s searches.SavedSearch) []any {
+	return []any{s.Id, s.UserID, s.Name, s.Kind, s.Query, s.Notify, s.Email, formatMatched(s.Matched),
+		formatTimePtr(s.AlertedAt), formatTime(s.CreatedAt), formatTime(s.UpdatedAt)}
+}
+
+func scanSearch(row interface{ Scan(...any) error }) (searches.SavedSearch, error) {
+	var s searches.SavedSearch
+	var matched, created, updated string
+	var alerted sql.NullString
+	err := row.Scan(&s.Id, &s.UserID, &s.Name, &s.Kind, &s.Query, &s.Notify, &s.Email, &matched,
+		&alerted, &created, &updated)
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal([]byte(matched), &s.Matched); err != nil {
+		return s, err
+	}
+	if s.AlertedAt, err = parseTimePtr(alerted); err != nil {
+		return s, err
+	}
+	if s.CreatedAt, err = parseTime(created); err != nil {
+		return s, err
+	}
+	s.UpdatedAt, err = parseTime(updated)
+	return s, err
+}
+
+// SearchStore реализует searches.Store поверх SQLite
+type SearchStore struct {
+	d *DB
+}
+
+func (d *DB) Searches() *SearchStore {
+	return &SearchStore{d: d}
+}
+
+func (s *SearchStore) Get(id string) (searches.SavedSearch, error) {
+	saved, err := scanSearch(s.d.db.QueryRow(`SELECT `+searchColumns+` FROM saved_searches WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return searches.SavedSearch{}, searches.ErrNotFound
+	}
+	return saved, err
+}
+
+func (s *SearchStore) query(query string, args ...any) ([]searches.SavedSearch, error) {
+	rows, err := s.d.db.Query(`SELECT `+searchColumns+` FROM saved_searches `+query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []searches.SavedSearch{}
+	for rows.Next() {
+		saved, err := scanSearch(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, saved)
+	}
+	return list, rows.Err()
+}
+
+func (s *SearchStore) ListByUser(userID string) ([]searches.SavedSearch, error) {
+	return s.query(`WHERE user_id = ? ORDER BY rowid`, userID)
+}
+
+func (s *SearchStore) List() ([]searches.SavedSearch, error) {
+	return s.query(`ORDER BY rowid`)
+}
+
+func (s *SearchStore) Create(saved searches.SavedSearch) error {
+	_, err := s.d.db.Exec(`INSERT INTO saved_searches (`+searchColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, searchArgs(saved)...)
+	return err
+}
+
+func (s *SearchStore) Update(saved searches.SavedSearch) error {
+	return s.d.execOne(searches.ErrNotFound, `UPDATE saved_searches SET user_id = ?, name = ?, kind = ?,
+		query = ?, notify = ?, email = ?, created_at = ?, updated_at = ? WHERE id = ?`,
+		saved.UserID, saved.Name, saved.Kind, saved.Query, saved.Notify, saved.Email,
+		formatTime(saved.CreatedAt), formatTime(saved.UpdatedAt), saved.Id)
+}
+
+func (s *SearchStore) Delete(id string) error {
+	return s.d.execOne(searches.ErrNotFound, `DELETE FROM saved_searches WHERE id = ?`, id)
+}
+
+func (s *SearchStore) SetMatched(id, query string, matched []string, alertedAt *time.Time) error {
+	res, err := s.d.db.Exec(`UPDATE saved_searches SET matched = ?, alerted_at = COALESCE(?, alerted_at)
+		WHERE id = ? AND query = ?`, formatMatched(matched), formatTimePtr(alertedAt), id, query)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	// Строка не нашлась: поиск удален (ErrNotFound) или фильтр уже сменили
+	if n == 0 {
+		_, err = s.Get(id)
+	}
+	return err
+}
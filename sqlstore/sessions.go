@@ -0,0 +1,96 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"errors"
+	"talant/auth"
+	"time"
+)
+
+const sessionColumns = `id, user_id, refresh_hash, prev_refresh_hash, rotated_at, user_agent, ip,
+	created_at, last_used_at, expires_at, revoked_at`
+
+func sessionArgs(s auth.Session) []any {
+	return []any{s.ID, s.UserID, s.RefreshHash, s.PrevRefreshHash, formatTime(s.RotatedAt),
+		s.UserAgent, s.IP, formatTime(s.CreatedAt), formatTime(s.LastUsedAt),
+		formatTime(s.ExpiresAt), formatTimePtr(s.RevokedAt)}
+}
+
+func scanSession(row interface{ Scan(...any) error }) (auth.Session, error) {
+	var s auth.Session
+	var rotated, created, lastUsed, expires string
+	var revoked sql.NullString
+	err := row.Scan(&s.ID, &s.UserID, &s.RefreshHash, &s.PrevRefreshHash, &rotated,
+		&s.UserAgent, &s.IP, &created, &lastUsed, &expires, &revoked)
+	if err != nil {
+		return s, err
+	}
+	for _, f := range []struct {
+		dst *time.Time
+		src string
+	}{{&s.RotatedAt, rotated}, {&s.CreatedAt, created}, {&s.LastUsedAt, lastUsed}, {&s.ExpiresAt, expires}} {
+		if *f.dst, err = parseTime(f.src); err != nil {
+			return s, err
+		}
+	}
+	s.RevokedAt, err = parseTimePtr(revoked)
+	return s, err
+}
+
+// SessionStore реализует auth.SessionStore поверх SQLite
+type SessionStore struct {
+	d *DB
+}
+
+func (d *DB) Sessions() *SessionStore {
+	return &SessionStore{d: d}
+}
+
+func (s *SessionStore) query(where string, args ...any) ([]auth.Session, error) {
+	rows, err := s.d.db.Query(`SELECT `+sessionColumns+` FROM sessions `+where+` ORDER BY rowid`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []auth.Session{}
+	for rows.Next() {
+		sess, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, sess)
+	}
+	return list, rows.Err()
+}
+
+func (s *SessionStore) List() ([]auth.Session, error) {
+	return s.query("")
+}
+
+func (s *SessionStore) Get(id string) (auth.Session, error) {
+	sess, err := scanSession(s.d.db.QueryRow(`SELECT `+sessionColumns+` FROM sessions WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return auth.Session{}, auth.ErrSessionNotFound
+	}
+	return sess, err
+}
+
+func (s *SessionStore) ListByUser(userID string) ([]auth.Session, error) {
+	return s.query("WHERE user_id = ?", userID)
+}
+
+func (s *SessionStore) Create(sess auth.Session) error {
+	_, err := s.d.db.Exec(`INSERT INTO sessions (`+sessionColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		sessionArgs(sess)...)
+	return err
+}
+
+func (s *SessionStore) Update(sess auth.Session) error {
+	args := sessionArgs(sess)
+	return s.d.execOne(auth.ErrSessionNotFound,
+		`UPDATE sessions SET user_id = ?, refresh_hash = ?, prev_refresh_hash = ?, rotated_at = ?,
+			user_agent = ?, ip = ?, created_at = ?, last_used_at = ?, expires_at = ?, revoked_at = ?
+		WHERE id = ?`,
+		append(args[1:], args[0])...)
+}
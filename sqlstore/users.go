@@ -0,0 +1,131 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"talant/auth"
+)
+
+const userColumns = `id, username, usermail, password, email_verified, email_verified_at, created_at, updated_at, roles, ban,
+	calendar_key`
+
+const insertUserSQL = `INSERT INTO users (` + userColumns + `) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+func userArgs(u auth.User) []any {
+	return []any{u.Id, u.Username, u.Usermail, u.Password, u.EmailVerified,
+		formatTimePtr(u.EmailVerifiedAt), formatTime(u.CreatedAt), formatTime(u.UpdatedAt),
+		joinRoles(u.Roles), formatJSON(u.Ban), u.CalendarKey}
+}
+
+// Роли хранятся одной строкой через запятую
+func joinRoles(roles []auth.Role) string {
+	list := make([]string, len(roles))
+	for i, role := range roles {
+		list[i] = string(role)
+	}
+	return strings.Join(list, ",")
+}
+
+func splitRoles(s string) []auth.Role {
+	var roles []auth.Role
+	for _, item := range strings.Split(s, ",") {
+		if item != "" {
+			roles = append(roles, auth.Role(item))
+		}
+	}
+	return roles
+}
+
+func scanUser(row interface{ Scan(...any) error }) (auth.User, error) {
+	var u auth.User
+	var verifiedAt sql.NullString
+	var created, updated, roles string
+	var ban sql.NullString
+	err := row.Scan(&u.Id, &u.Username, &u.Usermail, &u.Password, &u.EmailVerified,
+		&verifiedAt, &created, &updated, &roles, &ban, &u.CalendarKey)
+	if err != nil {
+		return u, err
+	}
+	if err := parseJSON(ban, &u.Ban); err != nil {
+		return u, err
+	}
+	u.Roles = splitRoles(roles)
+	if u.EmailVerifiedAt, err = parseTimePtr(verifiedAt); err != nil {
+		return u, err
+	}
+	if u.CreatedAt, err = parseTime(created); err != nil {
+		return u, err
+	}
+	u.UpdatedAt, err = parseTime(updated)
+	return u, err
+}
+
+// UserStore реализует auth.UserStore поверх SQLite
+type UserStore struct {
+	d *DB
+}
+
+func (d *DB) Users() *UserStore {
+	return &UserStore{d: d}
+}
+
+func (s *UserStore) List() ([]auth.User, error) {
+	rows, err := s.d.db.Query(`SELECT ` + userColumns + ` FROM users ORDER BY rowid`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []auth.User{}
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, u)
+	}
+	return list, rows.Err()
+}
+
+func (s *UserStore) getBy(column, value string) (auth.User, error) {
+	u, err := scanUser(s.d.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE `+column+` = ?`, value))
+	if errors.Is(err, sql.ErrNoRows) {
+		return auth.User{}, auth.ErrNotFound
+	}
+	return u, err
+}
+
+func (s *UserStore) GetByID(id string) (auth.User, error) {
+	return s.getBy("id", id)
+}
+
+func (s *UserStore) GetByUsername(username string) (auth.User, error) {
+	return s.getBy("username", username)
+}
+
+func (s *UserStore) GetByEmail(email string) (auth.User, error) {
+	return s.getBy("usermail", email)
+}
+
+func (s *UserStore) Create(u auth.User) error {
+	_, err := s.d.db.Exec(insertUserSQL, userArgs(u)...)
+	if isUniqueViolation(err) {
+		return auth.ErrUserExists
+	}
+	return err
+}
+
+func (s *UserStore) Update(u auth.User) error {
+	err := s.d.execOne(auth.ErrNotFound,
+		`UPDATE users SET username = ?, usermail = ?, password = ?, email_verified = ?,
+			email_verified_at = ?, created_at = ?, updated_at = ?, roles = ?, ban = ?,
+			calendar_key = ? WHERE id = ?`,
+		u.Username, u.Usermail, u.Password, u.EmailVerified,
+		formatTimePtr(u.EmailVerifiedAt), formatTime(u.CreatedAt), formatTime(u.UpdatedAt),
+		joinRoles(u.Roles), formatJSON(u.Ban), u.CalendarKey, u.Id)
+	if isUniqueViolation(err) {
+		return auth.ErrUserExists
+	}
+	return err
+}
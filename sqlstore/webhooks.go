@@ -0,0 +1,180 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"talant/webhooks"
+	"time"
+)
+
+const webhookColumns = `id, user_id, url, secret, events, active, created_at, updated_at`
+
+const deliveryColumns = `id, webhook_id, event, payload, status, attempts, response_code, error,
+	created_at, next_attempt_at, delivered_at`
+
+func webhookArgs(w webhooks.Webhook) []any {
+	// Подписки хранятся JSON-массивом
+	events, _ := json.Marshal(w.Events)
+	return []any{w.Id, w.UserID, w.URL, w.Secret, string(events), w.Active,
+		formatTime(w.CreatedAt), formatTime(w.UpdatedAt)}
+}
+
+func scanWebhook(row interface{ Scan(...any) error }) (webhooks.Webhook, error) {
+	var w webhooks.Webhook
+	var events, created, updated string
+	err := row.Scan(&w.Id, &w.UserID, &w.URL, &w.Secret, &events, &w.Active, &created, &updated)
+	if err != nil {
+		return w, err
+	}
+	if err := json.Unmarshal([]byte(events), &w.Events); err != nil {
+		return w, err
+	}
+	if w.CreatedAt, err = parseTime(created); err != nil {
+		return w, err
+	}
+	w.UpdatedAt, err = parseTime(updated)
+	return w, err
+}
+
+func deliveryArgs(d webhooks.Delivery) []any {
+	return []any{d.Id, d.WebhookID, d.Event, string(d.Payload), d.Status, d.Attempts, d.ResponseCode, d.Error,
+		formatTime(d.CreatedAt), formatTimePtr(d.NextAttemptAt), formatTimePtr(d.DeliveredAt)}
+}
+
+func scanDelivery(row interface{ Scan(...any) error }) (webhooks.Delivery, error) {
+	var d webhooks.Delivery
+	var payload, created string
+	var next, delivered sql.NullString
+	err := row.Scan(&d.Id, &d.WebhookID, &d.Event, &payload, &d.Status, &d.Attempts, &d.ResponseCode, &d.Error,
+		&created, &next, &delivered)
+	if err != nil {
+		return d, err
+	}
+	d.Payload = json.RawMessage(payload)
+	if d.CreatedAt, err = parseTime(created); err != nil {
+		return d, err
+	}
+	if d.NextAttemptAt, err = parseTimePtr(next); err != nil {
+		return d, err
+	}
+	d.DeliveredAt, err = parseTimePtr(delivered)
+	return d, err
+}
+
+// WebhookStore реализует webhooks.Store поверх SQLite
+type WebhookStore struct {
+	d *DB
+}
+
+func (d *DB) Webhooks() *WebhookStore {
+	return &WebhookStore{d: d}
+}
+
+func (s *WebhookStore) Get(id string) (webhooks.Webhook, error) {
+	w, err := scanWebhook(s.d.db.QueryRow(`SELECT `+webhookColumns+` FROM webhooks WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return webhooks.Webhook{}, webhooks.ErrNotFound
+	}
+	return w, err
+}
+
+func (s *WebhookStore) ListByUser(userID string) ([]webhooks.Webhook, error) {
+	rows, err := s.d.db.Query(`SELECT `+webhookColumns+` FROM webhooks WHERE user_id = ? ORDER BY rowid`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []webhooks.Webhook{}
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, w)
+	}
+	return list, rows.Err()
+}
+
+func (s *WebhookStore) Create(w webhooks.Webhook) error {
+	_, err := s.d.db.Exec(`INSERT INTO webhooks (`+webhookColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		webhookArgs(w)...)
+	return err
+}
+
+func (s *WebhookStore) Update(w webhooks.Webhook) error {
+	args := append(webhookArgs(w)[1:], w.Id)
+	return s.d.execOne(webhooks.ErrNotFound, `UPDATE webhooks SET user_id = ?, url = ?, secret = ?, events = ?,
+		active = ?, created_at = ?, updated_at = ? WHERE id = ?`, args...)
+}
+
+func (s *WebhookStore) Delete(id string) error {
+	tx, err := s.d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`DELETE FROM webhooks WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return webhooks.ErrNotFound
+	}
+	if _, err := tx.Exec(`DELETE FROM webhook_deliveries WHERE webhook_id = ?`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *WebhookStore) AddDelivery(d webhooks.Delivery) error {
+	_, err := s.d.db.Exec(`INSERT INTO webhook_deliveries (`+deliveryColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, deliveryArgs(d)...)
+	return err
+}
+
+func (s *WebhookStore) UpdateDelivery(d webhooks.Delivery) error {
+	args := append(deliveryArgs(d)[1:], d.Id)
+	return s.d.execOne(webhooks.ErrNotFound, `UPDATE webhook_deliveries SET webhook_id = ?, event = ?, payload = ?,
+		status = ?, attempts = ?, response_code = ?, error = ?, created_at = ?, next_attempt_at = ?,
+		delivered_at = ? WHERE id = ?`, args...)
+}
+
+func (s *WebhookStore) queryDeliveries(query string, args ...any) ([]webhooks.Delivery, error) {
+	rows, err := s.d.db.Query(`SELECT `+deliveryColumns+` FROM webhook_deliveries `+query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []webhooks.Delivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, d)
+	}
+	return list, rows.Err()
+}
+
+func (s *WebhookStore) ListDeliveries(webhookID string, limit int) ([]webhooks.Delivery, error) {
+	return s.queryDeliveries(`WHERE webhook_id = ? ORDER BY rowid DESC LIMIT ?`, webhookID, limit)
+}
+
+func (s *WebhookStore) DueDeliveries(now time.Time) ([]webhooks.Delivery, error) {
+	return s.queryDeliveries(`WHERE status = ? AND next_attempt_at <= ? ORDER BY rowid`,
+		webhooks.StatusPending, formatTime(now))
+}
+
+func (s *WebhookStore) DeleteDeliveriesBefore(t time.Time) error {
+	_, err := s.d.db.Exec(`DELETE FROM webhook_deliveries WHERE status != ? AND created_at < ?`,
+		webhooks.StatusPending, formatTime(t))
+	return err
+}
@@ -0,0 +1,307 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"talant/ankety"
+	"talant/job"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Доставка - POST на URL вебхука с JSON-телом
+//
+//	{"id": "<ID доставки>", "event": "job.created", "created_at": "...", "data": {...}}
+//
+// и заголовками X-Talant-Event, X-Talant-Delivery и X-Talant-Signature-256:
+// "sha256=" + hex(HMAC-SHA256(секрет, тело)). Успех - любой ответ 2xx; иначе
+// попытка повторяется через паузы из backoff, после последней доставка - failed.
+
+// DeliveryStatus - состояние доставки
+type DeliveryStatus string
+
+const (
+	StatusPending DeliveryStatus = "pending" // ждет попытки
+	StatusSuccess DeliveryStatus = "success"
+	StatusFailed  DeliveryStatus = "failed" // попытки кончились
+)
+
+// backoff - паузы перед повторными попытками; попыток на одну больше
+var backoff = []time.Duration{30 * time.Second, 2 * time.Minute, 10 * time.Minute, time.Hour, 6 * time.Hour}
+
+const (
+	// deliveryTimeout - сколько ждать ответа получателя
+	deliveryTimeout = 10 * time.Second
+	// pollInterval - как часто доставка проверяет, не пора ли повторить попытки
+	pollInterval = 10 * time.Second
+	// retention - сколько хранить завершенные доставки в журнале
+	retention = 30 * 24 * time.Hour
+	// maxErrorLength - сколько байт ответа или ошибки сохранять в журнале
+	maxErrorLength = 500
+)
+
+// Delivery - одна доставка события вебхуку со всеми попытками
+type Delivery struct {
+	Id        string          `json:"id"`
+	WebhookID string          `json:"webhook_id"`
+	Event     Event           `json:"event"`
+	Payload   json.RawMessage `json:"payload"`
+	Status    DeliveryStatus  `json:"status"`
+	Attempts  int             `json:"attempts"`
+	// ResponseCode и Error - итог последней попытки
+	ResponseCode  int        `json:"response_code,omitempty"`
+	Error         string     `json:"error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+}
+
+var errPrivateAddress = errors.New("address is not public")
+
+// client доставляет вебхуки. По умолчанию он не ходит на внутренние адреса
+// (localhost, частные сети): иначе через вебхук можно было бы опрашивать
+// сервисы рядом с сервером. Адрес проверяется при соединении, уже после
+// DNS, поэтому его не обойти именем, которое указывает внутрь.
+var client = newClient(false)
+
+func newClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: deliveryTimeout}
+	if !allowPrivate {
+		dialer.Control = checkPublic
+	}
+	return &http.Client{
+		Timeout: deliveryTimeout,
+		// Прокси из окружения не используем: проверять нужно адрес получателя
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: deliveryTimeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     time.Minute,
+		},
+		// Перенаправления не выполняем: 3xx - неудачная попытка
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// checkPublic - Control для net.Dialer: пускает соединение только на публичный
+// адрес (address - уже IP и порт)
+func checkPublic(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	ip := addrPort.Addr().Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return errPrivateAddress
+	}
+	return nil
+}
+
+// Configure разрешает доставку на внутренние адреса (для разработки и тестов
+// с локальным получателем)
+func Configure(allowPrivate bool) {
+	client = newClient(allowPrivate)
+}
+
+// Sign - подпись тела доставки для заголовка X-Talant-Signature-256
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// newDelivery собирает доставку события вебхуку; попытка - сразу
+func newDelivery(hook Webhook, event Event, data any) (Delivery, error) {
+	now := time.Now()
+	d := Delivery{
+		Id:            uuid.New().String(),
+		WebhookID:     hook.Id,
+		Event:         event,
+		Status:        StatusPending,
+		CreatedAt:     now,
+		NextAttemptAt: &now,
+	}
+	payload, err := json.Marshal(struct {
+		Id        string    `json:"id"`
+		Event     Event     `json:"event"`
+		CreatedAt time.Time `json:"created_at"`
+		Data      any       `json:"data"`
+	}{d.Id, event, now, data})
+	d.Payload = payload
+	return d, err
+}
+
+func truncate(s string) string {
+	if len(s) > maxErrorLength {
+		return s[:maxErrorLength]
+	}
+	return s
+}
+
+// send делает одну попытку доставки и возвращает код ответа
+func send(ctx context.Context, hook Webhook, d Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Talant-Webhooks/1.0")
+	req.Header.Set("X-Talant-Event", string(d.Event))
+	req.Header.Set("X-Talant-Delivery", d.Id)
+	req.Header.Set("X-Talant-Signature-256", Sign(hook.Secret, d.Payload))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorLength))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, body)
+	}
+	return resp.StatusCode, nil
+}
+
+// attempt делает попытку и записывает ее итог в d. retry - планировать ли
+// повтор после неудачи; без него неудачная доставка сразу failed.
+func attempt(ctx context.Context, hook Webhook, d *Delivery, retry bool) {
+	code, err := send(ctx, hook, *d)
+	now := time.Now()
+	d.Attempts++
+	d.ResponseCode = code
+	d.Error = ""
+	d.NextAttemptAt = nil
+	switch {
+	case err == nil:
+		d.Status = StatusSuccess
+		d.DeliveredAt = &now
+	case retry && d.Attempts <= len(backoff):
+		d.Error = truncate(err.Error())
+		next := now.Add(backoff[d.Attempts-1])
+		d.NextAttemptAt = &next
+	default:
+		d.Error = truncate(err.Error())
+		d.Status = StatusFailed
+	}
+}
+
+// wake будит доставку, когда появились новые события
+var wake = make(chan struct{}, 1)
+
+// enqueue ставит в очередь событие для подписанных вебхуков пользователя
+func enqueue(userID string, event Event, data any) error {
+	list, err := store.ListByUser(userID)
+	if err != nil {
+		return err
+	}
+	queued := false
+	for _, hook := range list {
+		if !hook.subscribed(event) {
+			continue
+		}
+		d, err := newDelivery(hook, event, data)
+		if err != nil {
+			return err
+		}
+		if err := store.AddDelivery(d); err != nil {
+			return err
+		}
+		queued = true
+	}
+	if queued {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// JobChanged ставит в очередь событие о вакансии для вебхуков ее владельца
+// (подключается через job.OnChange)
+func JobChanged(e job.Event, j job.Job) error {
+	return enqueue(j.UserID, Event("job."+string(e)), j)
+}
+
+// AnketaChanged ставит в очередь событие об анкете для вебхуков ее владельца
+// (подключается через ankety.OnChange)
+func AnketaChanged(e ankety.Event, a ankety.Ankety) error {
+	return enqueue(a.UserId, Event("anketa."+string(e)), a)
+}
+
+// deliverDue делает попытки по всем доставкам, время которых наступило
+func deliverDue(ctx context.Context) error {
+	due, err := store.DueDeliveries(time.Now())
+	if err != nil {
+		return err
+	}
+	for _, d := range due {
+		if ctx.Err() != nil {
+			return nil
+		}
+		hook, err := store.Get(d.WebhookID)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			// Вебхук удален вместе с журналом, пока доставка ждала
+			continue
+		case err != nil:
+			return err
+		case !hook.Active:
+			d.Status = StatusFailed
+			d.Error = "webhook is disabled"
+			d.NextAttemptAt = nil
+		default:
+			attempt(ctx, hook, &d, true)
+			// Прерванную остановкой сервера попытку не засчитываем
+			if ctx.Err() != nil {
+				return nil
+			}
+		}
+		if d.Status == StatusFailed {
+			fmt.Printf("Доставка %s (%s) вебхуку %s не удалась: %s\n", d.Id, d.Event, hook.Id, d.Error)
+		}
+		if err := store.UpdateDelivery(d); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run доставляет события, пока не отменен ctx: сразу по новым событиям и раз в
+// pollInterval - повторные попытки. Заодно чистит журнал от старых доставок.
+func Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	var cleaned time.Time
+	for {
+		if err := deliverDue(ctx); err != nil {
+			fmt.Printf("Ошибка доставки вебхуков: %v\n", err)
+		}
+		if time.Since(cleaned) > time.Hour {
+			if err := store.DeleteDeliveriesBefore(time.Now().Add(-retention)); err != nil {
+				fmt.Printf("Ошибка очистки журнала вебхуков: %v\n", err)
+			}
+			cleaned = time.Now()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-wake:
+		}
+	}
+}
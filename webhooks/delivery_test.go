@@ -0,0 +1,212 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// useClient подменяет клиента доставки на время теста
+func useClient(t *testing.T, allowPrivate bool) {
+	t.Helper()
+	prev := client
+	client = newClient(allowPrivate)
+	t.Cleanup(func() { client = prev })
+}
+
+func testHook(url string) Webhook {
+	return Webhook{Id: "hook-1", UserID: "user-1", URL: url, Secret: "0123456789abcdef",
+		Events: []Event{EventJobCreated}, Active: true}
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '{"a":1}' | openssl dgst -sha256 -hmac secret
+	want := "sha256=aa9e2e3575f5d7098b6caccd790888c36d5fdb63342a73bada2d6a51747a8494"
+	if got := Sign("secret", []byte(`{"a":1}`)); got != want {
+		t.Errorf("Sign = %q, want %q", got, want)
+	}
+	if Sign("other", []byte(`{"a":1}`)) == want {
+		t.Error("signature does not depend on the secret")
+	}
+}
+
+func TestSendSignsBody(t *testing.T) {
+	useClient(t, true)
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	got := make(chan received, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- received{r.Header.Clone(), body}
+	}))
+	defer srv.Close()
+
+	hook := testHook(srv.URL)
+	d, err := newDelivery(hook, EventJobCreated, map[string]string{"id": "job-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := send(context.Background(), hook, d)
+	if err != nil || code != http.StatusOK {
+		t.Fatalf("send = %d, %v; want 200, nil", code, err)
+	}
+
+	r := <-got
+	if string(r.body) != string(d.Payload) {
+		t.Errorf("body = %s, want %s", r.body, d.Payload)
+	}
+	if sig := r.header.Get("X-Talant-Signature-256"); sig != Sign(hook.Secret, r.body) {
+		t.Errorf("X-Talant-Signature-256 = %q, want %q", sig, Sign(hook.Secret, r.body))
+	}
+	if e := r.header.Get("X-Talant-Event"); e != string(EventJobCreated) {
+		t.Errorf("X-Talant-Event = %q, want %q", e, EventJobCreated)
+	}
+	if id := r.header.Get("X-Talant-Delivery"); id != d.Id {
+		t.Errorf("X-Talant-Delivery = %q, want %q", id, d.Id)
+	}
+}
+
+func TestAttemptRetriesWithBackoff(t *testing.T) {
+	useClient(t, true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	hook := testHook(srv.URL)
+	d, err := newDelivery(hook, EventJobCreated, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, pause := range backoff {
+		before := time.Now()
+		attempt(context.Background(), hook, &d, true)
+		if d.Status != StatusPending || d.Attempts != i+1 || d.ResponseCode != http.StatusServiceUnavailable {
+			t.Fatalf("attempt %d: status %s, attempts %d, code %d; want pending, %d, 503",
+				i+1, d.Status, d.Attempts, d.ResponseCode, i+1)
+		}
+		if d.NextAttemptAt == nil || d.NextAttemptAt.Before(before.Add(pause)) || d.NextAttemptAt.After(time.Now().Add(pause)) {
+			t.Fatalf("attempt %d: next attempt at %v, want in %v", i+1, d.NextAttemptAt, pause)
+		}
+	}
+	// После последней паузы попыток больше нет
+	attempt(context.Background(), hook, &d, true)
+	if d.Status != StatusFailed || d.NextAttemptAt != nil || d.Error == "" {
+		t.Fatalf("last attempt: status %s, next %v, error %q; want failed, nil, error", d.Status, d.NextAttemptAt, d.Error)
+	}
+}
+
+func TestDeliverDueRetriesUntilSuccess(t *testing.T) {
+	useClient(t, true)
+	prevStore := store
+	SetStore(NewJSONStore(filepath.Join(t.TempDir(), "webhooks.json")))
+	t.Cleanup(func() { store = prevStore })
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			http.Error(w, "oops", http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	hook := testHook(srv.URL)
+	if err := store.Create(hook); err != nil {
+		t.Fatal(err)
+	}
+	if err := enqueue(hook.UserID, EventJobCreated, map[string]string{"id": "job-1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Первая попытка - 500, доставка ждет повтора
+	if err := deliverDue(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	list, err := store.ListDeliveries(hook.Id, 10)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("ListDeliveries = %v, %v; want one delivery", list, err)
+	}
+	d := list[0]
+	if d.Status != StatusPending || d.Attempts != 1 || d.ResponseCode != http.StatusInternalServerError {
+		t.Fatalf("after 500: status %s, attempts %d, code %d; want pending, 1, 500", d.Status, d.Attempts, d.ResponseCode)
+	}
+
+	// До паузы повтора не бывает
+	if err := deliverDue(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("receiver called %d times before backoff elapsed, want 1", n)
+	}
+
+	// Пауза прошла - вторая попытка успешна
+	past := time.Now().Add(-time.Second)
+	d.NextAttemptAt = &past
+	if err := store.UpdateDelivery(d); err != nil {
+		t.Fatal(err)
+	}
+	if err := deliverDue(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	list, _ = store.ListDeliveries(hook.Id, 10)
+	if d := list[0]; d.Status != StatusSuccess || d.Attempts != 2 || d.DeliveredAt == nil {
+		t.Fatalf("after retry: status %s, attempts %d, delivered %v; want success, 2, set", d.Status, d.Attempts, d.DeliveredAt)
+	}
+}
+
+func TestCheckPublic(t *testing.T) {
+	tests := []struct {
+		address string
+		public  bool
+	}{
+		{"127.0.0.1:80", false},
+		{"[::1]:443", false},
+		{"10.1.2.3:80", false},
+		{"172.16.0.1:80", false},
+		{"192.168.1.10:8080", false},
+		{"169.254.169.254:80", false}, // метаданные облака
+		{"0.0.0.0:80", false},
+		{"[fd00::1]:80", false},
+		{"[::ffff:127.0.0.1]:80", false},
+		{"8.8.8.8:443", true},
+		{"[2606:4700::1111]:443", true},
+	}
+	for _, tt := range tests {
+		err := checkPublic("tcp", tt.address, nil)
+		if tt.public && err != nil {
+			t.Errorf("checkPublic(%s) = %v, want nil", tt.address, err)
+		}
+		if !tt.public && !errors.Is(err, errPrivateAddress) {
+			t.Errorf("checkPublic(%s) = %v, want errPrivateAddress", tt.address, err)
+		}
+	}
+}
+
+func TestSendRejectsLoopback(t *testing.T) {
+	useClient(t, false)
+	var called atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called.Store(true)
+	}))
+	defer srv.Close()
+
+	hook := testHook(srv.URL)
+	d, err := newDelivery(hook, EventJobCreated, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := send(context.Background(), hook, d); !errors.Is(err, errPrivateAddress) {
+		t.Fatalf("send to %s = %v, want errPrivateAddress", srv.URL, err)
+	}
+	if called.Load() {
+		t.Fatal("loopback receiver was called")
+	}
+}
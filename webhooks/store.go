@@ -0,0 +1,314 @@
+package webhooks
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"talant/jsonfile"
+	"talant/memstore"
+	"time"
+)
+
+// ErrNotFound возвращается хранилищем, если вебхук или доставка не найдены
+var ErrNotFound = errors.New("webhook not found")
+
+// Store - хранилище вебхуков и журнала их доставок
+type Store interface {
+	Get(id string) (Webhook, error)
+	ListByUser(userID string) ([]Webhook, error)
+	Create(w Webhook) error
+	Update(w Webhook) error
+	// Delete удаляет вебхук вместе с журналом доставок
+	Delete(id string) error
+
+	AddDelivery(d Delivery) error
+	UpdateDelivery(d Delivery) error
+	// ListDeliveries - до limit последних доставок вебхука, новые первыми
+	ListDeliveries(webhookID string, limit int) ([]Delivery, error)
+	// DueDeliveries - ожидающие доставки, время попытки которых наступило, по порядку создания
+	DueDeliveries(now time.Time) ([]Delivery, error)
+	// DeleteDeliveriesBefore удаляет из журнала завершенные доставки старше t
+	DeleteDeliveriesBefore(t time.Time) error
+}
+
+var store Store = NewJSONStore("webhooks.json")
+
+// SetStore подменяет хранилище, с которым работают обработчики и доставка
+func SetStore(s Store) {
+	store = s
+}
+
+// DeliveriesPath - файл журнала доставок рядом с файлом вебхуков:
+// webhooks.json -> webhooks_deliveries.json
+func DeliveriesPath(path string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "_deliveries" + ext
+}
+
+func isDue(d Delivery, now time.Time) bool {
+	return d.Status == StatusPending && d.NextAttemptAt != nil && !d.NextAttemptAt.After(now)
+}
+
+func isStale(d Delivery, t time.Time) bool {
+	return d.Status != StatusPending && d.CreatedAt.Before(t)
+}
+
+// newestFirst - до limit записей с конца списка в обратном порядке
+func newestFirst(list []Delivery, limit int) []Delivery {
+	page := []Delivery{}
+	for i := len(list) - 1; i >= 0 && len(page) < limit; i-- {
+		page = append(page, list[i])
+	}
+	return page
+}
+
+// JSONStore хранит вебхуки и доставки двумя JSON-файлами
+type JSONStore struct {
+	hooks      *jsonfile.File[Webhook]
+	deliveries *jsonfile.File[Delivery]
+}
+
+func NewJSONStore(path string) *JSONStore {
+	return &JSONStore{
+		hooks:      jsonfile.New[Webhook](path),
+		deliveries: jsonfile.New[Delivery](DeliveriesPath(path)),
+	}
+}
+
+func (s *JSONStore) Get(id string) (Webhook, error) {
+	list, err := s.hooks.Load()
+	if err != nil {
+		return Webhook{}, err
+	}
+	for _, w := range list {
+		if w.Id == id {
+			return w, nil
+		}
+	}
+	return Webhook{}, ErrNotFound
+}
+
+func (s *JSONStore) ListByUser(userID string) ([]Webhook, error) {
+	list, err := s.hooks.Load()
+	if err != nil {
+		return nil, err
+	}
+	found := []Webhook{}
+	for _, w := range list {
+		if w.UserID == userID {
+			found = append(found, w)
+		}
+	}
+	return found, nil
+}
+
+func (s *JSONStore) Create(w Webhook) error {
+	return s.hooks.Update(func(list []Webhook) ([]Webhook, error) {
+		return append(list, w), nil
+	})
+}
+
+func (s *JSONStore) Update(w Webhook) error {
+	return s.hooks.Update(func(list []Webhook) ([]Webhook, error) {
+		for i := range list {
+			if list[i].Id == w.Id {
+				list[i] = w
+				return list, nil
+			}
+		}
+		return nil, ErrNotFound
+	})
+}
+
+func (s *JSONStore) Delete(id string) error {
+	err := s.hooks.Update(func(list []Webhook) ([]Webhook, error) {
+		for i := range list {
+			if list[i].Id == id {
+				return append(list[:i], list[i+1:]...), nil
+			}
+		}
+		return nil, ErrNotFound
+	})
+	if err != nil {
+		return err
+	}
+	return s.deliveries.Update(func(list []Delivery) ([]Delivery, error) {
+		return slices.DeleteFunc(list, func(d Delivery) bool { return d.WebhookID == id }), nil
+	})
+}
+
+func (s *JSONStore) AddDelivery(d Delivery) error {
+	return s.deliveries.Update(func(list []Delivery) ([]Delivery, error) {
+		return append(list, d), nil
+	})
+}
+
+func (s *JSONStore) UpdateDelivery(d Delivery) error {
+	return s.deliveries.Update(func(list []Delivery) ([]Delivery, error) {
+		for i := range list {
+			if list[i].Id == d.Id {
+				list[i] = d
+				return list, nil
+			}
+		}
+		return nil, ErrNotFound
+	})
+}
+
+func (s *JSONStore) ListDeliveries(webhookID string, limit int) ([]Delivery, error) {
+	list, err := s.deliveries.Load()
+	if err != nil {
+		return nil, err
+	}
+	own := []Delivery{}
+	for _, d := range list {
+		if d.WebhookID == webhookID {
+			own = append(own, d)
+		}
+	}
+	return newestFirst(own, limit), nil
+}
+
+func (s *JSONStore) DueDeliveries(now time.Time) ([]Delivery, error) {
+	list, err := s.deliveries.Load()
+	if err != nil {
+		return nil, err
+	}
+	due := []Delivery{}
+	for _, d := range list {
+		if isDue(d, now) {
+			due = append(due, d)
+		}
+	}
+	return due, nil
+}
+
+func (s *JSONStore) DeleteDeliveriesBefore(t time.Time) error {
+	return s.deliveries.Update(func(list []Delivery) ([]Delivery, error) {
+		return slices.DeleteFunc(list, func(d Delivery) bool { return isStale(d, t) }), nil
+	})
+}
+
+// CachedStore держит вебхуки и доставки в памяти, каждые со своим журналом
+type CachedStore struct {
+	hooks      *memstore.Store[Webhook]
+	deliveries *memstore.Store[Delivery]
+}
+
+// NewCachedStore загружает вебхуки из path, а доставки - из DeliveriesPath(path)
+func NewCachedStore(path string) (*CachedStore, error) {
+	hooks, err := memstore.Open(memstore.Options[Webhook]{
+		Snapshot: path,
+		ID:       func(w Webhook) string { return w.Id },
+		Indexes: map[string]func(Webhook) string{
+			"user": func(w Webhook) string { return w.UserID },
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	deliveries, err := memstore.Open(memstore.Options[Delivery]{
+		Snapshot: DeliveriesPath(path),
+		ID:       func(d Delivery) string { return d.Id },
+		Indexes: map[string]func(Delivery) string{
+			"webhook": func(d Delivery) string { return d.WebhookID },
+			// Индексируются только ожидающие: их перебирает доставка
+			"status": func(d Delivery) string {
+				if d.Status == StatusPending {
+					return string(d.Status)
+				}
+				return ""
+			},
+		},
+	})
+	if err != nil {
+		hooks.Close()
+		return nil, err
+	}
+	return &CachedStore{hooks: hooks, deliveries: deliveries}, nil
+}
+
+func (s *CachedStore) Close() error {
+	return errors.Join(s.hooks.Close(), s.deliveries.Close())
+}
+
+func (s *CachedStore) Get(id string) (Webhook, error) {
+	w, ok := s.hooks.Get(id)
+	if !ok {
+		return Webhook{}, ErrNotFound
+	}
+	return w, nil
+}
+
+func (s *CachedStore) ListByUser(userID string) ([]Webhook, error) {
+	return s.hooks.Find("user", userID), nil
+}
+
+func (s *CachedStore) Create(w Webhook) error {
+	return s.hooks.Put(w, nil)
+}
+
+func (s *CachedStore) Update(w Webhook) error {
+	return s.hooks.Put(w, func(v memstore.View[Webhook]) error {
+		if _, ok := v.Get(w.Id); !ok {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (s *CachedStore) Delete(id string) error {
+	ok, err := s.hooks.Delete(id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFound
+	}
+	for _, d := range s.deliveries.Find("webhook", id) {
+		if _, err := s.deliveries.Delete(d.Id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *CachedStore) AddDelivery(d Delivery) error {
+	return s.deliveries.Put(d, nil)
+}
+
+func (s *CachedStore) UpdateDelivery(d Delivery) error {
+	return s.deliveries.Put(d, func(v memstore.View[Delivery]) error {
+		if _, ok := v.Get(d.Id); !ok {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (s *CachedStore) ListDeliveries(webhookID string, limit int) ([]Delivery, error) {
+	return newestFirst(s.deliveries.Find("webhook", webhookID), limit), nil
+}
+
+func (s *CachedStore) DueDeliveries(now time.Time) ([]Delivery, error) {
+	due := []Delivery{}
+	for _, d := range s.deliveries.Find("status", string(StatusPending)) {
+		if isDue(d, now) {
+			due = append(due, d)
+		}
+	}
+	return due, nil
+}
+
+func (s *CachedStore) DeleteDeliveriesBefore(t time.Time) error {
+	for _, d := range s.deliveries.List() {
+		if !isStale(d, t) {
+			continue
+		}
+		if _, err := s.deliveries.Delete(d.Id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
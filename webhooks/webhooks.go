@@ -0,0 +1,347 @@
+// Package webhooks - исходящие вебхуки: пользователь регистрирует URL с секретом
+// и получает подписанные JSON-уведомления о своих вакансиях и анкетах.
+// Неудачные доставки повторяются с нарастающей паузой, все попытки видны в журнале.
+package webhooks
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"talant/auth"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Event - событие, о котором сообщает вебхук
+type Event string
+
+const (
+	EventJobCreated    Event = "job.created"
+	EventJobUpdated    Event = "job.updated"
+	EventJobDeleted    Event = "job.deleted"
+	EventAnketaCreated Event = "anketa.created"
+	EventAnketaUpdated Event = "anketa.updated"
+	EventAnketaDeleted Event = "anketa.deleted"
+	// EventPing отправляет только тестовая доставка
+	EventPing Event = "ping"
+)
+
+// events - события, на которые можно подписаться
+var events = []Event{EventJobCreated, EventJobUpdated, EventJobDeleted, EventAnketaCreated, EventAnketaUpdated,
+	EventAnketaDeleted}
+
+const (
+	maxWebhooks     = 10   // вебхуков у одного пользователя
+	maxURLLength    = 2000 // символов в URL
+	minSecretLength = 16
+)
+
+// Webhook - зарегистрированный пользователем адрес доставки
+type Webhook struct {
+	Id     string `json:"id"`
+	UserID string `json:"user_id"`
+	URL    string `json:"url"`
+	// Secret - ключ HMAC-подписи; в ответах показывается только при создании и смене
+	Secret string  `json:"secret"`
+	Events []Event `json:"events"`
+	// Active - выключенный вебхук ничего не получает
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (w Webhook) subscribed(e Event) bool {
+	return w.Active && slices.Contains(w.Events, e)
+}
+
+// webhookView - вебхук в ответе; Secret перекрывает поле вебхука и пуст,
+// если секрет не нужно показывать
+type webhookView struct {
+	Webhook
+	Secret string `json:"secret,omitempty"`
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// checkURL - вебхук принимает только абсолютные http(s)-адреса
+func checkURL(raw string) error {
+	if raw == "" {
+		return errors.New("URL is required")
+	}
+	if utf8.RuneCountInString(raw) > maxURLLength {
+		return fmt.Errorf("URL is too long (max %d characters)", maxURLLength)
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("URL must be an absolute http or https address")
+	}
+	return nil
+}
+
+// parseEvents читает поле events (через запятую или несколькими значениями);
+// без него - все события
+func parseEvents(values []string) ([]Event, error) {
+	var list []Event
+	for _, v := range values {
+		for _, name := range strings.Split(v, ",") {
+			e := Event(strings.TrimSpace(name))
+			if e == "" {
+				continue
+			}
+			if !slices.Contains(events, e) {
+				return nil, fmt.Errorf("Unknown event: %s", e)
+			}
+			if !slices.Contains(list, e) {
+				list = append(list, e)
+			}
+		}
+	}
+	if len(list) == 0 {
+		return slices.Clone(events), nil
+	}
+	return list, nil
+}
+
+// readSecret - секрет из формы или новый случайный
+func readSecret(r *http.Request) (string, error) {
+	secret := r.FormValue("secret")
+	if secret == "" {
+		return newSecret()
+	}
+	if len(secret) < minSecretLength {
+		return "", fmt.Errorf("Secret is too short (min %d characters)", minSecretLength)
+	}
+	return secret, nil
+}
+
+// loadOwned загружает вебхук из пути запроса; чужой вебхук - 404, как несуществующий
+func loadOwned(w http.ResponseWriter, r *http.Request) (Webhook, bool) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return Webhook{}, false
+	}
+	hook, err := store.Get(r.PathValue("id"))
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		http.Error(w, "Error loading webhooks", http.StatusInternalServerError)
+		return Webhook{}, false
+	}
+	if err != nil || hook.UserID != user.ID {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return Webhook{}, false
+	}
+	return hook, true
+}
+
+func writeWebhook(w http.ResponseWriter, status int, view webhookView) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(view)
+}
+
+// CreateHandler регистрирует вебхук (POST /api/webhooks, поля url, events, secret).
+// Без secret он генерируется; секрет возвращается в ответе один раз.
+func CreateHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	rawURL := strings.TrimSpace(r.FormValue("url"))
+	if err := checkURL(rawURL); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	subscribed, err := parseEvents(r.Form["events"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	secret, err := readSecret(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	list, err := store.ListByUser(user.ID)
+	if err != nil {
+		http.Error(w, "Error loading webhooks", http.StatusInternalServerError)
+		return
+	}
+	if len(list) >= maxWebhooks {
+		http.Error(w, fmt.Sprintf("Too many webhooks (max %d)", maxWebhooks), http.StatusConflict)
+		return
+	}
+
+	now := time.Now()
+	hook := Webhook{
+		Id:        uuid.New().String(),
+		UserID:    user.ID,
+		URL:       rawURL,
+		Secret:    secret,
+		Events:    subscribed,
+		Active:    true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := store.Create(hook); err != nil {
+		http.Error(w, "Error saving webhooks", http.StatusInternalServerError)
+		return
+	}
+	fmt.Printf("Вебхук %s пользователя %s: %s\n", hook.Id, user.Username, hook.URL)
+	writeWebhook(w, http.StatusCreated, webhookView{Webhook: hook, Secret: secret})
+}
+
+// ListHandler - вебхуки текущего пользователя без секретов (GET /api/webhooks)
+func ListHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.MustUser(w, r)
+	if !ok {
+		return
+	}
+	list, err := store.ListByUser(user.ID)
+	if err != nil {
+		http.Error(w, "Error loading webhooks", http.StatusInternalServerError)
+		return
+	}
+	result := make([]webhookView, 0, len(list))
+	for _, hook := range list {
+		result = append(result, webhookView{Webhook: hook})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetHandler - один вебхук без секрета (GET /api/webhooks/{id})
+func GetHandler(w http.ResponseWriter, r *http.Request) {
+	hook, ok := loadOwned(w, r)
+	if !ok {
+		return
+	}
+	writeWebhook(w, http.StatusOK, webhookView{Webhook: hook})
+}
+
+// UpdateHandler меняет вебхук (PUT /api/webhooks/{id}). Меняются только
+// переданные поля: url, events, active; rotate_secret=1 или secret - новый секрет.
+func UpdateHandler(w http.ResponseWriter, r *http.Request) {
+	hook, ok := loadOwned(w, r)
+	if !ok {
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad form", http.StatusBadRequest)
+		return
+	}
+
+	if r.Form.Has("url") {
+		rawURL := strings.TrimSpace(r.FormValue("url"))
+		if err := checkURL(rawURL); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		hook.URL = rawURL
+	}
+	if r.Form.Has("events") {
+		subscribed, err := parseEvents(r.Form["events"])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		hook.Events = subscribed
+	}
+	if r.Form.Has("active") {
+		active, err := strconv.ParseBool(r.FormValue("active"))
+		if err != nil {
+			http.Error(w, "Invalid active", http.StatusBadRequest)
+			return
+		}
+		hook.Active = active
+	}
+	var shown string
+	if r.Form.Has("secret") || r.FormValue("rotate_secret") == "1" {
+		secret, err := readSecret(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		hook.Secret, shown = secret, secret
+	}
+
+	hook.UpdatedAt = time.Now()
+	if err := store.Update(hook); err != nil {
+		http.Error(w, "Error saving webhooks", http.StatusInternalServerError)
+		return
+	}
+	writeWebhook(w, http.StatusOK, webhookView{Webhook: hook, Secret: shown})
+}
+
+// DeleteHandler удаляет вебхук вместе с журналом (DELETE /api/webhooks/{id})
+func DeleteHandler(w http.ResponseWriter, r *http.Request) {
+	hook, ok := loadOwned(w, r)
+	if !ok {
+		return
+	}
+	if err := store.Delete(hook.Id); err != nil {
+		http.Error(w, "Error saving webhooks", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeliveriesHandler - журнал доставок вебхука, новые первыми
+// (GET /api/webhooks/{id}/deliveries, limit до 100)
+func DeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	hook, ok := loadOwned(w, r)
+	if !ok {
+		return
+	}
+	limit := 20
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, 100)
+	}
+	list, err := store.ListDeliveries(hook.Id, limit)
+	if err != nil {
+		http.Error(w, "Error loading deliveries", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// TestHandler сразу отправляет вебхуку событие ping (POST /api/webhooks/{id}/test)
+// и возвращает результат доставки. Тестовая доставка не повторяется.
+func TestHandler(w http.ResponseWriter, r *http.Request) {
+	hook, ok := loadOwned(w, r)
+	if !ok {
+		return
+	}
+	d, err := newDelivery(hook, EventPing, map[string]string{"webhook_id": hook.Id})
+	if err != nil {
+		http.Error(w, "Error building payload", http.StatusInternalServerError)
+		return
+	}
+	attempt(r.Context(), hook, &d, false)
+	if err := store.AddDelivery(d); err != nil {
+		http.Error(w, "Error saving deliveries", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(d)
+}